@@ -1,5 +1,10 @@
 package chartmogul
 
+import (
+	"fmt"
+	"time"
+)
+
 const (
 	accountEndpoint = "account"
 )
@@ -18,3 +23,15 @@ func (api API) RetrieveAccount() (*Account, error) {
 	accountUUID := ""
 	return result, api.retrieve(accountEndpoint, accountUUID, result)
 }
+
+// Location returns the time zone of the account, UTC if not set.
+func (account *Account) Location() (*time.Location, error) {
+	if account.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(account.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("chartmogul: unknown account time zone %q: %v", account.TimeZone, err)
+	}
+	return loc, nil
+}
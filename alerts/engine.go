@@ -0,0 +1,244 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Client retrieves the metrics, eg. *chartmogul.API.
+type Client interface {
+	MetricsRetrieveMRR(metricsFilter *cm.MetricsFilter) (*cm.MRRResult, error)
+	MetricsRetrieveARR(metricsFilter *cm.MetricsFilter) (*cm.ARRResult, error)
+	MetricsRetrieveARPA(metricsFilter *cm.MetricsFilter) (*cm.ARPAResult, error)
+	MetricsRetrieveASP(metricsFilter *cm.MetricsFilter) (*cm.ASPResult, error)
+	MetricsRetrieveCustomerCount(metricsFilter *cm.MetricsFilter) (*cm.CustomerCountResult, error)
+	MetricsRetrieveCustomerChurnRate(metricsFilter *cm.MetricsFilter) (*cm.CustomerChurnRateResult, error)
+	MetricsRetrieveMRRChurnRate(metricsFilter *cm.MetricsFilter) (*cm.MRRChurnRateResult, error)
+	MetricsRetrieveLTV(metricsFilter *cm.MetricsFilter) (*cm.LTVResult, error)
+}
+
+// Engine checks the rules against metrics of a relative date range and notifies about new alerts.
+type Engine struct {
+	// Account sets the time zone and week start of the range, UTC and Monday if nil.
+	Account *cm.Account
+	Rules   []Rule
+	// Notifiers are remembered by position for the delivery of alerts, see Check.
+	Notifiers []Notifier
+	// State remembers the fired alerts, so each is notified only once.
+	State State
+	// OnError receives the errors of scheduled checks, they're dropped if nil.
+	OnError func(error)
+
+	client   Client
+	window   cm.RelativeRange
+	interval cm.Interval
+}
+
+// NewEngine creates an engine checking metrics of the window with the given interval,
+// eg. LastPeriods(60, IntervalDay) for daily rules with enough history for outliers.
+// Alerts are remembered in memory until State is set.
+func NewEngine(client Client, window cm.RelativeRange, interval cm.Interval) *Engine {
+	return &Engine{
+		State:    NewMemoryState(),
+		client:   client,
+		window:   window,
+		interval: interval,
+	}
+}
+
+// Check retrieves the metrics of the rules, checks them once and notifies about alerts which
+// weren't delivered before. It returns the alerts not yet delivered to all notifiers.
+//
+// Delivery is remembered per notifier, so when one fails, only it is retried with the next check.
+func (e *Engine) Check(ctx context.Context) ([]Alert, error) {
+	filter, err := cm.NewMetricsFilter().Relative(e.window).Interval(e.interval).Build(e.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range e.Rules {
+		if v, ok := rule.(validator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fetch := &fetcher{client: e.client, filter: filter, series: map[Metric][]Point{}}
+	var alerts []Alert
+	for _, rule := range e.Rules {
+		points, ok := fetch.series[rule.Metric()]
+		if !ok {
+			if points, err = fetch.retrieve(rule.Metric()); err != nil {
+				return nil, err
+			}
+			fetch.series[rule.Metric()] = points
+		}
+		alert := rule.Check(points)
+		if alert == nil || e.State.Fired(alert.key()) {
+			continue
+		}
+		alerts = append(alerts, *alert)
+	}
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+
+	var failed error
+	for i, notifier := range e.Notifiers {
+		var undelivered []Alert
+		for _, alert := range alerts {
+			if !e.State.Fired(deliveryKey(alert, i)) {
+				undelivered = append(undelivered, alert)
+			}
+		}
+		if len(undelivered) == 0 {
+			continue
+		}
+		if err := notifier.Notify(ctx, undelivered); err != nil {
+			if failed == nil {
+				failed = err
+			}
+			continue
+		}
+		for _, alert := range undelivered {
+			if err := e.State.Record(deliveryKey(alert, i)); err != nil {
+				return alerts, err
+			}
+		}
+	}
+	if failed != nil {
+		return alerts, failed
+	}
+	for _, alert := range alerts {
+		if err := e.State.Record(alert.key()); err != nil {
+			return alerts, err
+		}
+	}
+	return alerts, nil
+}
+
+// deliveryKey identifies the delivery of an alert to the i-th notifier.
+func deliveryKey(alert Alert, i int) string {
+	return fmt.Sprintf("%v@notifier/%d", alert.key(), i)
+}
+
+// Run checks the rules right away and then every period until the context is done.
+func (e *Engine) Run(ctx context.Context, every time.Duration) error {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if _, err := e.Check(ctx); err != nil && e.OnError != nil {
+			e.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetcher retrieves the series of one check, MRR with its movements only once.
+type fetcher struct {
+	client Client
+	filter *cm.MetricsFilter
+	series map[Metric][]Point
+	mrr    *cm.MRRResult
+}
+
+// retrieve returns the series of the metric.
+func (f *fetcher) retrieve(metric Metric) ([]Point, error) {
+	var points []Point
+	switch metric {
+	case MRR, MRRNewBusiness, MRRExpansion, MRRContraction, MRRChurn, MRRReactivation:
+		if f.mrr == nil {
+			result, err := f.client.MetricsRetrieveMRR(f.filter)
+			if err != nil {
+				return nil, err
+			}
+			f.mrr = result
+		}
+		for _, entry := range f.mrr.Entries {
+			value := map[Metric]float64{
+				MRR:             entry.MRR,
+				MRRNewBusiness:  entry.MRRNewBusiness,
+				MRRExpansion:    entry.MRRExpansion,
+				MRRContraction:  entry.MRRContraction,
+				MRRChurn:        entry.MRRChurn,
+				MRRReactivation: entry.MRRReactivation,
+			}[metric]
+			points = append(points, Point{Date: entry.Date, Value: value, PercentageChange: entry.PercentageChange})
+		}
+		if metric != MRR {
+			for i := range points {
+				points[i].PercentageChange = 0
+				if i > 0 {
+					points[i].PercentageChange = cm.PercentageChange(points[i-1].Value, points[i].Value)
+				}
+			}
+		}
+	case ARR:
+		result, err := f.client.MetricsRetrieveARR(f.filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range result.Entries {
+			points = append(points, Point{Date: entry.Date, Value: entry.ARR, PercentageChange: entry.PercentageChange})
+		}
+	case ARPA:
+		result, err := f.client.MetricsRetrieveARPA(f.filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range result.Entries {
+			points = append(points, Point{Date: entry.Date, Value: entry.ARPA, PercentageChange: entry.PercentageChange})
+		}
+	case ASP:
+		result, err := f.client.MetricsRetrieveASP(f.filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range result.Entries {
+			points = append(points, Point{Date: entry.Date, Value: entry.ASP, PercentageChange: entry.PercentageChange})
+		}
+	case Customers:
+		result, err := f.client.MetricsRetrieveCustomerCount(f.filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range result.Entries {
+			points = append(points, Point{Date: entry.Date, Value: float64(entry.Customers), PercentageChange: entry.PercentageChange})
+		}
+	case CustomerChurnRate:
+		result, err := f.client.MetricsRetrieveCustomerChurnRate(f.filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range result.Entries {
+			points = append(points, Point{Date: entry.Date, Value: entry.CustomerChurnRate, PercentageChange: entry.PercentageChange})
+		}
+	case MRRChurnRate:
+		result, err := f.client.MetricsRetrieveMRRChurnRate(f.filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range result.Entries {
+			points = append(points, Point{Date: entry.Date, Value: entry.MRRChurnRate, PercentageChange: entry.PercentageChange})
+		}
+	case LTV:
+		result, err := f.client.MetricsRetrieveLTV(f.filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range result.Entries {
+			points = append(points, Point{Date: entry.Date, Value: entry.LTV, PercentageChange: entry.PercentageChange})
+		}
+	default:
+		return nil, fmt.Errorf("chartmogul: unknown metric %q", metric)
+	}
+	return points, nil
+}
@@ -0,0 +1,207 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// fakeClient serves MRR and ARPA, other metrics aren't expected.
+type fakeClient struct {
+	Client
+	mrr      *cm.MRRResult
+	arpa     *cm.ARPAResult
+	requests int
+}
+
+func (c *fakeClient) MetricsRetrieveMRR(filter *cm.MetricsFilter) (*cm.MRRResult, error) {
+	c.requests++
+	if filter.Interval != cm.IntervalDay || filter.StartDate == "" {
+		return nil, errors.New("unexpected filter")
+	}
+	return c.mrr, nil
+}
+
+func (c *fakeClient) MetricsRetrieveARPA(filter *cm.MetricsFilter) (*cm.ARPAResult, error) {
+	c.requests++
+	return c.arpa, nil
+}
+
+func testClient() *fakeClient {
+	return &fakeClient{
+		mrr: &cm.MRRResult{Entries: []*cm.MRRMetrics{
+			{Date: "2022-05-29", MRR: 1000, MRRContraction: -10},
+			{Date: "2022-05-30", MRR: 990, MRRContraction: -10},
+			{Date: "2022-05-31", MRR: 900, MRRContraction: -90},
+		}},
+		arpa: &cm.ARPAResult{Entries: []*cm.ARPAMetrics{
+			{Date: "2022-05-30", ARPA: 55},
+			{Date: "2022-05-31", ARPA: 45, PercentageChange: -18.2},
+		}},
+	}
+}
+
+func TestEngineCheck(t *testing.T) {
+	client := testClient()
+	out := &bytes.Buffer{}
+	engine := NewEngine(client, cm.LastPeriods(3, cm.IntervalDay), cm.IntervalDay)
+	engine.Notifiers = []Notifier{NewWriter(out)}
+	engine.Rules = []Rule{
+		PercentageChange{ID: "contraction-jump", Of: MRRContraction, Comparison: Above, Percent: 100},
+		Threshold{ID: "mrr-low", Of: MRR, Comparison: Below, Limit: 500},
+		PercentageChange{ID: "arpa-drop", Of: ARPA, Comparison: Below, Percent: -10},
+	}
+
+	alerts, err := engine.Check(context.Background())
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	expected := "2022-05-31 contraction-jump: mrr-contraction changed by 800.00% to -90, above 100%\n" +
+		"2022-05-31 arpa-drop: arpa changed by -18.20% to 45, below -10%\n"
+	if len(alerts) != 2 || out.String() != expected {
+		spew.Dump(alerts, out.String())
+		t.Fatal("Unexpected alerts")
+	}
+	if client.requests != 2 {
+		t.Fatalf("Expected MRR to be retrieved once for two rules, got %d requests", client.requests)
+	}
+}
+
+func TestEngineRemembersAlerts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "alerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fired.json")
+
+	var posted []Alert
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("Unexpected headers %v", r.Header)
+				}
+				var body struct {
+					Alerts []Alert `json:"alerts"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Error(err)
+				}
+				posted = append(posted, body.Alerts...)
+			}))
+	defer server.Close()
+
+	check := func() []Alert {
+		state, err := NewFileState(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		engine := NewEngine(testClient(), cm.LastPeriods(3, cm.IntervalDay), cm.IntervalDay)
+		engine.State = state
+		engine.Rules = []Rule{Threshold{ID: "arpa-low", Of: ARPA, Comparison: Below, Limit: 50}}
+		engine.Notifiers = []Notifier{&Webhook{URL: server.URL, Header: http.Header{"Authorization": {"Bearer secret"}}}}
+		alerts, err := engine.Check(context.Background())
+		if err != nil {
+			spew.Dump(err)
+			t.Fatal("Not expected to fail")
+		}
+		return alerts
+	}
+
+	if alerts := check(); len(alerts) != 1 {
+		spew.Dump(alerts)
+		t.Fatal("Expected an alert")
+	}
+	if alerts := check(); len(alerts) != 0 {
+		spew.Dump(alerts)
+		t.Fatal("Expected the alert to be remembered")
+	}
+	if len(posted) != 1 || posted[0].Rule != "arpa-low" || posted[0].Value != 45 {
+		spew.Dump(posted)
+		t.Fatal("Unexpected webhook posts")
+	}
+}
+
+func TestWebhookFailureIsRetried(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+	defer server.Close()
+
+	engine := NewEngine(testClient(), cm.LastPeriods(3, cm.IntervalDay), cm.IntervalDay)
+	engine.Rules = []Rule{Threshold{ID: "arpa-low", Of: ARPA, Comparison: Below, Limit: 50}}
+	engine.Notifiers = []Notifier{&Webhook{URL: server.URL}}
+	for i := 0; i < 2; i++ {
+		if _, err := engine.Check(context.Background()); err == nil {
+			t.Fatal("Expected the webhook to fail every time")
+		}
+	}
+}
+
+// flakyNotifier fails until it's fixed and counts the delivered alerts.
+type flakyNotifier struct {
+	broken    bool
+	delivered int
+}
+
+func (n *flakyNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	if n.broken {
+		return errors.New("unavailable")
+	}
+	n.delivered += len(alerts)
+	return nil
+}
+
+func TestFailedNotifierIsRetriedAlone(t *testing.T) {
+	working, flaky := &flakyNotifier{}, &flakyNotifier{broken: true}
+	engine := NewEngine(testClient(), cm.LastPeriods(3, cm.IntervalDay), cm.IntervalDay)
+	engine.Rules = []Rule{Threshold{ID: "arpa-low", Of: ARPA, Comparison: Below, Limit: 50}}
+	engine.Notifiers = []Notifier{working, flaky}
+
+	if _, err := engine.Check(context.Background()); err == nil {
+		t.Fatal("Expected the broken notifier to fail")
+	}
+	flaky.broken = false
+	if alerts, err := engine.Check(context.Background()); err != nil || len(alerts) != 1 {
+		t.Fatalf("Expected the alert to be retried, got %v %v", alerts, err)
+	}
+	if alerts, err := engine.Check(context.Background()); err != nil || len(alerts) != 0 {
+		t.Fatalf("Expected the alert to be remembered, got %v %v", alerts, err)
+	}
+	if working.delivered != 1 || flaky.delivered != 1 {
+		t.Fatalf("Expected each notifier to get the alert once, got %v and %v", working.delivered, flaky.delivered)
+	}
+}
+
+var _ Client = &cm.API{}
+
+func TestEngineRun(t *testing.T) {
+	out := &bytes.Buffer{}
+	engine := NewEngine(testClient(), cm.LastPeriods(3, cm.IntervalDay), cm.IntervalDay)
+	engine.Rules = []Rule{Threshold{ID: "arpa-low", Of: ARPA, Comparison: Below, Limit: 50}}
+	engine.Notifiers = []Notifier{NewWriter(out)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := engine.Run(ctx, time.Hour); err != context.Canceled {
+		t.Fatalf("Expected run to end with the context, got %v", err)
+	}
+	if out.String() != "2022-05-31 arpa-low: arpa is 45, below 50\n" {
+		t.Fatalf("Expected a check before the first tick, got %q", out.String())
+	}
+}
@@ -0,0 +1,81 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Notifier delivers alerts.
+type Notifier interface {
+	Notify(ctx context.Context, alerts []Alert) error
+}
+
+// Webhook posts the alerts as JSON, {"alerts": [...]}, to a URL.
+type Webhook struct {
+	URL string
+	// Header is added to the requests, eg. for authorization.
+	Header http.Header
+	// Client sends the requests, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// Notify posts the alerts, responses other than 2xx are errors.
+func (w *Webhook) Notify(ctx context.Context, alerts []Alert) error {
+	body, err := json.Marshal(struct {
+		Alerts []Alert `json:"alerts"`
+	}{alerts})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range w.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()            //nolint
+	io.Copy(ioutil.Discard, res.Body) //nolint
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("chartmogul: webhook %v responded %v", w.URL, res.Status)
+	}
+	return nil
+}
+
+// Writer writes a line per alert, eg. to os.Stderr or a log file.
+type Writer struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewWriter creates a notifier writing to out.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Notify writes the alerts as "date rule: message" lines.
+func (w *Writer) Notify(ctx context.Context, alerts []Alert) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, alert := range alerts {
+		if _, err := fmt.Fprintf(w.out, "%v %v: %v\n", alert.Date, alert.Rule, alert.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+// Package alerts checks metrics against rules on a schedule and notifies about the alerts,
+// eg. churn spikes, sudden MRR contraction or ARPA dropping below a threshold.
+package alerts
+
+import (
+	"fmt"
+	"math"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Metric is a metric series the rules can check.
+type Metric string
+
+// Metrics available to rules; the MRR movements come from MetricsRetrieveMRR.
+const (
+	MRR               Metric = "mrr"
+	MRRNewBusiness    Metric = "mrr-new-business"
+	MRRExpansion      Metric = "mrr-expansion"
+	MRRContraction    Metric = "mrr-contraction"
+	MRRChurn          Metric = "mrr-churn"
+	MRRReactivation   Metric = "mrr-reactivation"
+	ARR               Metric = "arr"
+	ARPA              Metric = "arpa"
+	ASP               Metric = "asp"
+	Customers         Metric = "customers"
+	CustomerChurnRate Metric = "customer-churn-rate"
+	MRRChurnRate      Metric = "mrr-churn-rate"
+	LTV               Metric = "ltv"
+)
+
+// Point is one entry of a metric series.
+type Point struct {
+	Date  cm.Date `json:"date"`
+	Value float64 `json:"value"`
+	// PercentageChange is taken from the Metrics API entry; MRR movements don't have one there,
+	// so theirs is computed from the previous entry.
+	PercentageChange float64 `json:"percentage-change"`
+}
+
+// Alert is a rule violated by the latest point of a metric.
+type Alert struct {
+	Rule    string  `json:"rule"`
+	Metric  Metric  `json:"metric"`
+	Date    cm.Date `json:"date"`
+	Value   float64 `json:"value"`
+	Message string  `json:"message"`
+}
+
+// key identifies the alert for remembering it fired.
+func (a Alert) key() string {
+	return a.Rule + "/" + string(a.Metric) + "/" + string(a.Date)
+}
+
+// Rule checks a metric series.
+type Rule interface {
+	// Name identifies the rule in alerts.
+	Name() string
+	// Metric is the series the rule checks.
+	Metric() Metric
+	// Check returns an alert if the latest point violates the rule, nil otherwise.
+	// The points are in chronological order.
+	Check(points []Point) *Alert
+}
+
+// validator is implemented by rules with settings to check before they're run, eg. Outlier.
+type validator interface {
+	Validate() error
+}
+
+// Comparison of a value with a limit.
+type Comparison string
+
+// Comparisons of rules.
+const (
+	Above Comparison = "above"
+	Below Comparison = "below"
+)
+
+func (c Comparison) violated(value, limit float64) bool {
+	switch c {
+	case Above:
+		return value > limit
+	case Below:
+		return value < limit
+	}
+	return false
+}
+
+func latest(points []Point) *Point {
+	if len(points) == 0 {
+		return nil
+	}
+	return &points[len(points)-1]
+}
+
+// Threshold alerts when the latest value is above or below a static limit,
+// eg. ARPA below 50 or customer churn rate above 5.
+type Threshold struct {
+	ID         string
+	Of         Metric
+	Comparison Comparison
+	Limit      float64
+}
+
+// Name returns the ID of the rule.
+func (r Threshold) Name() string { return r.ID }
+
+// Metric returns the checked metric.
+func (r Threshold) Metric() Metric { return r.Of }
+
+// Check compares the latest value with the limit.
+func (r Threshold) Check(points []Point) *Alert {
+	p := latest(points)
+	if p == nil || !r.Comparison.violated(p.Value, r.Limit) {
+		return nil
+	}
+	return &Alert{
+		Rule: r.ID, Metric: r.Of, Date: p.Date, Value: p.Value,
+		Message: fmt.Sprintf("%v is %v, %v %v", r.Of, p.Value, r.Comparison, r.Limit),
+	}
+}
+
+// PercentageChange alerts when the latest percentage change is above or below a limit,
+// eg. MRR contraction growing above 50 percent or ARPA changing below -10 percent.
+type PercentageChange struct {
+	ID         string
+	Of         Metric
+	Comparison Comparison
+	Percent    float64
+}
+
+// Name returns the ID of the rule.
+func (r PercentageChange) Name() string { return r.ID }
+
+// Metric returns the checked metric.
+func (r PercentageChange) Metric() Metric { return r.Of }
+
+// Check compares the latest percentage change with the limit.
+func (r PercentageChange) Check(points []Point) *Alert {
+	p := latest(points)
+	if p == nil || len(points) < 2 || !r.Comparison.violated(p.PercentageChange, r.Percent) {
+		return nil
+	}
+	return &Alert{
+		Rule: r.ID, Metric: r.Of, Date: p.Date, Value: p.Value,
+		Message: fmt.Sprintf("%v changed by %.2f%% to %v, %v %v%%", r.Of, p.PercentageChange, p.Value, r.Comparison, r.Percent),
+	}
+}
+
+// Outlier alerts when the latest value is further than Sigmas standard deviations
+// from the mean of the Window points before it, eg. a daily churn spike.
+type Outlier struct {
+	ID string
+	Of Metric
+	// Window is the number of previous points, 30 if zero.
+	Window int
+	// Sigmas is the allowed distance from the mean, 3 if zero.
+	Sigmas float64
+	// Comparison limits the alerts to one direction, both if empty.
+	Comparison Comparison
+}
+
+// Name returns the ID of the rule.
+func (r Outlier) Name() string { return r.ID }
+
+// Metric returns the checked metric.
+func (r Outlier) Metric() Metric { return r.Of }
+
+// Validate rejects a negative window or sigmas.
+func (r Outlier) Validate() error {
+	if r.Window < 0 || r.Sigmas < 0 {
+		return fmt.Errorf("chartmogul: outlier rule %v needs a positive window and sigmas", r.ID)
+	}
+	return nil
+}
+
+// Check compares the latest value with the rolling window before it, which must be full.
+// After a flat window, eg. churn stuck at zero, any other value is an outlier.
+func (r Outlier) Check(points []Point) *Alert {
+	if r.Validate() != nil {
+		return nil
+	}
+	window, sigmas := r.Window, r.Sigmas
+	if window == 0 {
+		window = 30
+	}
+	if sigmas == 0 {
+		sigmas = 3
+	}
+	if len(points) < window+1 {
+		return nil
+	}
+	p := latest(points)
+	previous := points[len(points)-1-window : len(points)-1]
+	flat := true
+	var mean, variance float64
+	for _, q := range previous {
+		mean += q.Value
+		flat = flat && q.Value == previous[0].Value
+	}
+	mean /= float64(window)
+	if flat {
+		baseline := previous[0].Value
+		if p.Value == baseline || r.Comparison == Above && p.Value < baseline || r.Comparison == Below && p.Value > baseline {
+			return nil
+		}
+		return &Alert{
+			Rule: r.ID, Metric: r.Of, Date: p.Date, Value: p.Value,
+			Message: fmt.Sprintf("%v is %v, the previous %d were all %v", r.Of, p.Value, window, baseline),
+		}
+	}
+	for _, q := range previous {
+		variance += (q.Value - mean) * (q.Value - mean) / float64(window)
+	}
+	deviation := math.Sqrt(variance)
+	score := (p.Value - mean) / deviation
+	switch {
+	case r.Comparison == Above && score <= sigmas,
+		r.Comparison == Below && score >= -sigmas,
+		math.Abs(score) <= sigmas:
+		return nil
+	}
+	return &Alert{
+		Rule: r.ID, Metric: r.Of, Date: p.Date, Value: p.Value,
+		Message: fmt.Sprintf("%v is %v, %.1f standard deviations from the mean %.2f of the previous %d", r.Of, p.Value, score, mean, window),
+	}
+}
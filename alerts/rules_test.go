@@ -0,0 +1,96 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+func points(values ...float64) []Point {
+	result := make([]Point, len(values))
+	for i, value := range values {
+		result[i] = Point{Date: "2022-05-31", Value: value}
+		if i > 0 && values[i-1] != 0 {
+			result[i].PercentageChange = (value - values[i-1]) / values[i-1] * 100
+		}
+	}
+	return result
+}
+
+func TestThreshold(t *testing.T) {
+	rule := Threshold{ID: "low-arpa", Of: ARPA, Comparison: Below, Limit: 50}
+	if alert := rule.Check(points(40, 60)); alert != nil {
+		spew.Dump(alert)
+		t.Fatal("Only the latest point counts")
+	}
+	alert := rule.Check(points(60, 40))
+	if alert == nil || alert.Rule != "low-arpa" || alert.Value != 40 || alert.Message != "arpa is 40, below 50" {
+		spew.Dump(alert)
+		t.Fatal("Expected an alert")
+	}
+	if rule.Check(nil) != nil {
+		t.Fatal("Expected no alert without points")
+	}
+}
+
+func TestPercentageChange(t *testing.T) {
+	rule := PercentageChange{ID: "arpa-drop", Of: ARPA, Comparison: Below, Percent: -10}
+	if alert := rule.Check(points(100, 95)); alert != nil {
+		spew.Dump(alert)
+		t.Fatal("Expected no alert for a small drop")
+	}
+	if alert := rule.Check(points(100, 80)); alert == nil || alert.Message != "arpa changed by -20.00% to 80, below -10%" {
+		spew.Dump(alert)
+		t.Fatal("Expected an alert")
+	}
+}
+
+func TestOutlier(t *testing.T) {
+	rule := Outlier{ID: "churn-spike", Of: MRRChurn, Window: 5, Sigmas: 2}
+	if alert := rule.Check(points(-100, -110, -90, -100, -105, -95)); alert != nil {
+		spew.Dump(alert)
+		t.Fatal("Expected no alert for a usual value")
+	}
+	if alert := rule.Check(points(-100, -110, -90, -100, -105, -400)); alert == nil || alert.Value != -400 {
+		spew.Dump(alert)
+		t.Fatal("Expected an alert for a spike")
+	}
+	if alert := (Outlier{ID: "up", Of: MRRChurn, Window: 5, Sigmas: 2, Comparison: Above}).Check(points(-100, -110, -90, -100, -105, -400)); alert != nil {
+		spew.Dump(alert)
+		t.Fatal("Expected no alert in the other direction")
+	}
+	if alert := rule.Check(points(-100, -400)); alert != nil {
+		t.Fatal("Expected no alert without full window")
+	}
+}
+
+func TestOutlierAfterFlatWindow(t *testing.T) {
+	rule := Outlier{ID: "churn-spike", Of: MRRChurn, Window: 3}
+	if alert := rule.Check(points(0, 0, 0, 0)); alert != nil {
+		spew.Dump(alert)
+		t.Fatal("Expected no alert for an unchanged value")
+	}
+	if alert := rule.Check(points(0, 0, 0, -50)); alert == nil || alert.Message != "mrr-churn is -50, the previous 3 were all 0" {
+		spew.Dump(alert)
+		t.Fatal("Expected an alert for the first spike")
+	}
+	if alert := (Outlier{ID: "up", Of: MRRChurn, Window: 3, Comparison: Above}).Check(points(0, 0, 0, -50)); alert != nil {
+		spew.Dump(alert)
+		t.Fatal("Expected no alert in the other direction")
+	}
+}
+
+func TestOutlierNegativeWindow(t *testing.T) {
+	rule := Outlier{ID: "churn-spike", Of: MRRChurn, Window: -1}
+	if alert := rule.Check(points(0, -50)); alert != nil {
+		spew.Dump(alert)
+		t.Fatal("Expected no alert")
+	}
+	engine := NewEngine(testClient(), cm.LastPeriods(3, cm.IntervalDay), cm.IntervalDay)
+	engine.Rules = []Rule{rule}
+	if _, err := engine.Check(context.Background()); err == nil {
+		t.Fatal("Expected the rule to be rejected")
+	}
+}
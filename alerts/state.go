@@ -0,0 +1,93 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// State remembers which alerts fired.
+type State interface {
+	Fired(key string) bool
+	Record(key string) error
+}
+
+// MemoryState remembers the alerts until the process ends.
+type MemoryState struct {
+	mu    sync.Mutex
+	fired map[string]bool
+}
+
+// NewMemoryState creates an empty in-memory state.
+func NewMemoryState() *MemoryState {
+	return &MemoryState{fired: map[string]bool{}}
+}
+
+// Fired returns true for recorded alerts.
+func (s *MemoryState) Fired(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fired[key]
+}
+
+// Record remembers the alert.
+func (s *MemoryState) Record(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fired[key] = true
+	return nil
+}
+
+// FileState keeps the alerts in a JSON file, so they're remembered across restarts.
+type FileState struct {
+	memory *MemoryState
+	path   string
+}
+
+// NewFileState loads the alerts from the file, which doesn't need to exist yet.
+func NewFileState(path string) (*FileState, error) {
+	s := &FileState{memory: NewMemoryState(), path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		s.memory.fired[key] = true
+	}
+	return s, nil
+}
+
+// Fired returns true for recorded alerts.
+func (s *FileState) Fired(key string) bool {
+	return s.memory.Fired(key)
+}
+
+// Record remembers the alert and saves the file.
+func (s *FileState) Record(key string) error {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+	s.memory.fired[key] = true
+	keys := make([]string, 0, len(s.memory.fired))
+	for k := range s.memory.fired {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	// written aside and renamed, so a crash doesn't leave a truncated file
+	if err := ioutil.WriteFile(s.path+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(s.path+".tmp", s.path)
+}
@@ -0,0 +1,408 @@
+package analytics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// activeStatus is the MetricsCustomerSubscription.Status of subscriptions that haven't ended.
+const activeStatus = "active"
+
+// Types of subscription events setting the plan, quantity and amount of a subscription.
+var stateEventTypes = map[string]bool{
+	"subscription_start":            true,
+	"subscription_start_scheduled":  true,
+	"subscription_updated":          true,
+	"subscription_update_scheduled": true,
+}
+
+// Snapshot is a customer's state at the end of a date.
+//
+// MRR, ARR and Currency are from the latest activity until the date. The API reports subscriptions
+// only as they are now, so their state on the date is taken from their subscription events, see
+// SubscriptionAsOf.
+type Snapshot struct {
+	CustomerUUID string  `json:"customer-uuid"`
+	Date         cm.Date `json:"date"`
+	// Subscriptions running on the date, started on or before it and not ended by it.
+	Subscriptions []*SubscriptionAsOf `json:"subscriptions"`
+	// MRR and ARR of the latest activity until the date, in Currency.
+	// Both are zero and Currency is empty if the activities are in more currencies, see ByCurrency.
+	MRR      float64 `json:"mrr"`
+	ARR      float64 `json:"arr"`
+	Currency string  `json:"currency"`
+	// ByCurrency is the MRR by currency, set only for activities in more currencies.
+	ByCurrency map[string]float64 `json:"by-currency,omitempty"`
+	// LastActivity is the latest activity until the date, nil before the first one.
+	LastActivity *cm.MetricsCustomerActivity `json:"last-activity"`
+}
+
+// SubscriptionAsOf is a subscription running on the date of a snapshot.
+type SubscriptionAsOf struct {
+	ID         uint64       `json:"id"`
+	ExternalID string       `json:"external-id"`
+	StartDate  cm.Timestamp `json:"start-date"`
+	// Historical is true if the plan, quantity and amount are those of the latest subscription event
+	// effective on the date. Without events, eg. for subscriptions of billing system integrations,
+	// they're unknown and zero. So they are if the subscription's external ID is used in more data
+	// sources of the customer and the Import API can't tell which one it's in.
+	Historical     bool   `json:"historical"`
+	PlanExternalID string `json:"plan-external-id,omitempty"`
+	Quantity       int32  `json:"quantity,omitempty"`
+	// AmountInCents is the amount per billing period before tax, in minor units of Currency.
+	AmountInCents int32  `json:"amount-in-cents,omitempty"`
+	Currency      string `json:"currency,omitempty"`
+	// Event is the latest subscription event effective on the date, nil if not Historical.
+	Event *cm.SubscriptionEvent `json:"event,omitempty"`
+}
+
+// SnapshotDiff is the change of a customer's state between two dates.
+type SnapshotDiff struct {
+	From *Snapshot `json:"from"`
+	To   *Snapshot `json:"to"`
+	// Added are the subscriptions running on To but not on From, Removed the other way round.
+	Added   []*SubscriptionAsOf `json:"added"`
+	Removed []*SubscriptionAsOf `json:"removed"`
+	// MRRChange is the MRR of To minus that of From, zero for activities in more currencies.
+	MRRChange float64 `json:"mrr-change"`
+	// Activities are those after From until To, in order.
+	Activities []*cm.MetricsCustomerActivity `json:"activities"`
+}
+
+// CustomerState answers point in time questions about one customer from its subscriptions
+// and activities, read once by LoadCustomerState.
+type CustomerState struct {
+	customerUUID  string
+	c             calendar
+	subscriptions []*cm.MetricsCustomerSubscription
+	activities    []customerActivity
+	// events of each subscription by data source and external ID, in order of effect
+	events map[eventKey][]subscriptionEvent
+	// data source of the events of each subscription by external ID, unset if ambiguous
+	sources map[string]string
+}
+
+type eventKey struct {
+	dataSourceUUID         string
+	subscriptionExternalID string
+}
+
+type subscriptionEvent struct {
+	event *cm.SubscriptionEvent
+	at    time.Time
+	day   cm.Date
+}
+
+// LoadCustomerState reads all subscriptions, activities and subscription events of a customer.
+// Subscription events are listed for each of the customer's external IDs in each of its data sources.
+//
+// Dates follow the account's time zone, like the Metrics API; the account can be nil for UTC.
+func LoadCustomerState(api cm.IApi, customerUUID string, account *cm.Account) (*CustomerState, error) {
+	c, err := newCalendar("", account)
+	if err != nil {
+		return nil, err
+	}
+	s := &CustomerState{customerUUID: customerUUID, c: c}
+	subscriptions := cm.NewMetricsCustomerSubscriptionsIterator(api, customerUUID, nil)
+	for {
+		subscription, err := subscriptions.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := subscription.StartDate.Time(); err != nil {
+			return nil, fmt.Errorf("chartmogul: subscription %v: %v", subscription.ID, err)
+		}
+		if _, err := subscription.EndDate.Time(); err != nil && !subscription.EndDate.IsZero() {
+			return nil, fmt.Errorf("chartmogul: subscription %v: %v", subscription.ID, err)
+		}
+		s.subscriptions = append(s.subscriptions, subscription)
+	}
+
+	activities := cm.NewMetricsCustomerActivitiesIterator(api, customerUUID, nil)
+	for {
+		activity, err := activities.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		at, err := activity.Date.Time()
+		if err != nil {
+			return nil, fmt.Errorf("chartmogul: activity %v: %v", activity.ID, err)
+		}
+		day, _ := c.day(activity.Date)
+		s.activities = append(s.activities, customerActivity{activity: activity, at: at, day: day})
+	}
+	sort.SliceStable(s.activities, func(i, j int) bool { return s.activities[i].at.Before(s.activities[j].at) })
+
+	if err := s.loadEvents(api); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadEvents reads the subscription events of the customer, leaving out retracted ones.
+func (s *CustomerState) loadEvents(api cm.IApi) error {
+	customer, err := api.RetrieveCustomer(s.customerUUID)
+	if err != nil {
+		return err
+	}
+	externalIDs := customer.ExternalIDs
+	if len(externalIDs) == 0 && customer.ExternalID != "" {
+		externalIDs = []string{customer.ExternalID}
+	}
+	dataSourceUUIDs := customer.DataSourceUUIDs
+	if len(dataSourceUUIDs) == 0 {
+		dataSourceUUIDs = []string{customer.DataSourceUUID}
+	}
+
+	var events []*cm.SubscriptionEvent
+	seen := map[uint64]bool{}
+	// retracted events by ID or by data source and external ID
+	retracted := map[string]bool{}
+	for _, externalID := range externalIDs {
+		for _, dataSourceUUID := range dataSourceUUIDs {
+			filters := &cm.FilterSubscriptionEvents{CustomerExternalID: externalID, DataSourceUUID: dataSourceUUID}
+			cursor := &cm.Cursor{}
+			for {
+				result, err := api.ListSubscriptionEvents(filters, cursor)
+				if err != nil {
+					return err
+				}
+				for _, event := range result.SubscriptionEvents {
+					if seen[event.ID] {
+						continue
+					}
+					seen[event.ID] = true
+					events = append(events, event)
+					if event.RetractedEventId != "" {
+						retracted[event.RetractedEventId] = true
+						retracted[event.DataSourceUUID+"/"+event.RetractedEventId] = true
+					}
+				}
+				if !result.HasMore || result.Cursor == "" {
+					break
+				}
+				cursor.Cursor = result.Cursor
+			}
+		}
+	}
+
+	s.events = map[eventKey][]subscriptionEvent{}
+	sources := map[string]map[string]bool{}
+	for _, event := range events {
+		if !stateEventTypes[event.EventType] || retracted[strconv.FormatUint(event.ID, 10)] ||
+			(event.ExternalID != "" && retracted[event.DataSourceUUID+"/"+event.ExternalID]) {
+			continue
+		}
+		date := event.EffectiveDate
+		if date.IsZero() {
+			date = event.EventDate
+		}
+		at, err := date.Time()
+		if err != nil {
+			return fmt.Errorf("chartmogul: subscription event %v: %v", event.ID, err)
+		}
+		day, _ := s.c.day(date)
+		key := eventKey{event.DataSourceUUID, event.SubscriptionExternalID}
+		s.events[key] = append(s.events[key], subscriptionEvent{event: event, at: at, day: day})
+		if sources[key.subscriptionExternalID] == nil {
+			sources[key.subscriptionExternalID] = map[string]bool{}
+		}
+		sources[key.subscriptionExternalID][key.dataSourceUUID] = true
+	}
+	for _, events := range s.events {
+		sort.SliceStable(events, func(i, j int) bool {
+			if !events[i].at.Equal(events[j].at) {
+				return events[i].at.Before(events[j].at)
+			}
+			return events[i].event.EventOrder < events[j].event.EventOrder
+		})
+	}
+	return s.loadSources(api, sources)
+}
+
+// loadSources sets the data source of each subscription with events, by external ID. The Metrics API
+// doesn't return the data source of a subscription, so if its events are in more data sources
+// it's taken from the subscriptions of the Import API, and left unset if it's in more too.
+func (s *CustomerState) loadSources(api cm.IApi, sources map[string]map[string]bool) error {
+	s.sources = map[string]string{}
+	ambiguous := false
+	for externalID, dataSources := range sources {
+		if len(dataSources) > 1 {
+			ambiguous = true
+			continue
+		}
+		for dataSourceUUID := range dataSources {
+			s.sources[externalID] = dataSourceUUID
+		}
+	}
+	if !ambiguous {
+		return nil
+	}
+
+	imported := map[string]map[string]bool{}
+	cursor := &cm.Cursor{}
+	for {
+		result, err := api.ListSubscriptions(cursor, s.customerUUID)
+		if err != nil {
+			return err
+		}
+		for _, subscription := range result.Subscriptions {
+			if imported[subscription.ExternalID] == nil {
+				imported[subscription.ExternalID] = map[string]bool{}
+			}
+			imported[subscription.ExternalID][subscription.DataSourceUUID] = true
+		}
+		if !result.HasMore || result.Cursor == "" {
+			break
+		}
+		cursor.Cursor = result.Cursor
+	}
+	for externalID, dataSources := range sources {
+		if len(dataSources) == 1 || len(imported[externalID]) != 1 {
+			continue
+		}
+		for dataSourceUUID := range imported[externalID] {
+			if dataSources[dataSourceUUID] {
+				s.sources[externalID] = dataSourceUUID
+			}
+		}
+	}
+	return nil
+}
+
+// CustomerAsOf returns the state of a customer at the end of the date, see LoadCustomerState.
+func CustomerAsOf(api cm.IApi, customerUUID string, date cm.Date, account *cm.Account) (*Snapshot, error) {
+	s, err := LoadCustomerState(api, customerUUID, account)
+	if err != nil {
+		return nil, err
+	}
+	return s.At(date)
+}
+
+// At returns the state of the customer at the end of the date.
+func (s *CustomerState) At(date cm.Date) (*Snapshot, error) {
+	t, err := date.Time()
+	if err != nil {
+		return nil, err
+	}
+	date = cm.NewDate(t)
+	snapshot := &Snapshot{CustomerUUID: s.customerUUID, Date: date, Subscriptions: []*SubscriptionAsOf{}}
+	for _, subscription := range s.subscriptions {
+		if s.running(subscription, date) {
+			snapshot.Subscriptions = append(snapshot.Subscriptions, s.subscriptionAt(subscription, date))
+		}
+	}
+
+	latest := map[string]*cm.MetricsCustomerActivity{}
+	for _, a := range s.activities {
+		if dateAfter(a.day, date) {
+			break
+		}
+		latest[a.activity.Currency] = a.activity
+		snapshot.LastActivity = a.activity
+	}
+	switch {
+	case len(latest) == 1:
+		snapshot.MRR = snapshot.LastActivity.ActivityMrr
+		snapshot.ARR = snapshot.LastActivity.ActivityArr
+		snapshot.Currency = snapshot.LastActivity.Currency
+	case len(latest) > 1:
+		snapshot.ByCurrency = map[string]float64{}
+		for currency, activity := range latest {
+			snapshot.ByCurrency[currency] = activity.ActivityMrr
+		}
+	}
+	return snapshot, nil
+}
+
+// Diff returns the change of the customer's state from the end of one date to the end of another.
+func (s *CustomerState) Diff(from, to cm.Date) (*SnapshotDiff, error) {
+	before, err := s.At(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.At(to)
+	if err != nil {
+		return nil, err
+	}
+	if dateAfter(before.Date, after.Date) {
+		return nil, fmt.Errorf("chartmogul: %v is after %v", from, to)
+	}
+	d := &SnapshotDiff{
+		From:       before,
+		To:         after,
+		Added:      subtractSubscriptions(after.Subscriptions, before.Subscriptions),
+		Removed:    subtractSubscriptions(before.Subscriptions, after.Subscriptions),
+		Activities: []*cm.MetricsCustomerActivity{},
+	}
+	if before.ByCurrency == nil && after.ByCurrency == nil {
+		d.MRRChange = after.MRR - before.MRR
+	}
+	for _, a := range s.activities {
+		if dateAfter(a.day, before.Date) && !dateAfter(a.day, after.Date) {
+			d.Activities = append(d.Activities, a.activity)
+		}
+	}
+	return d, nil
+}
+
+// running is true if the subscription started on or before the date and didn't end by it.
+// Active subscriptions haven't ended, their end date is that of the current billing period.
+func (s *CustomerState) running(subscription *cm.MetricsCustomerSubscription, date cm.Date) bool {
+	start, _ := s.c.day(subscription.StartDate)
+	if dateAfter(start, date) {
+		return false
+	}
+	if subscription.Status == activeStatus || subscription.EndDate.IsZero() {
+		return true
+	}
+	end, _ := s.c.day(subscription.EndDate)
+	return dateAfter(end, date)
+}
+
+// subscriptionAt returns the state of the subscription at the end of the date from its latest event.
+func (s *CustomerState) subscriptionAt(subscription *cm.MetricsCustomerSubscription, date cm.Date) *SubscriptionAsOf {
+	state := &SubscriptionAsOf{ID: subscription.ID, ExternalID: subscription.ExternalID, StartDate: subscription.StartDate}
+	dataSourceUUID, ok := s.sources[subscription.ExternalID]
+	if !ok {
+		return state
+	}
+	for _, e := range s.events[eventKey{dataSourceUUID, subscription.ExternalID}] {
+		if dateAfter(e.day, date) {
+			break
+		}
+		state.Historical = true
+		state.PlanExternalID = e.event.PlanExternalID
+		state.Quantity = e.event.Quantity
+		state.AmountInCents = e.event.AmountInCents
+		state.Currency = e.event.Currency
+		state.Event = e.event
+	}
+	return state
+}
+
+// subtractSubscriptions returns the subscriptions of a not in b, by ID.
+func subtractSubscriptions(a, b []*SubscriptionAsOf) []*SubscriptionAsOf {
+	ids := map[uint64]bool{}
+	for _, subscription := range b {
+		ids[subscription.ID] = true
+	}
+	rest := []*SubscriptionAsOf{}
+	for _, subscription := range a {
+		if !ids[subscription.ID] {
+			rest = append(rest, subscription)
+		}
+	}
+	return rest
+}
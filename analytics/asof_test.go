@@ -0,0 +1,145 @@
+package analytics
+
+import (
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// asOfAPI serves the customer's subscriptions and subscription events next to the activities of historyAPI.
+type asOfAPI struct {
+	historyAPI
+	subscriptions []*cm.MetricsCustomerSubscription
+	events        []*cm.SubscriptionEvent
+	imported      []cm.Subscription
+}
+
+func (api *asOfAPI) ListSubscriptions(cursor *cm.Cursor, customerUUID string) (*cm.Subscriptions, error) {
+	return &cm.Subscriptions{Subscriptions: api.imported}, nil
+}
+
+func (api *asOfAPI) ListSubscriptionEvents(filters *cm.FilterSubscriptionEvents, cursor *cm.Cursor) (*cm.SubscriptionEvents, error) {
+	result := &cm.SubscriptionEvents{SubscriptionEvents: []*cm.SubscriptionEvent{}}
+	for _, event := range api.events {
+		if event.CustomerExternalID == filters.CustomerExternalID && event.DataSourceUUID == filters.DataSourceUUID {
+			result.SubscriptionEvents = append(result.SubscriptionEvents, event)
+		}
+	}
+	return result, nil
+}
+
+func (api *asOfAPI) MetricsListCustomerSubscriptions(cursor *cm.Cursor, customerUUID string) (*cm.MetricsCustomerSubscriptions, error) {
+	return &cm.MetricsCustomerSubscriptions{Entries: api.subscriptions}, nil
+}
+
+func TestCustomerState(t *testing.T) {
+	api := &asOfAPI{
+		historyAPI: historyAPI{activities: []*cm.MetricsCustomerActivity{
+			{ID: 1, Date: "2022-01-10T10:00:00Z", Type: NewBusiness, ActivityMrr: 1000, ActivityArr: 12000, ActivityMrrMovement: 1000, Currency: "EUR"},
+			{ID: 3, Date: "2022-07-01T02:00:00Z", Type: Churn, ActivityMrr: 1500, ActivityArr: 18000, ActivityMrrMovement: -1000, Currency: "EUR"},
+			{ID: 2, Date: "2022-03-01T10:00:00Z", Type: NewBusiness, ActivityMrr: 2500, ActivityArr: 30000, ActivityMrrMovement: 1500, Currency: "EUR"},
+		}, customer: &cm.Customer{ExternalID: "c1", DataSourceUUIDs: []string{"ds_1", "ds_2"}}},
+		subscriptions: []*cm.MetricsCustomerSubscription{
+			{ID: 1, Plan: "Gold", Quantity: 1, Status: "cancelled", StartDate: "2022-01-10T10:00:00Z", EndDate: "2022-07-01T02:00:00Z"},
+			{ID: 2, ExternalID: "sub_2", Plan: "Silver", Quantity: 9, Status: "active", StartDate: "2022-03-01T10:00:00Z", EndDate: "2022-04-01T10:00:00Z"},
+		},
+		// sub_2 started with 3 seats and grew to 5, the update to 7 was retracted, another sub_2 of ds_1 isn't the customer's
+		events: []*cm.SubscriptionEvent{
+			{ID: 4, DataSourceUUID: "ds_2", CustomerExternalID: "c1", SubscriptionExternalID: "sub_2", EventType: "subscription_start",
+				EffectiveDate: "2022-03-01T10:00:00Z", PlanExternalID: "silver", Quantity: 3, AmountInCents: 1500, Currency: "EUR"},
+			{ID: 5, DataSourceUUID: "ds_2", CustomerExternalID: "c1", SubscriptionExternalID: "sub_2", EventType: "subscription_updated",
+				EffectiveDate: "2022-05-01", PlanExternalID: "silver", Quantity: 5, AmountInCents: 2500, Currency: "EUR"},
+			{ID: 6, DataSourceUUID: "ds_2", CustomerExternalID: "c1", SubscriptionExternalID: "sub_2", EventType: "subscription_updated",
+				EffectiveDate: "2022-06-01", PlanExternalID: "silver", Quantity: 7, AmountInCents: 3500, Currency: "EUR"},
+			{ID: 7, DataSourceUUID: "ds_2", CustomerExternalID: "c1", SubscriptionExternalID: "sub_2", EventType: "subscription_event_retracted",
+				EventDate: "2022-06-02", RetractedEventId: "6"},
+			{ID: 8, DataSourceUUID: "ds_1", CustomerExternalID: "c1", SubscriptionExternalID: "sub_2", EventType: "subscription_start",
+				EffectiveDate: "2022-04-15", PlanExternalID: "bronze", Quantity: 40, AmountInCents: 1000, Currency: "EUR"},
+		},
+		imported: []cm.Subscription{{ExternalID: "sub_2", DataSourceUUID: "ds_2"}},
+	}
+	s, err := LoadCustomerState(api, "cus_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		date          cm.Date
+		mrr           float64
+		subscriptions []uint64
+		quantities    []int32
+	}{
+		{"2022-01-09", 0, nil, nil},
+		{"2022-02-15", 1000, []uint64{1}, []int32{0}},
+		{"2022-04-30", 2500, []uint64{1, 2}, []int32{0, 3}},
+		{"2022-06-30", 2500, []uint64{1, 2}, []int32{0, 5}},
+		{"2022-07-01", 1500, []uint64{2}, []int32{5}},
+		{"2023-06-30", 1500, []uint64{2}, []int32{5}},
+	}
+	for _, c := range cases {
+		snapshot, err := s.At(c.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok := snapshot.MRR == c.mrr && len(snapshot.Subscriptions) == len(c.subscriptions)
+		for i := 0; ok && i < len(c.subscriptions); i++ {
+			subscription := snapshot.Subscriptions[i]
+			ok = subscription.ID == c.subscriptions[i] && subscription.Quantity == c.quantities[i] &&
+				subscription.Historical == (c.quantities[i] != 0)
+		}
+		if !ok {
+			spew.Dump(snapshot)
+			t.Fatalf("Unexpected snapshot on %v", c.date)
+		}
+	}
+
+	diff, err := s.Diff("2022-02-15", "2022-06-30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.MRRChange != 1500 || len(diff.Added) != 1 || diff.Added[0].PlanExternalID != "silver" || len(diff.Removed) != 0 ||
+		len(diff.Activities) != 1 || diff.Activities[0].ID != 2 || diff.To.Currency != "EUR" || diff.To.ARR != 30000 {
+		spew.Dump(diff)
+		t.Fatal("Unexpected diff")
+	}
+	if _, err := s.Diff("2022-06-30", "2022-02-15"); err == nil {
+		t.Fatal("Expected reversed dates to fail")
+	}
+
+	// In New York the churn happened on 2022-06-30, so the Gold subscription ended that day.
+	account := &cm.Account{TimeZone: "America/New_York"}
+	snapshot, err := CustomerAsOf(api, "cus_1", "2022-06-30", account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Subscriptions) != 1 || snapshot.Subscriptions[0].ID != 2 || snapshot.LastActivity.ID != 3 || snapshot.MRR != 1500 {
+		spew.Dump(snapshot)
+		t.Fatal("Unexpected snapshot in the account's time zone")
+	}
+}
+
+func TestCustomerStateAmbiguousSubscription(t *testing.T) {
+	api := &asOfAPI{
+		historyAPI: historyAPI{customer: &cm.Customer{ExternalID: "c1", DataSourceUUIDs: []string{"ds_1", "ds_2"}}},
+		subscriptions: []*cm.MetricsCustomerSubscription{
+			{ID: 1, ExternalID: "sub_1", Status: "active", StartDate: "2022-01-01T00:00:00Z"},
+			{ID: 2, ExternalID: "sub_1", Status: "active", StartDate: "2022-01-01T00:00:00Z"},
+		},
+		events: []*cm.SubscriptionEvent{
+			{ID: 1, DataSourceUUID: "ds_1", CustomerExternalID: "c1", SubscriptionExternalID: "sub_1", EventType: "subscription_start",
+				EffectiveDate: "2022-01-01", Quantity: 3},
+			{ID: 2, DataSourceUUID: "ds_2", CustomerExternalID: "c1", SubscriptionExternalID: "sub_1", EventType: "subscription_start",
+				EffectiveDate: "2022-01-01", Quantity: 5},
+		},
+		imported: []cm.Subscription{{ExternalID: "sub_1", DataSourceUUID: "ds_1"}, {ExternalID: "sub_1", DataSourceUUID: "ds_2"}},
+	}
+	snapshot, err := CustomerAsOf(api, "cus_1", "2022-06-30", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Subscriptions) != 2 || snapshot.Subscriptions[0].Historical || snapshot.Subscriptions[1].Historical {
+		spew.Dump(snapshot)
+		t.Fatal("Expected the events of subscriptions in more data sources not to be mixed up")
+	}
+}
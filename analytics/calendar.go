@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"fmt"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// calendar places activities in periods like the Metrics API does,
+// in the account's time zone with its week start.
+type calendar struct {
+	interval  cm.Interval
+	loc       *time.Location
+	weekStart time.Weekday
+}
+
+// newCalendar defaults to monthly periods, the account can be nil for UTC with weeks starting on Monday.
+func newCalendar(interval cm.Interval, account *cm.Account) (calendar, error) {
+	if interval == "" {
+		interval = cm.IntervalMonth
+	}
+	if !interval.IsValid() {
+		return calendar{}, fmt.Errorf("chartmogul: invalid interval %q", interval)
+	}
+	c := calendar{interval: interval, loc: time.UTC, weekStart: time.Monday}
+	if account != nil {
+		loc, err := account.Location()
+		if err != nil {
+			return calendar{}, err
+		}
+		c.loc, c.weekStart = loc, account.WeekStart()
+	}
+	return c, nil
+}
+
+// day returns the calendar date of the timestamp in the account's time zone.
+func (c calendar) day(ts cm.Timestamp) (cm.Date, error) {
+	return ts.Date(c.loc)
+}
+
+// period returns the first and last day of the period containing the date, which must be valid.
+func (c calendar) period(date cm.Date) (start, end cm.Date) {
+	t, _ := date.Time()
+	return cm.NewDate(c.interval.PeriodStart(t, c.weekStart)), cm.NewDate(c.interval.PeriodEnd(t, c.weekStart))
+}
+
+// next returns the first day of the period n periods after the one starting on start.
+func (c calendar) next(start cm.Date, n int) cm.Date {
+	t, _ := start.Time()
+	return cm.NewDate(c.interval.AddTo(t, n))
+}
+
+// dateAfter compares valid dates.
+func dateAfter(a, b cm.Date) bool {
+	ta, _ := a.Time()
+	tb, _ := b.Time()
+	return ta.After(tb)
+}
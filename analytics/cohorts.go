@@ -0,0 +1,209 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// CohortPeriod is the retention of a cohort at the end of a period after signup.
+// Retention rates are percentages of the cohort's customers and MRR at the end of its first period.
+type CohortPeriod struct {
+	// Date is the last day of the period.
+	Date      cm.Date `json:"date"`
+	Customers int     `json:"customers"`
+	MRR       float64 `json:"mrr"`
+	// CustomerRetention is the logo retention.
+	CustomerRetention float64 `json:"customer-retention"`
+	// NetRevenueRetention includes expansion and reactivation.
+	NetRevenueRetention float64 `json:"net-revenue-retention"`
+	// GrossRevenueRetention counts each customer's MRR up to its first period MRR.
+	GrossRevenueRetention float64 `json:"gross-revenue-retention"`
+}
+
+// Cohort are the customers who signed up within one period.
+type Cohort struct {
+	// Start is the first day of the signup period.
+	Start     cm.Date `json:"start"`
+	Customers int     `json:"customers"`
+	MRR       float64 `json:"mrr"`
+	// Periods start with the signup period and end with the matrix.
+	Periods []*CohortPeriod `json:"periods"`
+}
+
+// CohortMatrix is the retention of signup cohorts, in chronological order.
+type CohortMatrix struct {
+	Interval cm.Interval `json:"interval"`
+	Cohorts  []*Cohort   `json:"cohorts"`
+}
+
+type cohortCustomer struct {
+	activities []*cm.MetricsActivity
+	times      []time.Time
+	days       []cm.Date
+}
+
+// BuildCohorts reads all activities from the source and groups customers into cohorts
+// by the period of their first new business activity; customers without one are left out.
+// Their MRR is followed through the movements of later activities up to the period of the latest activity.
+//
+// Periods follow the account's time zone and week start, monthly or quarterly cohorts are usual.
+// The account can be nil for UTC periods with weeks starting on Monday.
+func BuildCohorts(source cm.MetricsActivitiesSource, interval cm.Interval, account *cm.Account) (*CohortMatrix, error) {
+	c, err := newCalendar(interval, account)
+	if err != nil {
+		return nil, err
+	}
+
+	customers := map[string]*cohortCustomer{}
+	var latest cm.Date
+	for {
+		activity, err := source.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !isMovement(activity.Type) {
+			continue
+		}
+		at, err := activity.Date.Time()
+		if err != nil {
+			return nil, fmt.Errorf("chartmogul: activity %v: %v", activity.UUID, err)
+		}
+		day, _ := c.day(activity.Date)
+		customer := customers[activity.CustomerUUID]
+		if customer == nil {
+			customer = &cohortCustomer{}
+			customers[activity.CustomerUUID] = customer
+		}
+		customer.activities = append(customer.activities, activity)
+		customer.times = append(customer.times, at)
+		customer.days = append(customer.days, day)
+		if latest == "" || dateAfter(day, latest) {
+			latest = day
+		}
+	}
+
+	matrix := &CohortMatrix{Interval: c.interval, Cohorts: []*Cohort{}}
+	if latest == "" {
+		return matrix, nil
+	}
+	_, last := c.period(latest)
+
+	cohorts := map[cm.Date]*Cohort{}
+	for _, customer := range customers {
+		sort.Stable(customer)
+		first := -1
+		for i, activity := range customer.activities {
+			if activity.Type == NewBusiness {
+				first = i
+				break
+			}
+		}
+		if first < 0 {
+			continue
+		}
+		start, _ := c.period(customer.days[first])
+		cohort := cohorts[start]
+		if cohort == nil {
+			cohort = &Cohort{Start: start}
+			for p := start; !dateAfter(p, last); p = c.next(p, 1) {
+				_, end := c.period(p)
+				cohort.Periods = append(cohort.Periods, &CohortPeriod{Date: end})
+			}
+			cohorts[start] = cohort
+		}
+
+		mrr, initial, i := 0.0, 0.0, first
+		for k, period := range cohort.Periods {
+			for ; i < len(customer.activities) && !dateAfter(customer.days[i], period.Date); i++ {
+				mrr += customer.activities[i].ActivityMrrMovement
+			}
+			if k == 0 {
+				initial = mrr
+				cohort.Customers++
+				cohort.MRR += initial
+			}
+			if mrr > 0 {
+				period.Customers++
+				period.MRR += mrr
+				period.GrossRevenueRetention += math.Min(mrr, initial)
+			}
+		}
+	}
+
+	for _, cohort := range cohorts {
+		for _, period := range cohort.Periods {
+			period.CustomerRetention = percentage(float64(period.Customers), float64(cohort.Customers))
+			period.NetRevenueRetention = percentage(period.MRR, cohort.MRR)
+			// the capped MRR summed above becomes the rate
+			period.GrossRevenueRetention = percentage(period.GrossRevenueRetention, cohort.MRR)
+		}
+		matrix.Cohorts = append(matrix.Cohorts, cohort)
+	}
+	sort.Slice(matrix.Cohorts, func(i, j int) bool {
+		return dateAfter(matrix.Cohorts[j].Start, matrix.Cohorts[i].Start)
+	})
+	return matrix, nil
+}
+
+func (c *cohortCustomer) Len() int           { return len(c.activities) }
+func (c *cohortCustomer) Less(i, j int) bool { return c.times[i].Before(c.times[j]) }
+func (c *cohortCustomer) Swap(i, j int) {
+	c.activities[i], c.activities[j] = c.activities[j], c.activities[i]
+	c.times[i], c.times[j] = c.times[j], c.times[i]
+	c.days[i], c.days[j] = c.days[j], c.days[i]
+}
+
+// percentage returns part of whole in percents, zero for zero whole.
+func percentage(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return 100 * part / whole
+}
+
+// cohortCSVHeader are the columns of CohortMatrix.EncodeCSV.
+var cohortCSVHeader = []string{
+	"cohort", "cohort-customers", "cohort-mrr", "period", "date", "customers", "mrr",
+	"customer-retention", "net-revenue-retention", "gross-revenue-retention",
+}
+
+// EncodeCSV writes one row per cohort and period, periods numbered from zero for the signup period.
+func (m *CohortMatrix) EncodeCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(cohortCSVHeader); err != nil {
+		return err
+	}
+	number := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	for _, cohort := range m.Cohorts {
+		for k, period := range cohort.Periods {
+			err := out.Write([]string{
+				cohort.Start.String(), strconv.Itoa(cohort.Customers), number(cohort.MRR),
+				strconv.Itoa(k), period.Date.String(), strconv.Itoa(period.Customers), number(period.MRR),
+				number(period.CustomerRetention), number(period.NetRevenueRetention), number(period.GrossRevenueRetention),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// EncodeJSON writes the matrix as JSON.
+func (m *CohortMatrix) EncodeJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(m)
+}
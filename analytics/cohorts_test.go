@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+func cohortActivities() *activities {
+	// newest first, as listed by the API
+	return &activities{
+		activity("2022-04-05T10:00:00Z", Reactivation, 500, "cus_2", "silver"),
+		activity("2022-03-15T10:00:00Z", Expansion, 100, "cus_3", "gold"),
+		activity("2022-03-10T10:00:00Z", Expansion, 600, "cus_1", "gold"),
+		activity("2022-03-01T10:00:00Z", Churn, -500, "cus_2", "silver"),
+		activity("2022-02-20T10:00:00Z", NewBusiness, 300, "cus_3", "gold"),
+		activity("2022-02-10T10:00:00Z", Contraction, -200, "cus_1", "gold"),
+		activity("2022-01-20T10:00:00Z", NewBusiness, 500, "cus_2", "silver"),
+		activity("2022-01-10T10:00:00Z", NewBusiness, 1000, "cus_1", "gold"),
+		// no new business within the feed
+		activity("2022-01-05T10:00:00Z", Churn, -100, "cus_4", "gold"),
+	}
+}
+
+func TestBuildCohorts(t *testing.T) {
+	matrix, err := BuildCohorts(cohortActivities(), cm.IntervalMonth, nil)
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	if len(matrix.Cohorts) != 2 || len(matrix.Cohorts[0].Periods) != 4 || len(matrix.Cohorts[1].Periods) != 3 {
+		spew.Dump(matrix)
+		t.Fatal("Expected January and February cohorts up to April")
+	}
+
+	january := matrix.Cohorts[0]
+	if january.Start != "2022-01-01" || january.Customers != 2 || january.MRR != 1500 {
+		spew.Dump(january)
+		t.Fatal("Unexpected January cohort")
+	}
+	expected := []CohortPeriod{
+		{Date: "2022-01-31", Customers: 2, MRR: 1500, CustomerRetention: 100, NetRevenueRetention: 100, GrossRevenueRetention: 100},
+		{Date: "2022-02-28", Customers: 2, MRR: 1300, CustomerRetention: 100, NetRevenueRetention: 1300.0 / 15, GrossRevenueRetention: 1300.0 / 15},
+		{Date: "2022-03-31", Customers: 1, MRR: 1400, CustomerRetention: 50, NetRevenueRetention: 1400.0 / 15, GrossRevenueRetention: 1000.0 / 15},
+		{Date: "2022-04-30", Customers: 2, MRR: 1900, CustomerRetention: 100, NetRevenueRetention: 1900.0 / 15, GrossRevenueRetention: 100},
+	}
+	for i, period := range january.Periods {
+		if *period != expected[i] {
+			spew.Dump(period)
+			t.Fatalf("Unexpected January cohort period %d", i)
+		}
+	}
+	if february := matrix.Cohorts[1]; february.Periods[2].NetRevenueRetention != 400.0/3 || february.Periods[2].GrossRevenueRetention != 100 {
+		spew.Dump(february)
+		t.Fatal("Unexpected February cohort")
+	}
+}
+
+func TestCohortMatrixEncoders(t *testing.T) {
+	matrix, err := BuildCohorts(cohortActivities(), cm.IntervalQuarter, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	if err := matrix.EncodeCSV(out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `cohort,cohort-customers,cohort-mrr,period,date,customers,mrr,customer-retention,net-revenue-retention,gross-revenue-retention
+2022-01-01,3,1800,0,2022-03-31,2,1800,66.66666666666667,100,100
+2022-01-01,3,1800,1,2022-06-30,3,2300,100,127.77777777777777,100
+`
+	if out.String() != expected {
+		t.Fatalf("Unexpected CSV:\n%v", out.String())
+	}
+
+	out.Reset()
+	if err := matrix.EncodeJSON(out); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &CohortMatrix{}
+	if err := json.Unmarshal(out.Bytes(), decoded); err != nil || decoded.Cohorts[0].Periods[1].MRR != 2300 ||
+		!strings.Contains(out.String(), `"net-revenue-retention":`) {
+		spew.Dump(out.String())
+		t.Fatal("Unexpected JSON")
+	}
+}
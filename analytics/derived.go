@@ -0,0 +1,149 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// DerivedMetrics are SaaS metrics derived from the MRR movements of one period or trailing window.
+// Rates are percentages; contraction and churn are negative as in MRRMetrics.
+//
+// The starting MRR of a period is its MRR less its net movements, which equals the MRR
+// of the previous entry. Over a trailing window, it's the starting MRR of the window's first period
+// and the movements are summed over the window.
+type DerivedMetrics struct {
+	Date        cm.Date `json:"date"`
+	StartingMRR float64 `json:"starting-mrr"`
+	MRR         float64 `json:"mrr"`
+	// NetNewMRR is new business + expansion + reactivation + contraction + churn.
+	NetNewMRR float64 `json:"net-new-mrr"`
+	// QuickRatio is (new business + expansion + reactivation) / |contraction + churn|,
+	// zero when nothing was lost.
+	QuickRatio float64 `json:"quick-ratio"`
+	// NetRevenueRetention is (starting MRR + expansion + contraction + churn) / starting MRR.
+	// New business and reactivation don't come from the starting customers, so they're left out.
+	// Over a trailing window, the movements of customers acquired within the window are included,
+	// as MRR entries don't tell them apart: it approximates the retention of the starting cohort.
+	NetRevenueRetention float64 `json:"net-revenue-retention"`
+	// GrossRevenueRetention is (starting MRR + contraction + churn) / starting MRR,
+	// approximated over trailing windows as NetRevenueRetention.
+	GrossRevenueRetention float64 `json:"gross-revenue-retention"`
+	// ExpansionRate is expansion / starting MRR.
+	ExpansionRate float64 `json:"expansion-rate"`
+	// Customers and ARPA are filled in from MetricsResult entries of the same date, if given.
+	Customers uint32  `json:"customers,omitempty"`
+	ARPA      float64 `json:"arpa,omitempty"`
+
+	// Changes since the previous entry, in percents.
+	QuickRatioPercentageChange            float64 `json:"quick-ratio-percentage-change"`
+	NetRevenueRetentionPercentageChange   float64 `json:"net-revenue-retention-percentage-change"`
+	GrossRevenueRetentionPercentageChange float64 `json:"gross-revenue-retention-percentage-change"`
+	ExpansionRatePercentageChange         float64 `json:"expansion-rate-percentage-change"`
+}
+
+// DerivedSummary compares the last entry with the first one, as AllSummary does.
+type DerivedSummary struct {
+	CurrentQuickRatio                     float64 `json:"current-quick-ratio"`
+	PreviousQuickRatio                    float64 `json:"previous-quick-ratio"`
+	QuickRatioPercentageChange            float64 `json:"quick-ratio-percentage-change"`
+	CurrentNetRevenueRetention            float64 `json:"current-net-revenue-retention"`
+	PreviousNetRevenueRetention           float64 `json:"previous-net-revenue-retention"`
+	NetRevenueRetentionPercentageChange   float64 `json:"net-revenue-retention-percentage-change"`
+	CurrentGrossRevenueRetention          float64 `json:"current-gross-revenue-retention"`
+	PreviousGrossRevenueRetention         float64 `json:"previous-gross-revenue-retention"`
+	GrossRevenueRetentionPercentageChange float64 `json:"gross-revenue-retention-percentage-change"`
+	CurrentExpansionRate                  float64 `json:"current-expansion-rate"`
+	PreviousExpansionRate                 float64 `json:"previous-expansion-rate"`
+	ExpansionRatePercentageChange         float64 `json:"expansion-rate-percentage-change"`
+}
+
+// DerivedResult are derived metrics in the order of the MRR entries.
+type DerivedResult struct {
+	Entries []*DerivedMetrics `json:"entries"`
+	Summary *DerivedSummary   `json:"summary"`
+}
+
+// DeriveMetrics computes the derived metrics of each MRR entry.
+// The metrics result is optional, it adds customers and ARPA to the entries.
+func DeriveMetrics(mrr *cm.MRRResult, metrics *cm.MetricsResult) *DerivedResult {
+	result, _ := DeriveTrailingMetrics(mrr, metrics, 1)
+	return result
+}
+
+// DeriveTrailingMetrics computes the derived metrics over trailing windows of the given number of periods,
+// eg. 12 for yearly retention from monthly MRR entries. The first entries without a full window are left out.
+// Retention includes expansion, contraction and churn of customers acquired within the window, see
+// DerivedMetrics; BuildCohorts follows the retention of each acquisition cohort exactly.
+// The metrics result is optional, it adds customers and ARPA to the entries.
+func DeriveTrailingMetrics(mrr *cm.MRRResult, metrics *cm.MetricsResult, window int) (*DerivedResult, error) {
+	if window < 1 {
+		return nil, fmt.Errorf("chartmogul: invalid window %d", window)
+	}
+	all := map[cm.Date]*cm.AllMetrics{}
+	if metrics != nil {
+		for _, entry := range metrics.Entries {
+			all[entry.Date] = entry
+		}
+	}
+
+	result := &DerivedResult{Entries: []*DerivedMetrics{}}
+	for last := window - 1; last < len(mrr.Entries); last++ {
+		entries := mrr.Entries[last-window+1 : last+1]
+		first := entries[0]
+		derived := &DerivedMetrics{
+			Date:        entries[len(entries)-1].Date,
+			StartingMRR: first.MRR - netMovement(first),
+			MRR:         entries[len(entries)-1].MRR,
+		}
+		var gained, lost, expansion float64
+		for _, entry := range entries {
+			derived.NetNewMRR += netMovement(entry)
+			gained += entry.MRRNewBusiness + entry.MRRExpansion + entry.MRRReactivation
+			lost += entry.MRRContraction + entry.MRRChurn
+			expansion += entry.MRRExpansion
+		}
+		if lost != 0 {
+			derived.QuickRatio = gained / math.Abs(lost)
+		}
+		derived.NetRevenueRetention = percentage(derived.StartingMRR+expansion+lost, derived.StartingMRR)
+		derived.GrossRevenueRetention = percentage(derived.StartingMRR+lost, derived.StartingMRR)
+		derived.ExpansionRate = percentage(expansion, derived.StartingMRR)
+		if entry := all[derived.Date]; entry != nil {
+			derived.Customers, derived.ARPA = entry.Customers, entry.Arpa
+		}
+
+		if n := len(result.Entries); n != 0 {
+			previous := result.Entries[n-1]
+			derived.QuickRatioPercentageChange = cm.PercentageChange(previous.QuickRatio, derived.QuickRatio)
+			derived.NetRevenueRetentionPercentageChange = cm.PercentageChange(previous.NetRevenueRetention, derived.NetRevenueRetention)
+			derived.GrossRevenueRetentionPercentageChange = cm.PercentageChange(previous.GrossRevenueRetention, derived.GrossRevenueRetention)
+			derived.ExpansionRatePercentageChange = cm.PercentageChange(previous.ExpansionRate, derived.ExpansionRate)
+		}
+		result.Entries = append(result.Entries, derived)
+	}
+
+	if n := len(result.Entries); n != 0 {
+		first, last := result.Entries[0], result.Entries[n-1]
+		result.Summary = &DerivedSummary{
+			CurrentQuickRatio:                     last.QuickRatio,
+			PreviousQuickRatio:                    first.QuickRatio,
+			QuickRatioPercentageChange:            cm.PercentageChange(first.QuickRatio, last.QuickRatio),
+			CurrentNetRevenueRetention:            last.NetRevenueRetention,
+			PreviousNetRevenueRetention:           first.NetRevenueRetention,
+			NetRevenueRetentionPercentageChange:   cm.PercentageChange(first.NetRevenueRetention, last.NetRevenueRetention),
+			CurrentGrossRevenueRetention:          last.GrossRevenueRetention,
+			PreviousGrossRevenueRetention:         first.GrossRevenueRetention,
+			GrossRevenueRetentionPercentageChange: cm.PercentageChange(first.GrossRevenueRetention, last.GrossRevenueRetention),
+			CurrentExpansionRate:                  last.ExpansionRate,
+			PreviousExpansionRate:                 first.ExpansionRate,
+			ExpansionRatePercentageChange:         cm.PercentageChange(first.ExpansionRate, last.ExpansionRate),
+		}
+	}
+	return result, nil
+}
+
+func netMovement(entry *cm.MRRMetrics) float64 {
+	return entry.MRRNewBusiness + entry.MRRExpansion + entry.MRRContraction + entry.MRRChurn + entry.MRRReactivation
+}
@@ -0,0 +1,86 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+func testMRR() *cm.MRRResult {
+	return &cm.MRRResult{Entries: []*cm.MRRMetrics{
+		{Date: "2022-01-31", MRR: 1000, MRRNewBusiness: 1000},
+		{Date: "2022-02-28", MRR: 1100, MRRNewBusiness: 100, MRRExpansion: 200, MRRContraction: -50, MRRChurn: -150},
+		{Date: "2022-03-31", MRR: 1300, MRRExpansion: 250, MRRChurn: -100, MRRReactivation: 50},
+	}}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestDeriveMetrics(t *testing.T) {
+	metrics := &cm.MetricsResult{Entries: []*cm.AllMetrics{{Date: "2022-03-31", Customers: 13, Arpa: 100}}}
+	result := DeriveMetrics(testMRR(), metrics)
+	if len(result.Entries) != 3 {
+		spew.Dump(result)
+		t.Fatal("Expected an entry per MRR entry")
+	}
+
+	if first := result.Entries[0]; first.StartingMRR != 0 || first.QuickRatio != 0 || first.NetRevenueRetention != 0 {
+		spew.Dump(first)
+		t.Fatal("Expected the first entry without starting MRR or losses")
+	}
+	february := result.Entries[1]
+	if february.StartingMRR != 1000 || february.NetNewMRR != 100 || february.QuickRatio != 1.5 ||
+		february.NetRevenueRetention != 100 || february.GrossRevenueRetention != 80 || february.ExpansionRate != 20 {
+		spew.Dump(february)
+		t.Fatal("Unexpected February")
+	}
+	march := result.Entries[2]
+	if march.StartingMRR != 1100 || march.QuickRatio != 3 || !near(march.NetRevenueRetention, 1250.0/11) ||
+		!near(march.GrossRevenueRetention, 1000.0/11) || !near(march.ExpansionRate, 250.0/11) ||
+		march.QuickRatioPercentageChange != 100 || march.Customers != 13 || march.ARPA != 100 {
+		spew.Dump(march)
+		t.Fatal("Unexpected March")
+	}
+	if result.Summary.PreviousQuickRatio != 0 || result.Summary.CurrentQuickRatio != 3 || result.Summary.QuickRatioPercentageChange != 0 {
+		spew.Dump(result.Summary)
+		t.Fatal("Unexpected summary")
+	}
+}
+
+func TestDeriveTrailingMetrics(t *testing.T) {
+	result, err := DeriveTrailingMetrics(testMRR(), nil, 2)
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	if len(result.Entries) != 2 || result.Entries[0].Date != "2022-02-28" {
+		spew.Dump(result)
+		t.Fatal("Expected entries with full windows only")
+	}
+	expected := DerivedMetrics{
+		Date:                                  "2022-03-31",
+		StartingMRR:                           1000,
+		MRR:                                   1300,
+		NetNewMRR:                             300,
+		QuickRatio:                            2,
+		NetRevenueRetention:                   115,
+		GrossRevenueRetention:                 70,
+		ExpansionRate:                         45,
+		QuickRatioPercentageChange:            cm.PercentageChange(6.5, 2),
+		NetRevenueRetentionPercentageChange:   0,
+		GrossRevenueRetentionPercentageChange: 0,
+		ExpansionRatePercentageChange:         0,
+	}
+	if *result.Entries[1] != expected {
+		spew.Dump(result.Entries[1])
+		t.Fatal("Unexpected trailing window")
+	}
+
+	if _, err := DeriveTrailingMetrics(testMRR(), nil, 0); err == nil {
+		t.Fatal("Expected empty window to fail")
+	}
+}
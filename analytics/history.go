@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// now is the time of the current period, replaced in tests.
+var now = time.Now
+
+// MRRHistoryPoint is a customer's MRR in one period.
+type MRRHistoryPoint struct {
+	// Start is the first day of the period.
+	Start cm.Date `json:"start"`
+	// Date is the last day of the period.
+	Date cm.Date `json:"date"`
+	// MRR at the end of the period, the activity MRR of the latest activity until then.
+	MRR float64 `json:"mrr"`
+	// Movement is the net MRR movement of the activities within the period.
+	Movement float64 `json:"mrr-movement"`
+}
+
+// MRRHistory is a customer's MRR over time, period by period up to the current one.
+type MRRHistory struct {
+	CustomerUUID string      `json:"customer-uuid"`
+	Interval     cm.Interval `json:"interval"`
+	// Currency of the activities and Points, empty along with Points if they are in more currencies.
+	Currency string             `json:"currency"`
+	Points   []*MRRHistoryPoint `json:"points"`
+	// ByCurrency are the points by currency, set only for activities in more currencies.
+	// The series cover the same periods, with zero MRR before the first activity of a currency.
+	ByCurrency map[string][]*MRRHistoryPoint `json:"by-currency,omitempty"`
+	// CustomerMRR is the current MRR of the customer from RetrieveCustomer.
+	CustomerMRR float64 `json:"customer-mrr"`
+	// Difference is the MRR of the last point minus CustomerMRR, zero for activities in more currencies.
+	Difference float64 `json:"difference"`
+}
+
+// Reconciled is true if the history ends within tolerance of the customer's MRR.
+// Histories in more currencies can't be compared and aren't reconciled.
+func (h *MRRHistory) Reconciled(tolerance float64) bool {
+	return h.ByCurrency == nil && math.Abs(h.Difference) <= tolerance
+}
+
+type customerActivity struct {
+	activity *cm.MetricsCustomerActivity
+	at       time.Time
+	day      cm.Date
+}
+
+// CustomerMRRHistory reconstructs the MRR of a customer from its activities, from the period
+// of the first activity to the current one, and cross-checks it with the customer's MRR.
+//
+// Periods follow the account's time zone and week start, like the Metrics API;
+// the account can be nil for UTC periods with weeks starting on Monday.
+func CustomerMRRHistory(api cm.IApi, customerUUID string, interval cm.Interval, account *cm.Account) (*MRRHistory, error) {
+	c, err := newCalendar(interval, account)
+	if err != nil {
+		return nil, err
+	}
+	var activities []customerActivity
+	currencies := map[string]bool{}
+	it := cm.NewMetricsCustomerActivitiesIterator(api, customerUUID, nil)
+	for {
+		activity, err := it.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		at, err := activity.Date.Time()
+		if err != nil {
+			return nil, fmt.Errorf("chartmogul: activity %v: %v", activity.ID, err)
+		}
+		day, _ := c.day(activity.Date)
+		activities = append(activities, customerActivity{activity: activity, at: at, day: day})
+		currencies[activity.Currency] = true
+	}
+	sort.SliceStable(activities, func(i, j int) bool { return activities[i].at.Before(activities[j].at) })
+
+	customer, err := api.RetrieveCustomer(customerUUID)
+	if err != nil {
+		return nil, err
+	}
+	h := &MRRHistory{CustomerUUID: customerUUID, Interval: c.interval, Points: []*MRRHistoryPoint{}, CustomerMRR: customer.Mrr}
+	if len(activities) == 0 {
+		h.Currency = customer.Currency
+		h.Difference = -customer.Mrr
+		return h, nil
+	}
+
+	first, _ := c.period(activities[0].day)
+	today, _ := c.day(cm.NewTimestamp(now()))
+	last, _ := c.period(today)
+	if dateAfter(activities[len(activities)-1].day, today) {
+		last, _ = c.period(activities[len(activities)-1].day)
+	}
+	series := map[string][]*MRRHistoryPoint{}
+	for currency := range currencies {
+		mrr, i := 0.0, 0
+		for start := first; !dateAfter(start, last); start = c.next(start, 1) {
+			_, end := c.period(start)
+			point := &MRRHistoryPoint{Start: start, Date: end}
+			for ; i < len(activities) && !dateAfter(activities[i].day, end); i++ {
+				if activities[i].activity.Currency != currency {
+					continue
+				}
+				mrr = activities[i].activity.ActivityMrr
+				point.Movement += activities[i].activity.ActivityMrrMovement
+			}
+			point.MRR = mrr
+			series[currency] = append(series[currency], point)
+		}
+	}
+
+	if len(series) > 1 {
+		h.ByCurrency = series
+		return h, nil
+	}
+	for currency, points := range series {
+		h.Currency, h.Points = currency, points
+	}
+	h.Difference = h.Points[len(h.Points)-1].MRR - customer.Mrr
+	return h, nil
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// historyAPI serves the customer's activities in pages of two.
+type historyAPI struct {
+	cm.IApi
+	activities []*cm.MetricsCustomerActivity
+	customer   *cm.Customer
+}
+
+func (api *historyAPI) MetricsListCustomerActivities(cursor *cm.Cursor, customerUUID string) (*cm.MetricsCustomerActivities, error) {
+	i := 0
+	if cursor.Cursor != "" {
+		i = int(cursor.Cursor[0] - '0')
+	}
+	j := i + 2
+	if j >= len(api.activities) {
+		return &cm.MetricsCustomerActivities{Entries: api.activities[i:]}, nil
+	}
+	return &cm.MetricsCustomerActivities{
+		Entries:    api.activities[i:j],
+		Pagination: cm.Pagination{Cursor: string(rune('0' + j)), HasMore: true},
+	}, nil
+}
+
+func (api *historyAPI) RetrieveCustomer(customerUUID string) (*cm.Customer, error) {
+	return api.customer, nil
+}
+
+func TestCustomerMRRHistory(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 5, 3, 0, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	api := &historyAPI{
+		activities: []*cm.MetricsCustomerActivity{
+			{ID: 1, Date: "2022-01-10T10:00:00Z", Type: NewBusiness, ActivityMrr: 1000, ActivityMrrMovement: 1000, Currency: "EUR"},
+			{ID: 3, Date: "2022-02-20T10:00:00Z", Type: Contraction, ActivityMrr: 1200, ActivityMrrMovement: -300, Currency: "EUR"},
+			{ID: 2, Date: "2022-02-10T10:00:00Z", Type: Expansion, ActivityMrr: 1500, ActivityMrrMovement: 500, Currency: "EUR"},
+		},
+		customer: &cm.Customer{Mrr: 1200, Currency: "EUR"},
+	}
+	h, err := CustomerMRRHistory(api, "cus_1", cm.IntervalMonth, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []MRRHistoryPoint{
+		{Start: "2022-01-01", Date: "2022-01-31", MRR: 1000, Movement: 1000},
+		{Start: "2022-02-01", Date: "2022-02-28", MRR: 1200, Movement: 200},
+		{Start: "2022-03-01", Date: "2022-03-31", MRR: 1200},
+		{Start: "2022-04-01", Date: "2022-04-30", MRR: 1200},
+		{Start: "2022-05-01", Date: "2022-05-31", MRR: 1200},
+	}
+	if h.Currency != "EUR" || len(h.Points) != len(expected) || h.ByCurrency != nil {
+		spew.Dump(h)
+		t.Fatal("Unexpected history")
+	}
+	for i, point := range h.Points {
+		if *point != expected[i] {
+			spew.Dump(h.Points)
+			t.Fatalf("Unexpected point %v", i)
+		}
+	}
+	if !h.Reconciled(0.01) {
+		spew.Dump(h)
+		t.Fatal("Expected the history to match the customer's MRR")
+	}
+
+	api.customer.Mrr = 1300
+	if h, _ := CustomerMRRHistory(api, "cus_1", cm.IntervalMonth, nil); h.Difference != -100 || h.Reconciled(1) {
+		spew.Dump(h)
+		t.Fatal("Expected a difference")
+	}
+}
+
+func TestCustomerMRRHistoryCurrencies(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 2, 3, 0, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	api := &historyAPI{
+		activities: []*cm.MetricsCustomerActivity{
+			{ID: 1, Date: "2022-01-10T10:00:00Z", Type: NewBusiness, ActivityMrr: 1000, ActivityMrrMovement: 1000, Currency: "EUR"},
+			{ID: 2, Date: "2022-02-01T10:00:00Z", Type: NewBusiness, ActivityMrr: 500, ActivityMrrMovement: 500, Currency: "USD"},
+		},
+		customer: &cm.Customer{Mrr: 1500},
+	}
+	h, err := CustomerMRRHistory(api, "cus_1", cm.IntervalMonth, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usd := h.ByCurrency["USD"]
+	if h.Currency != "" || len(h.Points) != 0 || len(usd) != 2 || usd[0].MRR != 0 || usd[1].MRR != 500 ||
+		h.ByCurrency["EUR"][1].MRR != 1000 || h.Reconciled(1000) {
+		spew.Dump(h)
+		t.Fatal("Unexpected history by currency")
+	}
+}
@@ -0,0 +1,257 @@
+package analytics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Transaction types and results, see cm.Transaction.
+const (
+	PaymentTransaction = "payment"
+	RefundTransaction  = "refund"
+	SuccessfulResult   = "successful"
+	FailedResult       = "failed"
+)
+
+// PaymentStats summarise the transactions of invoices in one period, plan and currency.
+// Amounts are in minor units of Currency.
+type PaymentStats struct {
+	// Period is the first day of the period, empty for stats rolled up over periods.
+	Period cm.Date `json:"period"`
+	// PlanUUID is the plan of the invoices' largest line item, empty for invoices without plan
+	// or stats rolled up over plans.
+	PlanUUID string `json:"plan-uuid"`
+	Currency string `json:"currency"`
+	// Attempts and Failed count payment transactions by their date.
+	Attempts int `json:"payment-attempts"`
+	Failed   int `json:"failed-payments"`
+	// FailedInvoices count invoices by the date of their first failed payment,
+	// Recovered those of them paid by a successful payment afterwards.
+	FailedInvoices int `json:"failed-invoices"`
+	Recovered      int `json:"recovered-invoices"`
+	// Paid is the amount of successful payments, Refunded that of successful refunds.
+	Paid     int64 `json:"paid"`
+	Refunds  int   `json:"refunds"`
+	Refunded int64 `json:"refunded"`
+}
+
+// FailureRate returns the failed payment attempts in percents of all attempts.
+func (s *PaymentStats) FailureRate() float64 {
+	return percentage(float64(s.Failed), float64(s.Attempts))
+}
+
+// RecoveryRate returns the recovered invoices in percents of the invoices with a failed payment.
+func (s *PaymentStats) RecoveryRate() float64 {
+	return percentage(float64(s.Recovered), float64(s.FailedInvoices))
+}
+
+func (s *PaymentStats) add(o *PaymentStats) {
+	s.Attempts += o.Attempts
+	s.Failed += o.Failed
+	s.FailedInvoices += o.FailedInvoices
+	s.Recovered += o.Recovered
+	s.Paid += o.Paid
+	s.Refunds += o.Refunds
+	s.Refunded += o.Refunded
+}
+
+// UnpaidInvoice is an invoice not paid in full by successful payments, eg. for dunning.
+type UnpaidInvoice struct {
+	Invoice *cm.Invoice `json:"invoice"`
+	// Outstanding is the total of the invoice less its successful payments, in minor units of its currency.
+	Outstanding    int64 `json:"outstanding"`
+	FailedAttempts int   `json:"failed-attempts"`
+	// LastAttempt is the date of the latest payment transaction, empty without any.
+	LastAttempt cm.Timestamp `json:"last-attempt"`
+	// DaysOverdue counts the days since the due date, or the invoice date without one, zero before it.
+	DaysOverdue int `json:"days-overdue"`
+}
+
+// PaymentsReport summarises payments and refunds of invoices.
+type PaymentsReport struct {
+	Interval cm.Interval `json:"interval"`
+	// Stats by period, plan and currency, in that order.
+	Stats []*PaymentStats `json:"stats"`
+	// Unpaid invoices, oldest first.
+	Unpaid []*UnpaidInvoice `json:"unpaid"`
+}
+
+// Rollup merges the stats over the dimensions not kept, they are always kept apart by currency.
+// Eg. Rollup(true, false) are the stats by period and currency, Rollup(false, false) by currency only.
+func (r *PaymentsReport) Rollup(byPeriod, byPlan bool) []*PaymentStats {
+	var rolled []*PaymentStats
+	index := map[PaymentStats]*PaymentStats{}
+	for _, s := range r.Stats {
+		key := PaymentStats{Currency: s.Currency}
+		if byPeriod {
+			key.Period = s.Period
+		}
+		if byPlan {
+			key.PlanUUID = s.PlanUUID
+		}
+		merged, ok := index[key]
+		if !ok {
+			merged = &PaymentStats{Period: key.Period, PlanUUID: key.PlanUUID, Currency: key.Currency}
+			index[key] = merged
+			rolled = append(rolled, merged)
+		}
+		merged.add(s)
+	}
+	sortPaymentStats(rolled)
+	return rolled
+}
+
+type paymentKey struct {
+	period   cm.Date
+	plan     string
+	currency string
+}
+
+// BuildPaymentsReport reads all invoices matching the parameters, which can be nil,
+// and summarises their payment and refund transactions by period, plan and currency.
+// Transactions without amount are for the full value of their invoice.
+//
+// Periods follow the account's time zone and week start; the account can be nil
+// for UTC periods with weeks starting on Monday.
+func BuildPaymentsReport(api cm.IApi, params *cm.ListAllInvoicesParams, interval cm.Interval, account *cm.Account) (*PaymentsReport, error) {
+	c, err := newCalendar(interval, account)
+	if err != nil {
+		return nil, err
+	}
+	report := &PaymentsReport{Interval: c.interval, Stats: []*PaymentStats{}, Unpaid: []*UnpaidInvoice{}}
+	stats := map[paymentKey]*PaymentStats{}
+	statsOf := func(date cm.Date, plan, currency string) *PaymentStats {
+		start, _ := c.period(date)
+		key := paymentKey{start, plan, currency}
+		if stats[key] == nil {
+			stats[key] = &PaymentStats{Period: start, PlanUUID: plan, Currency: currency}
+			report.Stats = append(report.Stats, stats[key])
+		}
+		return stats[key]
+	}
+	today, _ := c.day(cm.NewTimestamp(now()))
+
+	it := cm.NewInvoicesIterator(api, params)
+	for {
+		invoice, err := it.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		total := invoice.Total()
+		plan := invoicePlan(invoice)
+		transactions, err := sortedTransactions(invoice, c)
+		if err != nil {
+			return nil, err
+		}
+
+		paid := cm.NewMoney(0, invoice.Currency)
+		unpaid := &UnpaidInvoice{Invoice: invoice}
+		var failedOn cm.Date
+		recovered := false
+		for _, t := range transactions {
+			amount, ok := t.transaction.Amount(invoice.Currency)
+			if !ok {
+				amount = total
+			}
+			s := statsOf(t.day, plan, total.Currency)
+			switch t.transaction.Type {
+			case PaymentTransaction:
+				s.Attempts++
+				unpaid.LastAttempt = t.transaction.Date
+				if t.transaction.Result != SuccessfulResult {
+					s.Failed++
+					unpaid.FailedAttempts++
+					if failedOn.IsZero() {
+						failedOn = t.day
+						s.FailedInvoices++
+					}
+					continue
+				}
+				s.Paid += amount.Amount
+				paid.Amount += amount.Amount
+				if !failedOn.IsZero() && !recovered {
+					statsOf(failedOn, plan, total.Currency).Recovered++
+					recovered = true
+				}
+			case RefundTransaction:
+				if t.transaction.Result == SuccessfulResult {
+					s.Refunds++
+					s.Refunded += amount.Amount
+				}
+			}
+		}
+		if total.Amount > 0 && paid.Amount < total.Amount {
+			unpaid.Outstanding = total.Amount - paid.Amount
+			due := invoice.DueDate
+			if due.IsZero() {
+				due = invoice.Date
+			}
+			if day, err := c.day(due); err == nil && dateAfter(today, day) {
+				from, _ := day.Time()
+				to, _ := today.Time()
+				unpaid.DaysOverdue = int(to.Sub(from).Hours() / 24)
+			}
+			report.Unpaid = append(report.Unpaid, unpaid)
+		}
+	}
+
+	sortPaymentStats(report.Stats)
+	sort.SliceStable(report.Unpaid, func(i, j int) bool {
+		a, _ := report.Unpaid[i].Invoice.Date.Time()
+		b, _ := report.Unpaid[j].Invoice.Date.Time()
+		return a.Before(b)
+	})
+	return report, nil
+}
+
+type invoiceTransaction struct {
+	transaction *cm.Transaction
+	at          time.Time
+	day         cm.Date
+}
+
+// sortedTransactions returns the transactions of the invoice in chronological order.
+func sortedTransactions(invoice *cm.Invoice, c calendar) ([]invoiceTransaction, error) {
+	transactions := make([]invoiceTransaction, len(invoice.Transactions))
+	for i, transaction := range invoice.Transactions {
+		at, err := transaction.Date.Time()
+		if err != nil {
+			return nil, fmt.Errorf("chartmogul: invoice %v: transaction %v: %v", invoice.ExternalID, transaction.ExternalID, err)
+		}
+		day, _ := c.day(transaction.Date)
+		transactions[i] = invoiceTransaction{transaction: transaction, at: at, day: day}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].at.Before(transactions[j].at) })
+	return transactions, nil
+}
+
+// invoicePlan returns the plan of the largest line item of the invoice with a plan.
+func invoicePlan(invoice *cm.Invoice) string {
+	plan, largest := "", 0
+	for _, lineItem := range invoice.LineItems {
+		if lineItem.PlanUUID != "" && (plan == "" || lineItem.AmountInCents > largest) {
+			plan, largest = lineItem.PlanUUID, lineItem.AmountInCents
+		}
+	}
+	return plan
+}
+
+func sortPaymentStats(stats []*PaymentStats) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.Period != b.Period {
+			return dateAfter(b.Period, a.Period)
+		}
+		if a.PlanUUID != b.PlanUUID {
+			return a.PlanUUID < b.PlanUUID
+		}
+		return a.Currency < b.Currency
+	})
+}
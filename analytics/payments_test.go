@@ -0,0 +1,98 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// invoicesAPI serves the invoices in pages of two.
+type invoicesAPI struct {
+	cm.IApi
+	invoices []*cm.Invoice
+}
+
+func (api *invoicesAPI) ListAllInvoices(params *cm.ListAllInvoicesParams) (*cm.Invoices, error) {
+	i := 0
+	if params.Cursor.Cursor != "" {
+		i = int(params.Cursor.Cursor[0] - '0')
+	}
+	j := i + 2
+	if j >= len(api.invoices) {
+		return &cm.Invoices{Invoices: api.invoices[i:]}, nil
+	}
+	return &cm.Invoices{
+		Invoices:   api.invoices[i:j],
+		Pagination: cm.Pagination{Cursor: string(rune('0' + j)), HasMore: true},
+	}, nil
+}
+
+func TestBuildPaymentsReport(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	half := 500
+	api := &invoicesAPI{invoices: []*cm.Invoice{
+		{ExternalID: "inv_1", Currency: "EUR", Date: "2022-01-05T00:00:00Z",
+			LineItems: []*cm.LineItem{{PlanUUID: "pl_small", AmountInCents: 200}, {PlanUUID: "pl_pro", AmountInCents: 800}},
+			Transactions: []*cm.Transaction{
+				{ExternalID: "t2", Date: "2022-02-02T00:00:00Z", Type: PaymentTransaction, Result: SuccessfulResult},
+				{ExternalID: "t1", Date: "2022-01-05T00:00:00Z", Type: PaymentTransaction, Result: FailedResult},
+				{ExternalID: "t3", Date: "2022-02-10T00:00:00Z", Type: RefundTransaction, Result: SuccessfulResult, AmountInCents: &half},
+			}},
+		{ExternalID: "inv_2", Currency: "EUR", Date: "2022-01-20T00:00:00Z", DueDate: "2022-02-20T00:00:00Z",
+			LineItems: []*cm.LineItem{{PlanUUID: "pl_pro", AmountInCents: 1000}},
+			Transactions: []*cm.Transaction{
+				{ExternalID: "t4", Date: "2022-01-20T00:00:00Z", Type: PaymentTransaction, Result: FailedResult},
+				{ExternalID: "t5", Date: "2022-01-27T00:00:00Z", Type: PaymentTransaction, Result: FailedResult},
+				{ExternalID: "t6", Date: "2022-02-03T00:00:00Z", Type: PaymentTransaction, Result: SuccessfulResult, AmountInCents: &half},
+			}},
+		{ExternalID: "inv_3", Currency: "USD", Date: "2022-01-25T00:00:00Z",
+			LineItems: []*cm.LineItem{{PlanUUID: "pl_pro", AmountInCents: 300}},
+			Transactions: []*cm.Transaction{
+				{ExternalID: "t7", Date: "2022-01-25T00:00:00Z", Type: PaymentTransaction, Result: SuccessfulResult},
+			}},
+	}}
+	report, err := BuildPaymentsReport(api, nil, cm.IntervalMonth, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []PaymentStats{
+		{Period: "2022-01-01", PlanUUID: "pl_pro", Currency: "EUR", Attempts: 3, Failed: 3, FailedInvoices: 2, Recovered: 2},
+		{Period: "2022-01-01", PlanUUID: "pl_pro", Currency: "USD", Attempts: 1, Paid: 300},
+		{Period: "2022-02-01", PlanUUID: "pl_pro", Currency: "EUR", Attempts: 2, Paid: 1500, Refunds: 1, Refunded: 500},
+	}
+	if len(report.Stats) != len(expected) {
+		spew.Dump(report.Stats)
+		t.Fatal("Unexpected stats")
+	}
+	for i, s := range report.Stats {
+		if *s != expected[i] {
+			spew.Dump(report.Stats)
+			t.Fatalf("Unexpected stats %v", i)
+		}
+	}
+	if report.Stats[0].FailureRate() != 100 || report.Stats[0].RecoveryRate() != 100 {
+		spew.Dump(report.Stats[0])
+		t.Fatal("Unexpected rates")
+	}
+
+	byCurrency := report.Rollup(false, false)
+	if len(byCurrency) != 2 || byCurrency[0].Currency != "EUR" || byCurrency[0].Attempts != 5 || byCurrency[0].FailureRate() != 60 {
+		spew.Dump(byCurrency)
+		t.Fatal("Unexpected rollup")
+	}
+
+	if len(report.Unpaid) != 1 {
+		spew.Dump(report.Unpaid)
+		t.Fatal("Expected one unpaid invoice")
+	}
+	unpaid := report.Unpaid[0]
+	if unpaid.Invoice.ExternalID != "inv_2" || unpaid.Outstanding != 500 || unpaid.FailedAttempts != 2 ||
+		unpaid.LastAttempt != "2022-02-03T00:00:00Z" || unpaid.DaysOverdue != 18 {
+		spew.Dump(unpaid)
+		t.Fatal("Unexpected unpaid invoice")
+	}
+}
@@ -0,0 +1,246 @@
+// Package analytics computes reports on top of the ChartMogul API, which the API doesn't offer directly.
+package analytics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Activity types of MRR movements, see MetricsActivity.Type.
+const (
+	NewBusiness  = "new_biz"
+	Expansion    = "expansion"
+	Contraction  = "contraction"
+	Churn        = "churn"
+	Reactivation = "reactivation"
+)
+
+// Movements are MRR movements summed by type. Contraction and churn are negative as in MRRMetrics.
+type Movements struct {
+	NewBusiness  float64 `json:"mrr-new-business"`
+	Expansion    float64 `json:"mrr-expansion"`
+	Contraction  float64 `json:"mrr-contraction"`
+	Churn        float64 `json:"mrr-churn"`
+	Reactivation float64 `json:"mrr-reactivation"`
+}
+
+// Net returns the net MRR movement.
+func (m Movements) Net() float64 {
+	return m.NewBusiness + m.Expansion + m.Contraction + m.Churn + m.Reactivation
+}
+
+// isMovement is true for activity types moving MRR.
+func isMovement(activityType string) bool {
+	switch activityType {
+	case NewBusiness, Expansion, Contraction, Churn, Reactivation:
+		return true
+	}
+	return false
+}
+
+// add counts the movement of an activity, false for activities not moving MRR.
+func (m *Movements) add(activityType string, amount float64) bool {
+	switch activityType {
+	case NewBusiness:
+		m.NewBusiness += amount
+	case Expansion:
+		m.Expansion += amount
+	case Contraction:
+		m.Contraction += amount
+	case Churn:
+		m.Churn += amount
+	case Reactivation:
+		m.Reactivation += amount
+	default:
+		return false
+	}
+	return true
+}
+
+// WaterfallPeriod are the MRR movements within one period.
+type WaterfallPeriod struct {
+	// Start is the first day of the period.
+	Start cm.Date `json:"start"`
+	// Date is the last day of the period, as the dates of Metrics API entries.
+	Date      cm.Date `json:"date"`
+	Movements `json:"movements"`
+	// ByCustomer are the movements by customer UUID.
+	ByCustomer map[string]*Movements `json:"by-customer"`
+	// ByPlan are the movements by plan external ID.
+	ByPlan map[string]*Movements `json:"by-plan"`
+}
+
+// Waterfall are MRR movements computed from activities, period by period.
+type Waterfall struct {
+	Interval cm.Interval `json:"interval"`
+	// Periods are in chronological order, periods without activities included.
+	Periods []*WaterfallPeriod `json:"periods"`
+	// Customers are the names of the customers by UUID.
+	Customers map[string]string `json:"customers"`
+	// Currencies counts the activities by currency. The movements are summed as they are,
+	// so activities in more currencies need converting first to be comparable with MRRMetrics.
+	Currencies map[string]int `json:"currencies"`
+
+	calendar calendar
+}
+
+// BuildWaterfall reads all activities from the source and sums their MRR movements by period,
+// customer and plan. Periods follow the account's time zone and week start,
+// like the Metrics API; the account can be nil for UTC periods with weeks starting on Monday.
+//
+// The source is eg. cm.NewMetricsActivitiesIterator or the reader of API.ExportActivities.
+func BuildWaterfall(source cm.MetricsActivitiesSource, interval cm.Interval, account *cm.Account) (*Waterfall, error) {
+	c, err := newCalendar(interval, account)
+	if err != nil {
+		return nil, err
+	}
+	w := &Waterfall{
+		Interval:   c.interval,
+		Customers:  map[string]string{},
+		Currencies: map[string]int{},
+		calendar:   c,
+	}
+
+	periods := map[cm.Date]*WaterfallPeriod{}
+	for {
+		activity, err := source.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		date, err := c.day(activity.Date)
+		if err != nil {
+			return nil, fmt.Errorf("chartmogul: activity %v: %v", activity.UUID, err)
+		}
+		start, end := c.period(date)
+		period := periods[start]
+		if period == nil {
+			period = &WaterfallPeriod{
+				Start:      start,
+				Date:       end,
+				ByCustomer: map[string]*Movements{},
+				ByPlan:     map[string]*Movements{},
+			}
+			periods[start] = period
+		}
+		if !period.add(activity.Type, activity.ActivityMrrMovement) {
+			continue
+		}
+		movements(period.ByCustomer, activity.CustomerUUID).add(activity.Type, activity.ActivityMrrMovement)
+		movements(period.ByPlan, activity.PlanExternalID).add(activity.Type, activity.ActivityMrrMovement)
+		if activity.CustomerName != "" {
+			w.Customers[activity.CustomerUUID] = activity.CustomerName
+		}
+		w.Currencies[activity.Currency]++
+	}
+	w.fill(periods)
+	return w, nil
+}
+
+func movements(by map[string]*Movements, key string) *Movements {
+	if by[key] == nil {
+		by[key] = &Movements{}
+	}
+	return by[key]
+}
+
+// fill orders the periods, adding empty ones between them.
+func (w *Waterfall) fill(periods map[cm.Date]*WaterfallPeriod) {
+	w.Periods = make([]*WaterfallPeriod, 0, len(periods))
+	if len(periods) == 0 {
+		return
+	}
+	starts := make([]string, 0, len(periods))
+	for start := range periods {
+		starts = append(starts, string(start))
+	}
+	sort.Strings(starts)
+	last := cm.Date(starts[len(starts)-1])
+	for start := cm.Date(starts[0]); !dateAfter(start, last); start = w.calendar.next(start, 1) {
+		_, end := w.calendar.period(start)
+		period := periods[start]
+		if period == nil {
+			period = &WaterfallPeriod{Start: start, Date: end, ByCustomer: map[string]*Movements{}, ByPlan: map[string]*Movements{}}
+		}
+		w.Periods = append(w.Periods, period)
+	}
+}
+
+// Period returns the period containing the date, nil if outside of the waterfall.
+func (w *Waterfall) Period(date cm.Date) *WaterfallPeriod {
+	if _, err := date.Time(); err != nil {
+		return nil
+	}
+	start, _ := w.calendar.period(date)
+	for _, period := range w.Periods {
+		if period.Start == start {
+			return period
+		}
+	}
+	return nil
+}
+
+// Total returns the movements summed over all periods.
+func (w *Waterfall) Total() Movements {
+	total := Movements{}
+	for _, period := range w.Periods {
+		total.NewBusiness += period.NewBusiness
+		total.Expansion += period.Expansion
+		total.Contraction += period.Contraction
+		total.Churn += period.Churn
+		total.Reactivation += period.Reactivation
+	}
+	return total
+}
+
+// Discrepancy is a movement which differs between the waterfall and MRRMetrics.
+type Discrepancy struct {
+	Date cm.Date `json:"date"`
+	// Movement is the name of the MRRMetrics field, eg. "mrr-churn".
+	Movement  string  `json:"movement"`
+	Waterfall float64 `json:"waterfall"`
+	Metrics   float64 `json:"metrics"`
+}
+
+// Difference returns how much the waterfall is off from the metrics.
+func (d Discrepancy) Difference() float64 {
+	return d.Waterfall - d.Metrics
+}
+
+func (d Discrepancy) String() string {
+	return fmt.Sprintf("%v %v: waterfall %v, metrics %v", d.Date, d.Movement, d.Waterfall, d.Metrics)
+}
+
+// Reconcile compares the movements of each MRR entry with the waterfall period containing its date,
+// which must be retrieved with the waterfall's interval. Movements differing by more than tolerance
+// are returned as discrepancies; periods without activities count as zero movements.
+func (w *Waterfall) Reconcile(mrr *cm.MRRResult, tolerance float64) []Discrepancy {
+	var discrepancies []Discrepancy
+	for _, entry := range mrr.Entries {
+		computed := Movements{}
+		if period := w.Period(entry.Date); period != nil {
+			computed = period.Movements
+		}
+		for _, m := range []struct {
+			name               string
+			waterfall, metrics float64
+		}{
+			{"mrr-new-business", computed.NewBusiness, entry.MRRNewBusiness},
+			{"mrr-expansion", computed.Expansion, entry.MRRExpansion},
+			{"mrr-contraction", computed.Contraction, entry.MRRContraction},
+			{"mrr-churn", computed.Churn, entry.MRRChurn},
+			{"mrr-reactivation", computed.Reactivation, entry.MRRReactivation},
+		} {
+			if math.Abs(m.waterfall-m.metrics) > tolerance {
+				discrepancies = append(discrepancies, Discrepancy{Date: entry.Date, Movement: m.name, Waterfall: m.waterfall, Metrics: m.metrics})
+			}
+		}
+	}
+	return discrepancies
+}
@@ -0,0 +1,118 @@
+package analytics
+
+import (
+	"io"
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// activities is a MetricsActivitiesSource over a slice.
+type activities []*cm.MetricsActivity
+
+func (a *activities) Read() (*cm.MetricsActivity, error) {
+	if len(*a) == 0 {
+		return nil, io.EOF
+	}
+	activity := (*a)[0]
+	*a = (*a)[1:]
+	return activity, nil
+}
+
+func activity(date cm.Timestamp, activityType string, movement float64, customer, plan string) *cm.MetricsActivity {
+	return &cm.MetricsActivity{
+		Date:                date,
+		Type:                activityType,
+		ActivityMrrMovement: movement,
+		Currency:            "USD",
+		CustomerUUID:        customer,
+		CustomerName:        "name of " + customer,
+		PlanExternalID:      plan,
+	}
+}
+
+func testActivities() *activities {
+	return &activities{
+		activity("2022-01-10T10:00:00Z", NewBusiness, 1000, "cus_1", "gold"),
+		activity("2022-01-20T10:00:00Z", NewBusiness, 500, "cus_2", "silver"),
+		// February 1st in New York
+		activity("2022-02-01T03:00:00Z", Expansion, 200, "cus_1", "platinum"),
+		activity("2022-03-15T10:00:00Z", Churn, -500, "cus_2", "silver"),
+		activity("2022-03-20T10:00:00Z", "other", 10, "cus_2", "silver"),
+		activity("2022-05-02T10:00:00Z", Contraction, -300, "cus_1", "gold"),
+	}
+}
+
+func TestBuildWaterfall(t *testing.T) {
+	waterfall, err := BuildWaterfall(testActivities(), cm.IntervalMonth, nil)
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+
+	if len(waterfall.Periods) != 5 || waterfall.Periods[3].Date != "2022-04-30" || waterfall.Periods[3].Net() != 0 {
+		spew.Dump(waterfall)
+		t.Fatal("Expected 5 months with April empty")
+	}
+	february := waterfall.Periods[1]
+	if february.Start != "2022-02-01" || february.Date != "2022-02-28" || february.Expansion != 200 ||
+		february.ByPlan["platinum"].Expansion != 200 || february.ByCustomer["cus_1"].Expansion != 200 {
+		spew.Dump(february)
+		t.Fatal("Unexpected February")
+	}
+	march := waterfall.Periods[2]
+	if march.Churn != -500 || march.Net() != -500 || len(march.ByCustomer) != 1 {
+		spew.Dump(march)
+		t.Fatal("Unexpected March")
+	}
+	total := waterfall.Total()
+	if total != (Movements{NewBusiness: 1500, Expansion: 200, Contraction: -300, Churn: -500}) ||
+		waterfall.Customers["cus_2"] != "name of cus_2" || waterfall.Currencies["USD"] != 5 {
+		spew.Dump(waterfall)
+		t.Fatal("Unexpected totals")
+	}
+}
+
+func TestBuildWaterfallAccountTimeZone(t *testing.T) {
+	waterfall, err := BuildWaterfall(testActivities(), cm.IntervalMonth, &cm.Account{TimeZone: "America/New_York"})
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	if waterfall.Periods[0].Expansion != 200 || waterfall.Periods[1].Expansion != 0 {
+		spew.Dump(waterfall)
+		t.Fatal("Expected the expansion in January in New York")
+	}
+}
+
+func TestWaterfallReconcile(t *testing.T) {
+	waterfall, err := BuildWaterfall(testActivities(), cm.IntervalMonth, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mrr := &cm.MRRResult{Entries: []*cm.MRRMetrics{
+		{Date: "2022-01-31", MRRNewBusiness: 1500},
+		{Date: "2022-02-28", MRRExpansion: 200},
+		{Date: "2022-03-31", MRRChurn: -450},
+		{Date: "2022-04-30"},
+		// partial last period
+		{Date: "2022-05-10", MRRContraction: -300.004},
+		{Date: "2022-06-30", MRRNewBusiness: 100},
+	}}
+
+	discrepancies := waterfall.Reconcile(mrr, 0.01)
+	if len(discrepancies) != 2 ||
+		discrepancies[0] != (Discrepancy{Date: "2022-03-31", Movement: "mrr-churn", Waterfall: -500, Metrics: -450}) ||
+		discrepancies[0].Difference() != -50 ||
+		discrepancies[1] != (Discrepancy{Date: "2022-06-30", Movement: "mrr-new-business", Waterfall: 0, Metrics: 100}) {
+		spew.Dump(discrepancies)
+		t.Fatal("Unexpected discrepancies")
+	}
+}
+
+func TestBuildWaterfallInvalidInterval(t *testing.T) {
+	if _, err := BuildWaterfall(&activities{}, "fortnight", nil); err == nil {
+		t.Fatal("Expected invalid interval to fail")
+	}
+}
@@ -0,0 +1,179 @@
+// Package cache decorates chartmogul.IApi with a cache of read responses.
+//
+// Entries are keyed on the endpoint and its normalised parameters, expire after the TTL
+// of their resource and are invalidated by the write methods called through the same API.
+// Concurrent identical requests share one call to the API.
+package cache
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Resource groups cached endpoints with the same TTL and invalidation.
+type Resource string
+
+// Cached resources.
+const (
+	Metrics     Resource = "metrics"
+	Activities  Resource = "activities"
+	Account     Resource = "account"
+	DataSources Resource = "data-sources"
+	Plans       Resource = "plans"
+	Customers   Resource = "customers"
+)
+
+// DefaultTTLs are the TTLs of the resources of a new API.
+var DefaultTTLs = map[Resource]time.Duration{
+	Metrics:     5 * time.Minute,
+	Activities:  time.Minute,
+	Account:     time.Hour,
+	DataSources: 10 * time.Minute,
+	Plans:       10 * time.Minute,
+	Customers:   time.Minute,
+}
+
+// API caches the read methods of the embedded API, other methods go through.
+type API struct {
+	// generation is incremented by invalidations, responses of older generations aren't stored.
+	// It's first for the alignment of atomic operations.
+	generation uint64
+
+	cm.IApi
+	// TTL per resource, resources without a positive TTL aren't cached.
+	// Set before the API is used concurrently.
+	TTL map[Resource]time.Duration
+
+	store Store
+	lock  sync.Mutex
+	calls map[string]*call
+}
+
+var _ cm.IApi = (*API)(nil)
+
+// call is a request in flight shared by identical requests.
+type call struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// New decorates the API with a cache in the store, an LRU of DefaultSize entries if nil.
+func New(api cm.IApi, store Store) *API {
+	if store == nil {
+		store = NewLRU(DefaultSize)
+	}
+	ttl := make(map[Resource]time.Duration, len(DefaultTTLs))
+	for resource, d := range DefaultTTLs {
+		ttl[resource] = d
+	}
+	return &API{IApi: api, TTL: ttl, store: store, calls: map[string]*call{}}
+}
+
+// Invalidate removes the cached responses of the resources, all of them if none are given.
+func (a *API) Invalidate(resources ...Resource) {
+	atomic.AddUint64(&a.generation, 1)
+	if len(resources) == 0 {
+		a.store.DeletePrefix("")
+		return
+	}
+	for _, resource := range resources {
+		a.store.DeletePrefix(string(resource) + "/")
+	}
+}
+
+// key of an endpoint and its parameters.
+func key(resource Resource, endpoint string, params interface{}) string {
+	data, _ := json.Marshal(params)
+	return string(resource) + "/" + endpoint + "?" + string(data)
+}
+
+// get decodes the cached response of the endpoint into result, a pointer to a new value
+// of the type returned by fetch. On a miss, fetch is called once for identical requests.
+// Responses are cached as JSON, so hits and misses decode the same data. A partial response
+// returned with an error, eg. by MetricsRetrieveDashboard, is decoded too but not cached.
+func (a *API) get(resource Resource, endpoint string, params interface{}, result interface{}, fetch func() (interface{}, error)) error {
+	ttl := a.TTL[resource]
+	if ttl <= 0 {
+		v, err := fetch()
+		if rv := reflect.ValueOf(v); rv.IsValid() && !rv.IsNil() {
+			reflect.ValueOf(result).Elem().Set(rv.Elem())
+		}
+		return err
+	}
+	k := key(resource, endpoint, params)
+	if data, ok := a.store.Get(k); ok {
+		return json.Unmarshal(data, result)
+	}
+
+	a.lock.Lock()
+	c, ok := a.calls[k]
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		a.calls[k] = c
+	}
+	a.lock.Unlock()
+	if ok {
+		<-c.done
+	} else {
+		generation := atomic.LoadUint64(&a.generation)
+		v, err := fetch()
+		if rv := reflect.ValueOf(v); rv.IsValid() && !rv.IsNil() {
+			var encodeErr error
+			if c.data, encodeErr = json.Marshal(v); err == nil {
+				err = encodeErr
+			}
+		}
+		c.err = err
+		if c.err == nil && atomic.LoadUint64(&a.generation) == generation {
+			a.store.Set(k, c.data, ttl)
+		}
+		a.lock.Lock()
+		delete(a.calls, k)
+		a.lock.Unlock()
+		close(c.done)
+	}
+	if c.data == nil {
+		return c.err
+	}
+	if err := json.Unmarshal(c.data, result); err != nil && c.err == nil {
+		return err
+	}
+	return c.err
+}
+
+// normalise returns the filter with the default interval and sorted lists,
+// so equivalent filters share the cache entry.
+func normalise(filter *cm.MetricsFilter) cm.MetricsFilter {
+	var f cm.MetricsFilter
+	if filter != nil {
+		f = *filter
+	}
+	f.StartDate = cm.Date(strings.TrimSpace(string(f.StartDate)))
+	f.EndDate = cm.Date(strings.TrimSpace(string(f.EndDate)))
+	f.Interval = cm.Interval(strings.ToLower(strings.TrimSpace(string(f.Interval))))
+	if f.Interval == "" {
+		f.Interval = cm.IntervalMonth
+	}
+	f.Geo = normaliseList(strings.ToUpper(f.Geo))
+	f.Plans = normaliseList(f.Plans)
+	return f
+}
+
+func normaliseList(list string) string {
+	var values []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
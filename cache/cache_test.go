@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// fakeAPI counts the calls, MRR blocks until release is closed if set.
+type fakeAPI struct {
+	cm.IApi
+	calls   int64
+	release chan struct{}
+	fail    bool
+}
+
+func (f *fakeAPI) MetricsRetrieveMRR(filter *cm.MetricsFilter) (*cm.MRRResult, error) {
+	atomic.AddInt64(&f.calls, 1)
+	if f.release != nil {
+		<-f.release
+	}
+	if f.fail {
+		return nil, errors.New("rate limited")
+	}
+	return &cm.MRRResult{Entries: []*cm.MRRMetrics{{Date: filter.EndDate, MRR: 1000}}}, nil
+}
+
+func (f *fakeAPI) MetricsListActivities(params *cm.MetricsListActivitiesParams) (*cm.MetricsActivities, error) {
+	atomic.AddInt64(&f.calls, 1)
+	return &cm.MetricsActivities{Entries: []*cm.MetricsActivity{
+		{UUID: "a1", Date: "2022-01-10 10:00:00", ActivityMrrMovement: -250.5, Type: "contraction"},
+		{UUID: "a2", Date: "2022-02-01T00:00:00.000+01:00", ActivityMrr: 1000, Type: "new_biz"},
+	}}, nil
+}
+
+// MetricsRetrieveDashboard fails the LTV series if fail is set.
+func (f *fakeAPI) MetricsRetrieveDashboard(filter *cm.MetricsFilter) (*cm.DashboardResult, error) {
+	atomic.AddInt64(&f.calls, 1)
+	result := &cm.DashboardResult{Entries: []*cm.DashboardMetrics{{Date: "2022-01-31", MRR: 1000, LTV: 5000}}}
+	if f.fail {
+		result.Entries[0].LTV = 0
+		result.Entries[0].Missing = []string{"ltv"}
+		return result, cm.DashboardErrors{"ltv": errors.New("rate limited")}
+	}
+	return result, nil
+}
+
+func (f *fakeAPI) MetricsBreakdownByRegion(filter *cm.MetricsFilter, regions map[string][]string) (*cm.MetricsBreakdown, error) {
+	atomic.AddInt64(&f.calls, 1)
+	return &cm.MetricsBreakdown{Groups: []string{"EMEA", cm.OtherGroup}, Dates: []cm.Date{filter.EndDate}}, nil
+}
+
+func (f *fakeAPI) RetrieveAccount() (*cm.Account, error) {
+	atomic.AddInt64(&f.calls, 1)
+	return &cm.Account{Name: "Acme"}, nil
+}
+
+func (f *fakeAPI) CreateInvoices(invoices []*cm.Invoice, customerUUID string) (*cm.Invoices, error) {
+	return &cm.Invoices{Invoices: invoices}, nil
+}
+
+func TestCachedMetrics(t *testing.T) {
+	api := &fakeAPI{}
+	a := New(api, nil)
+	first, err := a.MetricsRetrieveMRR(&cm.MetricsFilter{EndDate: "2022-01-31", Geo: "us, GB", Plans: "Pro,Basic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Entries[0].MRR = 0
+	second, err := a.MetricsRetrieveMRR(&cm.MetricsFilter{EndDate: "2022-01-31", Geo: "GB,US", Plans: "Basic,Pro", Interval: "month"})
+	if err != nil || api.calls != 1 || second.Entries[0].MRR != 1000 {
+		spew.Dump(second, api.calls, err)
+		t.Fatal("Expected equivalent filters to be served from the cache")
+	}
+	if _, err := a.MetricsRetrieveMRR(&cm.MetricsFilter{EndDate: "2022-02-28"}); err != nil || api.calls != 2 {
+		t.Fatal("Expected another filter to miss")
+	}
+
+	if _, err := a.CreateInvoices(nil, "cus_1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.MetricsRetrieveMRR(&cm.MetricsFilter{EndDate: "2022-02-28"}); err != nil || api.calls != 3 {
+		t.Fatal("Expected the invoices to invalidate metrics")
+	}
+}
+
+func TestInvalidateResource(t *testing.T) {
+	api := &fakeAPI{}
+	a := New(api, nil)
+	a.RetrieveAccount()                                            //nolint
+	a.MetricsRetrieveMRR(&cm.MetricsFilter{EndDate: "2022-01-31"}) //nolint
+	a.Invalidate(Metrics)
+	a.RetrieveAccount()                                            //nolint
+	a.MetricsRetrieveMRR(&cm.MetricsFilter{EndDate: "2022-01-31"}) //nolint
+	if api.calls != 3 {
+		t.Fatalf("Expected only metrics to be invalidated, %v calls", api.calls)
+	}
+}
+
+func TestDisabledResource(t *testing.T) {
+	api := &fakeAPI{}
+	a := New(api, nil)
+	a.TTL[Account] = 0
+	for i := 0; i < 2; i++ {
+		account, err := a.RetrieveAccount()
+		if err != nil || account.Name != "Acme" {
+			spew.Dump(account, err)
+			t.Fatal("Unexpected account")
+		}
+	}
+	if api.calls != 2 {
+		t.Fatalf("Expected account not to be cached, %v calls", api.calls)
+	}
+}
+
+func TestSingleflight(t *testing.T) {
+	api := &fakeAPI{release: make(chan struct{})}
+	a := New(api, nil)
+	var wg sync.WaitGroup
+	results := make([]*cm.MRRResult, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = a.MetricsRetrieveMRR(&cm.MetricsFilter{EndDate: "2022-01-31"})
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(api.release)
+	wg.Wait()
+	if api.calls != 1 {
+		t.Fatalf("Expected identical requests to share a call, %v calls", api.calls)
+	}
+	for _, result := range results {
+		if result == nil || result.Entries[0].MRR != 1000 {
+			spew.Dump(results)
+			t.Fatal("Unexpected result")
+		}
+	}
+	if results[0] == results[1] {
+		t.Fatal("Expected every caller to get its own result")
+	}
+}
+
+func TestErrorsNotCached(t *testing.T) {
+	api := &fakeAPI{fail: true}
+	a := New(api, nil)
+	if _, err := a.MetricsRetrieveMRR(&cm.MetricsFilter{}); err == nil {
+		t.Fatal("Expected the error")
+	}
+	api.fail = false
+	if result, err := a.MetricsRetrieveMRR(&cm.MetricsFilter{}); err != nil || result == nil || api.calls != 2 {
+		spew.Dump(result, err)
+		t.Fatal("Expected the error not to be cached")
+	}
+}
+
+func TestHitEqualsMiss(t *testing.T) {
+	api := &fakeAPI{}
+	direct, _ := api.MetricsListActivities(nil)
+	a := New(api, nil)
+	miss, err := a.MetricsListActivities(&cm.MetricsListActivitiesParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hit, err := a.MetricsListActivities(&cm.MetricsListActivitiesParams{})
+	if err != nil || api.calls != 2 {
+		t.Fatal("Expected the second request to hit")
+	}
+	if !reflect.DeepEqual(miss, direct) || !reflect.DeepEqual(hit, direct) {
+		spew.Dump(direct, miss, hit)
+		t.Fatal("Expected hits and misses to equal the response")
+	}
+}
+
+func TestPartialDashboardNotCached(t *testing.T) {
+	api := &fakeAPI{fail: true}
+	a := New(api, nil)
+	result, err := a.MetricsRetrieveDashboard(&cm.MetricsFilter{})
+	if _, ok := err.(cm.DashboardErrors); !ok || result == nil || len(result.Entries) != 1 ||
+		result.Entries[0].MRR != 1000 || result.Entries[0].Missing[0] != "ltv" {
+		spew.Dump(result, err)
+		t.Fatal("Expected the partial result with the series errors")
+	}
+	api.fail = false
+	result, err = a.MetricsRetrieveDashboard(&cm.MetricsFilter{})
+	if err != nil || api.calls != 2 || result.Entries[0].LTV != 5000 || result.Entries[0].Missing != nil {
+		spew.Dump(result, err)
+		t.Fatal("Expected the partial result not to be cached")
+	}
+	if _, err := a.MetricsRetrieveDashboard(&cm.MetricsFilter{}); err != nil || api.calls != 2 {
+		t.Fatal("Expected the complete result to be cached")
+	}
+
+	a.TTL[Metrics] = 0
+	api.fail = true
+	if result, err := a.MetricsRetrieveDashboard(&cm.MetricsFilter{}); err == nil || result == nil || result.Entries[0].MRR != 1000 {
+		spew.Dump(result, err)
+		t.Fatal("Expected the partial result without cache")
+	}
+}
+
+func TestCachedBreakdown(t *testing.T) {
+	api := &fakeAPI{}
+	a := New(api, nil)
+	regions := map[string][]string{"EMEA": {"DE", "GB"}}
+	first, err := a.MetricsBreakdownByRegion(&cm.MetricsFilter{EndDate: "2022-01-31", Plans: "Pro,Basic"}, regions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := a.MetricsBreakdownByRegion(&cm.MetricsFilter{EndDate: "2022-01-31", Plans: "Basic,Pro"}, regions)
+	if err != nil || api.calls != 1 || !reflect.DeepEqual(first, second) {
+		spew.Dump(first, second, api.calls, err)
+		t.Fatal("Expected the breakdown to be served from the cache")
+	}
+	if _, err := a.MetricsBreakdownByRegion(&cm.MetricsFilter{EndDate: "2022-01-31"}, map[string][]string{"APAC": {"JP"}}); err != nil || api.calls != 2 {
+		t.Fatal("Expected other regions to miss")
+	}
+}
@@ -0,0 +1,340 @@
+package cache
+
+import (
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// MetricsRetrieveAll returns the cached MetricsResult.
+func (a *API) MetricsRetrieveAll(metricsFilter *cm.MetricsFilter) (*cm.MetricsResult, error) {
+	result := &cm.MetricsResult{}
+	if err := a.get(Metrics, "all", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveAll(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsRetrieveMRR returns the cached MRRResult.
+func (a *API) MetricsRetrieveMRR(metricsFilter *cm.MetricsFilter) (*cm.MRRResult, error) {
+	result := &cm.MRRResult{}
+	if err := a.get(Metrics, "mrr", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveMRR(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsRetrieveARR returns the cached ARRResult.
+func (a *API) MetricsRetrieveARR(metricsFilter *cm.MetricsFilter) (*cm.ARRResult, error) {
+	result := &cm.ARRResult{}
+	if err := a.get(Metrics, "arr", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveARR(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsRetrieveARPA returns the cached ARPAResult.
+func (a *API) MetricsRetrieveARPA(metricsFilter *cm.MetricsFilter) (*cm.ARPAResult, error) {
+	result := &cm.ARPAResult{}
+	if err := a.get(Metrics, "arpa", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveARPA(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsRetrieveASP returns the cached ASPResult.
+func (a *API) MetricsRetrieveASP(metricsFilter *cm.MetricsFilter) (*cm.ASPResult, error) {
+	result := &cm.ASPResult{}
+	if err := a.get(Metrics, "asp", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveASP(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsRetrieveCustomerCount returns the cached CustomerCountResult.
+func (a *API) MetricsRetrieveCustomerCount(metricsFilter *cm.MetricsFilter) (*cm.CustomerCountResult, error) {
+	result := &cm.CustomerCountResult{}
+	if err := a.get(Metrics, "customer-count", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveCustomerCount(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsRetrieveCustomerChurnRate returns the cached CustomerChurnRateResult.
+func (a *API) MetricsRetrieveCustomerChurnRate(metricsFilter *cm.MetricsFilter) (*cm.CustomerChurnRateResult, error) {
+	result := &cm.CustomerChurnRateResult{}
+	if err := a.get(Metrics, "customer-churn-rate", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveCustomerChurnRate(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsRetrieveMRRChurnRate returns the cached MRRChurnRateResult.
+func (a *API) MetricsRetrieveMRRChurnRate(metricsFilter *cm.MetricsFilter) (*cm.MRRChurnRateResult, error) {
+	result := &cm.MRRChurnRateResult{}
+	if err := a.get(Metrics, "mrr-churn-rate", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveMRRChurnRate(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsRetrieveLTV returns the cached LTVResult.
+func (a *API) MetricsRetrieveLTV(metricsFilter *cm.MetricsFilter) (*cm.LTVResult, error) {
+	result := &cm.LTVResult{}
+	if err := a.get(Metrics, "ltv", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveLTV(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsRetrieveDashboard returns the cached DashboardResult. If some of the series fail,
+// the partial result is returned with the cm.DashboardErrors and isn't cached.
+func (a *API) MetricsRetrieveDashboard(metricsFilter *cm.MetricsFilter) (*cm.DashboardResult, error) {
+	result := &cm.DashboardResult{}
+	err := a.get(Metrics, "dashboard", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsRetrieveDashboard(metricsFilter)
+	})
+	if _, partial := err.(cm.DashboardErrors); partial {
+		return result, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsBreakdownByPlans returns the cached MetricsBreakdown.
+func (a *API) MetricsBreakdownByPlans(metricsFilter *cm.MetricsFilter, groups map[string][]string) (*cm.MetricsBreakdown, error) {
+	result := &cm.MetricsBreakdown{}
+	if err := a.get(Metrics, "breakdown-plans", []interface{}{normalise(metricsFilter), groups}, result, func() (interface{}, error) {
+		return a.IApi.MetricsBreakdownByPlans(metricsFilter, groups)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsBreakdownByPlanGroup returns the cached MetricsBreakdown.
+func (a *API) MetricsBreakdownByPlanGroup(metricsFilter *cm.MetricsFilter) (*cm.MetricsBreakdown, error) {
+	result := &cm.MetricsBreakdown{}
+	if err := a.get(Metrics, "breakdown-plan-group", normalise(metricsFilter), result, func() (interface{}, error) {
+		return a.IApi.MetricsBreakdownByPlanGroup(metricsFilter)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsBreakdownByGeo returns the cached MetricsBreakdown.
+func (a *API) MetricsBreakdownByGeo(metricsFilter *cm.MetricsFilter, countries []string) (*cm.MetricsBreakdown, error) {
+	result := &cm.MetricsBreakdown{}
+	if err := a.get(Metrics, "breakdown-geo", []interface{}{normalise(metricsFilter), countries}, result, func() (interface{}, error) {
+		return a.IApi.MetricsBreakdownByGeo(metricsFilter, countries)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsBreakdownByRegion returns the cached MetricsBreakdown.
+func (a *API) MetricsBreakdownByRegion(metricsFilter *cm.MetricsFilter, regions map[string][]string) (*cm.MetricsBreakdown, error) {
+	result := &cm.MetricsBreakdown{}
+	if err := a.get(Metrics, "breakdown-region", []interface{}{normalise(metricsFilter), regions}, result, func() (interface{}, error) {
+		return a.IApi.MetricsBreakdownByRegion(metricsFilter, regions)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsListActivities returns the cached MetricsActivities.
+func (a *API) MetricsListActivities(params *cm.MetricsListActivitiesParams) (*cm.MetricsActivities, error) {
+	result := &cm.MetricsActivities{}
+	if err := a.get(Activities, "activities", params, result, func() (interface{}, error) {
+		return a.IApi.MetricsListActivities(params)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsListCustomerActivities returns the cached MetricsCustomerActivities.
+func (a *API) MetricsListCustomerActivities(cursor *cm.Cursor, customerUUID string) (*cm.MetricsCustomerActivities, error) {
+	result := &cm.MetricsCustomerActivities{}
+	if err := a.get(Activities, "customer-activities", []interface{}{cursor, customerUUID}, result, func() (interface{}, error) {
+		return a.IApi.MetricsListCustomerActivities(cursor, customerUUID)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MetricsListCustomerSubscriptions returns the cached MetricsCustomerSubscriptions.
+func (a *API) MetricsListCustomerSubscriptions(cursor *cm.Cursor, customerUUID string) (*cm.MetricsCustomerSubscriptions, error) {
+	result := &cm.MetricsCustomerSubscriptions{}
+	if err := a.get(Activities, "customer-subscriptions", []interface{}{cursor, customerUUID}, result, func() (interface{}, error) {
+		return a.IApi.MetricsListCustomerSubscriptions(cursor, customerUUID)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RetrieveAccount returns the cached Account.
+func (a *API) RetrieveAccount() (*cm.Account, error) {
+	result := &cm.Account{}
+	if err := a.get(Account, "account", nil, result, func() (interface{}, error) {
+		return a.IApi.RetrieveAccount()
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RetrieveDataSource returns the cached DataSource.
+func (a *API) RetrieveDataSource(dataSourceUUID string) (*cm.DataSource, error) {
+	result := &cm.DataSource{}
+	if err := a.get(DataSources, "data-source", dataSourceUUID, result, func() (interface{}, error) {
+		return a.IApi.RetrieveDataSource(dataSourceUUID)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListDataSources returns the cached DataSources.
+func (a *API) ListDataSources() (*cm.DataSources, error) {
+	result := &cm.DataSources{}
+	if err := a.get(DataSources, "data-sources", nil, result, func() (interface{}, error) {
+		return a.IApi.ListDataSources()
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListDataSourcesWithFilters returns the cached DataSources.
+func (a *API) ListDataSourcesWithFilters(params *cm.ListDataSourcesParams) (*cm.DataSources, error) {
+	result := &cm.DataSources{}
+	if err := a.get(DataSources, "data-sources", params, result, func() (interface{}, error) {
+		return a.IApi.ListDataSourcesWithFilters(params)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RetrievePlan returns the cached Plan.
+func (a *API) RetrievePlan(planUUID string) (*cm.Plan, error) {
+	result := &cm.Plan{}
+	if err := a.get(Plans, "plan", planUUID, result, func() (interface{}, error) {
+		return a.IApi.RetrievePlan(planUUID)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListPlans returns the cached Plans.
+func (a *API) ListPlans(params *cm.ListPlansParams) (*cm.Plans, error) {
+	result := &cm.Plans{}
+	if err := a.get(Plans, "plans", params, result, func() (interface{}, error) {
+		return a.IApi.ListPlans(params)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RetrievePlanGroup returns the cached PlanGroup.
+func (a *API) RetrievePlanGroup(planGroupUUID string) (*cm.PlanGroup, error) {
+	result := &cm.PlanGroup{}
+	if err := a.get(Plans, "plan-group", planGroupUUID, result, func() (interface{}, error) {
+		return a.IApi.RetrievePlanGroup(planGroupUUID)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListPlanGroups returns the cached PlanGroups.
+func (a *API) ListPlanGroups(cursor *cm.Cursor) (*cm.PlanGroups, error) {
+	result := &cm.PlanGroups{}
+	if err := a.get(Plans, "plan-groups", cursor, result, func() (interface{}, error) {
+		return a.IApi.ListPlanGroups(cursor)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListPlanGroupPlans returns the cached PlanGroupPlans.
+func (a *API) ListPlanGroupPlans(cursor *cm.Cursor, planGroupUUID string) (*cm.PlanGroupPlans, error) {
+	result := &cm.PlanGroupPlans{}
+	if err := a.get(Plans, "plan-group-plans", []interface{}{cursor, planGroupUUID}, result, func() (interface{}, error) {
+		return a.IApi.ListPlanGroupPlans(cursor, planGroupUUID)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RetrieveCustomer returns the cached Customer.
+func (a *API) RetrieveCustomer(customerUUID string) (*cm.Customer, error) {
+	result := &cm.Customer{}
+	if err := a.get(Customers, "customer", customerUUID, result, func() (interface{}, error) {
+		return a.IApi.RetrieveCustomer(customerUUID)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListCustomers returns the cached Customers.
+func (a *API) ListCustomers(params *cm.ListCustomersParams) (*cm.Customers, error) {
+	result := &cm.Customers{}
+	if err := a.get(Customers, "customers", params, result, func() (interface{}, error) {
+		return a.IApi.ListCustomers(params)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SearchCustomers returns the cached Customers.
+func (a *API) SearchCustomers(params *cm.SearchCustomersParams) (*cm.Customers, error) {
+	result := &cm.Customers{}
+	if err := a.get(Customers, "search", params, result, func() (interface{}, error) {
+		return a.IApi.SearchCustomers(params)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RetrieveCustomersAttributes returns the cached Attributes.
+func (a *API) RetrieveCustomersAttributes(customerUUID string) (*cm.Attributes, error) {
+	result := &cm.Attributes{}
+	if err := a.get(Customers, "attributes", customerUUID, result, func() (interface{}, error) {
+		return a.IApi.RetrieveCustomersAttributes(customerUUID)
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSize is the number of entries of the LRU store created by New.
+const DefaultSize = 1000
+
+// Store keeps encoded responses by key, eg. LRU or a shared store like Redis.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the value of a key which didn't expire.
+	Get(key string) ([]byte, bool)
+	// Set stores the value of a key for the TTL.
+	Set(key string, value []byte, ttl time.Duration)
+	// DeletePrefix removes the keys starting with the prefix.
+	DeletePrefix(prefix string)
+}
+
+// LRU is an in-memory store evicting the least recently used entries beyond its size.
+type LRU struct {
+	size    int
+	lock    sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU creates a store of up to size entries, DefaultSize if not positive.
+func NewLRU(size int) *LRU {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &LRU{size: size, order: list.New(), entries: map[string]*list.Element{}, now: time.Now}
+}
+
+// Get returns the value of a key which didn't expire.
+func (s *LRU) Get(key string) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*lruEntry)
+	if !s.now().Before(entry.expires) {
+		s.remove(e)
+		return nil, false
+	}
+	s.order.MoveToFront(e)
+	return entry.value, true
+}
+
+// Set stores the value of a key for the TTL, evicting the least recently used entry when full.
+func (s *LRU) Set(key string, value []byte, ttl time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry := &lruEntry{key: key, value: value, expires: s.now().Add(ttl)}
+	if e, ok := s.entries[key]; ok {
+		e.Value = entry
+		s.order.MoveToFront(e)
+		return
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.size {
+		s.remove(s.order.Back())
+	}
+}
+
+// DeletePrefix removes the keys starting with the prefix.
+func (s *LRU) DeletePrefix(prefix string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key, e := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(e)
+		}
+	}
+}
+
+// Len returns the number of entries, including expired ones not evicted yet.
+func (s *LRU) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.order.Len()
+}
+
+func (s *LRU) remove(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.entries, e.Value.(*lruEntry).key)
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewLRU(2)
+	s.now = func() time.Time { return now }
+	s.Set("metrics/mrr", []byte("1"), time.Minute)
+	s.Set("metrics/arr", []byte("2"), time.Minute)
+	s.Get("metrics/mrr")
+	s.Set("account/account", []byte("3"), time.Hour)
+	if _, ok := s.Get("metrics/arr"); ok || s.Len() != 2 {
+		t.Fatal("Expected the least recently used entry to be evicted")
+	}
+	if v, ok := s.Get("metrics/mrr"); !ok || string(v) != "1" {
+		t.Fatal("Expected the recently used entry")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := s.Get("metrics/mrr"); ok {
+		t.Fatal("Expected the entry to expire")
+	}
+	if _, ok := s.Get("account/account"); !ok {
+		t.Fatal("Expected the entry not to expire")
+	}
+}
+
+func TestLRUDeletePrefix(t *testing.T) {
+	s := NewLRU(0)
+	s.Set("metrics/mrr", []byte("1"), time.Minute)
+	s.Set("plans/plans", []byte("2"), time.Minute)
+	s.DeletePrefix("metrics/")
+	if _, ok := s.Get("metrics/mrr"); ok || s.Len() != 1 {
+		t.Fatal("Expected the prefix to be deleted")
+	}
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Write methods invalidate the cached resources they change. Writes of data affecting
+// metrics invalidate metrics, activities and customers, data source purges everything.
+
+// CreateDataSource invalidates data sources.
+func (a *API) CreateDataSource(name string) (*cm.DataSource, error) {
+	defer a.Invalidate(DataSources)
+	return a.IApi.CreateDataSource(name)
+}
+
+// CreateDataSourceWithSystem invalidates data sources.
+func (a *API) CreateDataSourceWithSystem(dataSource *cm.DataSource) (*cm.DataSource, error) {
+	defer a.Invalidate(DataSources)
+	return a.IApi.CreateDataSourceWithSystem(dataSource)
+}
+
+// PurgeDataSource invalidates everything.
+func (a *API) PurgeDataSource(dataSourceUUID string) error {
+	defer a.Invalidate()
+	return a.IApi.PurgeDataSource(dataSourceUUID)
+}
+
+// EmptyDataSource invalidates everything.
+func (a *API) EmptyDataSource(dataSourceUUID string) error {
+	defer a.Invalidate()
+	return a.IApi.EmptyDataSource(dataSourceUUID)
+}
+
+// DeleteDataSource invalidates everything.
+func (a *API) DeleteDataSource(dataSourceUUID string) error {
+	defer a.Invalidate()
+	return a.IApi.DeleteDataSource(dataSourceUUID)
+}
+
+// CreateInvoices invalidates metrics, activities and customers.
+func (a *API) CreateInvoices(invoices []*cm.Invoice, customerUUID string) (*cm.Invoices, error) {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.CreateInvoices(invoices, customerUUID)
+}
+
+// DeleteInvoice invalidates metrics, activities and customers.
+func (a *API) DeleteInvoice(invoiceUUID string) error {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.DeleteInvoice(invoiceUUID)
+}
+
+// CreatePlan invalidates plans.
+func (a *API) CreatePlan(plan *cm.Plan) (*cm.Plan, error) {
+	defer a.Invalidate(Plans)
+	return a.IApi.CreatePlan(plan)
+}
+
+// UpdatePlan invalidates plans.
+func (a *API) UpdatePlan(plan *cm.Plan, planUUID string) (*cm.Plan, error) {
+	defer a.Invalidate(Plans)
+	return a.IApi.UpdatePlan(plan, planUUID)
+}
+
+// DeletePlan invalidates plans.
+func (a *API) DeletePlan(planUUID string) error {
+	defer a.Invalidate(Plans)
+	return a.IApi.DeletePlan(planUUID)
+}
+
+// CreatePlanGroup invalidates plans.
+func (a *API) CreatePlanGroup(planGroup *cm.PlanGroup) (*cm.PlanGroup, error) {
+	defer a.Invalidate(Plans)
+	return a.IApi.CreatePlanGroup(planGroup)
+}
+
+// UpdatePlanGroup invalidates plans.
+func (a *API) UpdatePlanGroup(planGroup *cm.PlanGroup, planGroupUUID string) (*cm.PlanGroup, error) {
+	defer a.Invalidate(Plans)
+	return a.IApi.UpdatePlanGroup(planGroup, planGroupUUID)
+}
+
+// DeletePlanGroup invalidates plans.
+func (a *API) DeletePlanGroup(planGroupUUID string) error {
+	defer a.Invalidate(Plans)
+	return a.IApi.DeletePlanGroup(planGroupUUID)
+}
+
+// CancelSubscription invalidates metrics, activities and customers.
+func (a *API) CancelSubscription(subscriptionUUID string, params *cm.CancelSubscriptionParams) (*cm.Subscription, error) {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.CancelSubscription(subscriptionUUID, params)
+}
+
+// CreateTransaction invalidates metrics, activities and customers.
+func (a *API) CreateTransaction(transaction *cm.Transaction, invoiceUUID string) (*cm.Transaction, error) {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.CreateTransaction(transaction, invoiceUUID)
+}
+
+// CreateCustomer invalidates customers.
+func (a *API) CreateCustomer(newCustomer *cm.NewCustomer) (*cm.Customer, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.CreateCustomer(newCustomer)
+}
+
+// UpdateCustomer invalidates customers.
+func (a *API) UpdateCustomer(customer *cm.Customer, customerUUID string) (*cm.Customer, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.UpdateCustomer(customer, customerUUID)
+}
+
+// UpdateCustomerV2 invalidates customers.
+func (a *API) UpdateCustomerV2(customer *cm.UpdateCustomer, customerUUID string) (*cm.Customer, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.UpdateCustomerV2(customer, customerUUID)
+}
+
+// MergeCustomers invalidates metrics, activities and customers.
+func (a *API) MergeCustomers(params *cm.MergeCustomersParams) error {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.MergeCustomers(params)
+}
+
+// DeleteCustomer invalidates metrics, activities and customers.
+func (a *API) DeleteCustomer(customerUUID string) error {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.DeleteCustomer(customerUUID)
+}
+
+// DeleteCustomerInvoices invalidates metrics, activities and customers.
+func (a *API) DeleteCustomerInvoices(dataSourceUUID, customerUUID string) error {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.DeleteCustomerInvoices(dataSourceUUID, customerUUID)
+}
+
+// DeleteCustomerInvoicesV2 invalidates metrics, activities and customers.
+func (a *API) DeleteCustomerInvoicesV2(dataSourceUUID, customerUUID string, params *cm.DeleteCustomerInvoicesParams) error {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.DeleteCustomerInvoicesV2(dataSourceUUID, customerUUID, params)
+}
+
+// AddTagsToCustomer invalidates customers.
+func (a *API) AddTagsToCustomer(customerUUID string, tags []string) (*cm.TagsResult, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.AddTagsToCustomer(customerUUID, tags)
+}
+
+// AddTagsToCustomersWithEmail invalidates customers.
+func (a *API) AddTagsToCustomersWithEmail(email string, tags []string) (*cm.Customers, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.AddTagsToCustomersWithEmail(email, tags)
+}
+
+// RemoveTagsFromCustomer invalidates customers.
+func (a *API) RemoveTagsFromCustomer(customerUUID string, tags []string) (*cm.TagsResult, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.RemoveTagsFromCustomer(customerUUID, tags)
+}
+
+// AddCustomAttributesToCustomer invalidates customers.
+func (a *API) AddCustomAttributesToCustomer(customerUUID string, customAttributes []*cm.CustomAttribute) (*cm.CustomAttributes, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.AddCustomAttributesToCustomer(customerUUID, customAttributes)
+}
+
+// AddCustomAttributesWithEmail invalidates customers.
+func (a *API) AddCustomAttributesWithEmail(email string, customAttributes []*cm.CustomAttribute) (*cm.Customers, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.AddCustomAttributesWithEmail(email, customAttributes)
+}
+
+// UpdateCustomAttributesOfCustomer invalidates customers.
+func (a *API) UpdateCustomAttributesOfCustomer(customerUUID string, customAttributes map[string]interface{}) (*cm.CustomAttributes, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.UpdateCustomAttributesOfCustomer(customerUUID, customAttributes)
+}
+
+// RemoveCustomAttributes invalidates customers.
+func (a *API) RemoveCustomAttributes(customerUUID string, customAttributes []string) (*cm.CustomAttributes, error) {
+	defer a.Invalidate(Customers)
+	return a.IApi.RemoveCustomAttributes(customerUUID, customAttributes)
+}
+
+// CreateSubscriptionEvent invalidates metrics, activities and customers.
+func (a *API) CreateSubscriptionEvent(newSubscriptionEvent *cm.SubscriptionEvent) (*cm.SubscriptionEvent, error) {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.CreateSubscriptionEvent(newSubscriptionEvent)
+}
+
+// UpdateSubscriptionEvent invalidates metrics, activities and customers.
+func (a *API) UpdateSubscriptionEvent(subscriptionEvent *cm.SubscriptionEvent) (*cm.SubscriptionEvent, error) {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.UpdateSubscriptionEvent(subscriptionEvent)
+}
+
+// DeleteSubscriptionEvent invalidates metrics, activities and customers.
+func (a *API) DeleteSubscriptionEvent(deleteParams *cm.DeleteSubscriptionEvent) error {
+	defer a.Invalidate(Metrics, Activities, Customers)
+	return a.IApi.DeleteSubscriptionEvent(deleteParams)
+}
@@ -10,6 +10,7 @@
 package chartmogul
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
@@ -138,6 +139,11 @@ type IApi interface {
 	MetricsRetrieveCustomerChurnRate(metricsFilter *MetricsFilter) (*CustomerChurnRateResult, error)
 	MetricsRetrieveMRRChurnRate(metricsFilter *MetricsFilter) (*MRRChurnRateResult, error)
 	MetricsRetrieveLTV(metricsFilter *MetricsFilter) (*LTVResult, error)
+	MetricsRetrieveDashboard(metricsFilter *MetricsFilter) (*DashboardResult, error)
+	MetricsBreakdownByPlanGroup(metricsFilter *MetricsFilter) (*MetricsBreakdown, error)
+	MetricsBreakdownByPlans(metricsFilter *MetricsFilter, groups map[string][]string) (*MetricsBreakdown, error)
+	MetricsBreakdownByGeo(metricsFilter *MetricsFilter, countries []string) (*MetricsBreakdown, error)
+	MetricsBreakdownByRegion(metricsFilter *MetricsFilter, regions map[string][]string) (*MetricsBreakdown, error)
 
 	// Metrics - Subscriptions & Activities
 	MetricsListCustomerSubscriptions(cursor *Cursor, customerUUID string) (*MetricsCustomerSubscriptions, error)
@@ -145,6 +151,7 @@ type IApi interface {
 	MetricsListActivities(MetricsListActivitiesParams *MetricsListActivitiesParams) (*MetricsActivities, error)
 	MetricsCreateActivitiesExport(CreateMetricsActivitiesExportParam *CreateMetricsActivitiesExportParam) (*MetricsActivitiesExport, error)
 	MetricsRetrieveActivitiesExport(activitiesExportUUID string) (*MetricsActivitiesExport, error)
+	ExportActivities(ctx context.Context, params *CreateMetricsActivitiesExportParam) (*MetricsActivitiesReader, error)
 
 	// Account
 	RetrieveAccount() (*Account, error)
@@ -264,6 +271,18 @@ func Setup(timeoutConf time.Duration) {
 	timeout = timeoutConf
 }
 
+// SetMaxConcurrency limits the number of requests in flight globally, across all calls,
+// requests over the limit wait for their turn. Zero or less means no limit, the default.
+func SetMaxConcurrency(maxConcurrency int) {
+	requestSlotsLock.Lock()
+	defer requestSlotsLock.Unlock()
+	if maxConcurrency <= 0 {
+		requestSlots = nil
+		return
+	}
+	requestSlots = make(chan struct{}, maxConcurrency)
+}
+
 // SetURL changes target URL for the module globally.
 func SetURL(specialURL string) {
 	url = specialURL
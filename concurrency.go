@@ -0,0 +1,60 @@
+package chartmogul
+
+import "sync"
+
+// maxParallel is the number of requests one call of the library issues in parallel,
+// eg. the chunks of a long metrics date range.
+const maxParallel = 8
+
+var (
+	requestSlotsLock sync.RWMutex
+	// requestSlots limit the requests in flight globally, unlimited if nil, see SetMaxConcurrency.
+	requestSlots chan struct{}
+)
+
+// acquireRequestSlot blocks until the request can be sent without exceeding the concurrency limit.
+// Call the returned function once the response is read.
+func acquireRequestSlot() (release func()) {
+	requestSlotsLock.RLock()
+	slots := requestSlots
+	requestSlotsLock.RUnlock()
+	if slots == nil {
+		return func() {}
+	}
+	slots <- struct{}{}
+	return func() { <-slots }
+}
+
+// parallel runs fn for 0..n-1, at most maxParallel at a time, and returns the errors by index.
+func parallel(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	slots := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+// Parallel runs the functions concurrently, at most 8 at a time, eg. to read the records of a customer
+// from several endpoints. It returns the first error in the order of the functions.
+func Parallel(fns ...func() error) error {
+	return firstError(parallel(len(fns), func(i int) error { return fns[i]() }))
+}
+
+// firstError returns the first non-nil error.
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
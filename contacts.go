@@ -9,6 +9,7 @@ type Contact struct {
 	CustomerExternalID string                 `json:"customer_external_id,omitempty"`
 	CustomerUUID       string                 `json:"customer_uuid,omitempty"`
 	DataSourceUUID     string                 `json:"data_source_uuid,omitempty"`
+	Email              string                 `json:"email,omitempty"`
 	FirstName          string                 `json:"first_name,omitempty"`
 	LastName           string                 `json:"last_name,omitempty"`
 	LinkedIn           string                 `json:"linked_in,omitempty"`
@@ -0,0 +1,104 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Converter converts amounts into the reporting currency at the rates of their dates.
+// Dates of timestamps are taken in UTC, the amounts of the API are in minor units.
+type Converter struct {
+	Table *Table
+	// Currency is the reporting currency.
+	Currency string
+}
+
+// NewConverter converts into the reporting currency with the rates of the table.
+func NewConverter(table *Table, reporting string) *Converter {
+	return &Converter{Table: table, Currency: strings.ToUpper(reporting)}
+}
+
+// Minor converts an amount in minor units of a currency into minor units of the reporting
+// currency on the date, minding the decimal places of both, eg. JPY and USD.
+func (c *Converter) Minor(amount float64, currency string, date cm.Date) (float64, error) {
+	rate, err := c.Table.Rate(currency, c.Currency, date)
+	if err != nil {
+		return 0, err
+	}
+	exp := cm.CurrencyExponent(c.Currency) - cm.CurrencyExponent(currency)
+	return amount * rate * math.Pow10(exp), nil
+}
+
+// Money converts money into the reporting currency on the date, rounding to the minor unit.
+func (c *Converter) Money(m cm.Money, date cm.Date) (cm.Money, error) {
+	rate, err := c.Table.Rate(m.Currency, c.Currency, date)
+	if err != nil {
+		return cm.Money{}, err
+	}
+	return cm.MoneyFromMajor(m.Major()*rate, c.Currency), nil
+}
+
+// Activity returns a copy of the activity with its amounts in the reporting currency on its date.
+func (c *Converter) Activity(activity *cm.MetricsActivity) (*cm.MetricsActivity, error) {
+	date, err := activity.Date.Date(time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("chartmogul: activity %v: %v", activity.UUID, err)
+	}
+	converted := *activity
+	for _, amount := range []*float64{&converted.ActivityArr, &converted.ActivityMrr, &converted.ActivityMrrMovement} {
+		if *amount, err = c.Minor(*amount, activity.Currency, date); err != nil {
+			return nil, fmt.Errorf("chartmogul: activity %v: %v", activity.UUID, err)
+		}
+	}
+	converted.Currency = c.Currency
+	return &converted, nil
+}
+
+// Subscription returns a copy of the subscription with MRR and ARR in the reporting currency on the date,
+// eg. the date of a report.
+func (c *Converter) Subscription(subscription *cm.MetricsCustomerSubscription, date cm.Date) (*cm.MetricsCustomerSubscription, error) {
+	converted := *subscription
+	var err error
+	for _, amount := range []*float64{&converted.MRR, &converted.ARR} {
+		if *amount, err = c.Minor(*amount, subscription.Currency, date); err != nil {
+			return nil, fmt.Errorf("chartmogul: subscription %v: %v", subscription.ExternalID, err)
+		}
+	}
+	converted.Currency, converted.CurrencySign = c.Currency, ""
+	return &converted, nil
+}
+
+// Invoice returns the total of the invoice in the reporting currency on its date.
+func (c *Converter) Invoice(invoice *cm.Invoice) (cm.Money, error) {
+	date, err := invoice.Date.Date(time.UTC)
+	if err == nil {
+		var total cm.Money
+		if total, err = c.Money(invoice.Total(), date); err == nil {
+			return total, nil
+		}
+	}
+	return cm.Money{}, fmt.Errorf("chartmogul: invoice %v: %v", invoice.ExternalID, err)
+}
+
+// Activities converts the activities of the source as they are read,
+// eg. for analytics.BuildWaterfall in the reporting currency.
+func (c *Converter) Activities(source cm.MetricsActivitiesSource) cm.MetricsActivitiesSource {
+	return &convertedActivities{source: source, converter: c}
+}
+
+type convertedActivities struct {
+	source    cm.MetricsActivitiesSource
+	converter *Converter
+}
+
+func (s *convertedActivities) Read() (*cm.MetricsActivity, error) {
+	activity, err := s.source.Read()
+	if err != nil {
+		return nil, err
+	}
+	return s.converter.Activity(activity)
+}
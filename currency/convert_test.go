@@ -0,0 +1,72 @@
+package currency
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// activities is a MetricsActivitiesSource over a slice.
+type activities []*cm.MetricsActivity
+
+func (a *activities) Read() (*cm.MetricsActivity, error) {
+	if len(*a) == 0 {
+		return nil, io.EOF
+	}
+	activity := (*a)[0]
+	*a = (*a)[1:]
+	return activity, nil
+}
+
+func testConverter(t *testing.T) *Converter {
+	table, err := LoadCSV(strings.NewReader(testRates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewConverter(table, "usd")
+}
+
+func TestConvertActivities(t *testing.T) {
+	c := testConverter(t)
+	source := c.Activities(&activities{
+		{UUID: "a1", Date: "2022-01-10T10:00:00Z", ActivityMrr: 1000, ActivityMrrMovement: 1000, ActivityArr: 12000, Currency: "EUR"},
+		{UUID: "a2", Date: "2022-02-10T10:00:00Z", ActivityMrr: 5000, ActivityMrrMovement: 5000, Currency: "JPY"},
+	})
+	first, err := source.Read()
+	if err != nil || first.Currency != "USD" || !near(first.ActivityMrrMovement, 1100) || !near(first.ActivityArr, 13200) {
+		spew.Dump(first, err)
+		t.Fatal("Unexpected EUR activity")
+	}
+	second, err := source.Read()
+	if err != nil || !near(second.ActivityMrr, 5000) {
+		spew.Dump(second, err)
+		t.Fatal("Expected 5000 JPY to be 50 USD")
+	}
+	if _, err := source.Read(); err != io.EOF {
+		t.Fatal("Expected the end of activities")
+	}
+
+	if _, err := c.Activity(&cm.MetricsActivity{UUID: "a3", Date: "2022-01-10T10:00:00Z", Currency: "GBP"}); err == nil {
+		t.Fatal("Expected an activity without rate to fail")
+	}
+}
+
+func TestConvertSubscriptionAndInvoice(t *testing.T) {
+	c := testConverter(t)
+	subscription, err := c.Subscription(&cm.MetricsCustomerSubscription{MRR: 1000, ARR: 12000, Currency: "EUR", CurrencySign: "€"}, "2022-02-28")
+	if err != nil || !near(subscription.MRR, 1200) || !near(subscription.ARR, 14400) || subscription.Currency != "USD" {
+		spew.Dump(subscription, err)
+		t.Fatal("Unexpected subscription")
+	}
+
+	invoice := &cm.Invoice{ExternalID: "inv_1", Date: "2022-01-20T00:00:00Z", Currency: "EUR",
+		LineItems: []*cm.LineItem{{AmountInCents: 1000}, {AmountInCents: 505}}}
+	total, err := c.Invoice(invoice)
+	if err != nil || total != cm.NewMoney(1656, "USD") {
+		spew.Dump(total, err)
+		t.Fatal("Unexpected invoice total")
+	}
+}
@@ -0,0 +1,141 @@
+package currency
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// FXCurrencyPeriod is the part of a period in one original currency.
+type FXCurrencyPeriod struct {
+	// LocalMRR is the MRR at the end of the period in the original currency.
+	LocalMRR float64 `json:"local-mrr"`
+	// LocalMovement is the net MRR movement within the period in the original currency.
+	LocalMovement float64 `json:"local-mrr-movement"`
+	// Rate into the reporting currency on the last day of the period.
+	Rate     float64 `json:"rate"`
+	MRR      float64 `json:"mrr"`
+	Organic  float64 `json:"organic"`
+	FXImpact float64 `json:"fx-impact"`
+}
+
+// FXPeriod splits the change of MRR in the reporting currency within a period into organic
+// movement and FX impact: OpeningMRR + Organic + FXImpact = MRR.
+//
+// Organic is the net movement converted at the rate of the period's end,
+// FXImpact is the revaluation of the opening MRR from the previous period's rate to this one.
+type FXPeriod struct {
+	Start      cm.Date                      `json:"start"`
+	Date       cm.Date                      `json:"date"`
+	OpeningMRR float64                      `json:"opening-mrr"`
+	Organic    float64                      `json:"organic"`
+	FXImpact   float64                      `json:"fx-impact"`
+	MRR        float64                      `json:"mrr"`
+	ByCurrency map[string]*FXCurrencyPeriod `json:"by-currency"`
+}
+
+// FXReport is the MRR in the reporting currency period by period, amounts in its minor units.
+type FXReport struct {
+	Currency string      `json:"currency"`
+	Interval cm.Interval `json:"interval"`
+	Periods  []*FXPeriod `json:"periods"`
+}
+
+// FXImpact reads the activities in their original currencies from the source and reports
+// the MRR in the reporting currency period by period, with the FX impact apart from organic movement.
+//
+// Periods follow the account's time zone and week start, from the first to the last activity;
+// the account can be nil for UTC periods with weeks starting on Monday.
+func (c *Converter) FXImpact(source cm.MetricsActivitiesSource, interval cm.Interval, account *cm.Account) (*FXReport, error) {
+	if interval == "" {
+		interval = cm.IntervalMonth
+	}
+	if !interval.IsValid() {
+		return nil, fmt.Errorf("chartmogul: invalid interval %q", interval)
+	}
+	loc, weekStart := time.UTC, time.Monday
+	if account != nil {
+		var err error
+		if loc, err = account.Location(); err != nil {
+			return nil, err
+		}
+		weekStart = account.WeekStart()
+	}
+
+	// movements by period start and currency
+	movements := map[cm.Date]map[string]float64{}
+	currencies := map[string]bool{}
+	var first, last time.Time
+	for {
+		activity, err := source.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		t, err := activity.Date.In(loc)
+		if err != nil {
+			return nil, fmt.Errorf("chartmogul: activity %v: %v", activity.UUID, err)
+		}
+		start := interval.PeriodStart(t, weekStart)
+		key := cm.NewDate(start)
+		if movements[key] == nil {
+			movements[key] = map[string]float64{}
+		}
+		movements[key][activity.Currency] += activity.ActivityMrrMovement
+		currencies[activity.Currency] = true
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+
+	report := &FXReport{Currency: c.Currency, Interval: interval, Periods: []*FXPeriod{}}
+	if first.IsZero() {
+		return report, nil
+	}
+	sorted := make([]string, 0, len(currencies))
+	for currency := range currencies {
+		sorted = append(sorted, currency)
+	}
+	sort.Strings(sorted)
+
+	var previous *FXPeriod
+	for start := first; !start.After(last); start = interval.AddTo(start, 1) {
+		end := interval.PeriodEnd(start, weekStart)
+		period := &FXPeriod{Start: cm.NewDate(start), Date: cm.NewDate(end), ByCurrency: map[string]*FXCurrencyPeriod{}}
+		for _, currency := range sorted {
+			rate, err := c.Table.Rate(currency, c.Currency, period.Date)
+			if err != nil {
+				return nil, err
+			}
+			p := &FXCurrencyPeriod{LocalMovement: movements[period.Start][currency], Rate: rate}
+			scale := rate * math.Pow10(cm.CurrencyExponent(c.Currency)-cm.CurrencyExponent(currency))
+			if previous != nil {
+				before := previous.ByCurrency[currency]
+				p.LocalMRR = before.LocalMRR
+				p.FXImpact = before.LocalMRR*scale - before.MRR
+			}
+			p.LocalMRR += p.LocalMovement
+			p.Organic = p.LocalMovement * scale
+			p.MRR = p.LocalMRR * scale
+			period.ByCurrency[currency] = p
+			period.Organic += p.Organic
+			period.FXImpact += p.FXImpact
+			period.MRR += p.MRR
+		}
+		if previous != nil {
+			period.OpeningMRR = previous.MRR
+		}
+		report.Periods = append(report.Periods, period)
+		previous = period
+	}
+	return report, nil
+}
@@ -0,0 +1,45 @@
+package currency
+
+import (
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestFXImpact(t *testing.T) {
+	c := testConverter(t)
+	report, err := c.FXImpact(&activities{
+		{UUID: "a1", Date: "2022-01-10T10:00:00Z", ActivityMrrMovement: 1000, Currency: "EUR"},
+		{UUID: "a2", Date: "2022-01-12T10:00:00Z", ActivityMrrMovement: 500, Currency: "USD"},
+		{UUID: "a3", Date: "2022-02-10T10:00:00Z", ActivityMrrMovement: 100, Currency: "EUR"},
+	}, cm.IntervalMonth, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Periods) != 2 || report.Currency != "USD" {
+		spew.Dump(report)
+		t.Fatal("Unexpected report")
+	}
+	january, february := report.Periods[0], report.Periods[1]
+	if january.Date != "2022-01-31" || !near(january.MRR, 1600) || !near(january.Organic, 1600) || january.FXImpact != 0 {
+		spew.Dump(january)
+		t.Fatal("Unexpected January")
+	}
+	if !near(february.OpeningMRR, 1600) || !near(february.Organic, 120) || !near(february.FXImpact, 100) ||
+		!near(february.MRR, 1820) || !near(february.ByCurrency["EUR"].LocalMRR, 1100) || february.ByCurrency["USD"].FXImpact != 0 {
+		spew.Dump(february)
+		t.Fatal("Unexpected February")
+	}
+	if !near(february.OpeningMRR+february.Organic+february.FXImpact, february.MRR) {
+		t.Fatal("Expected the opening MRR, organic movement and FX impact to add up")
+	}
+}
+
+func TestFXImpactEmpty(t *testing.T) {
+	report, err := testConverter(t).FXImpact(&activities{}, "", nil)
+	if err != nil || len(report.Periods) != 0 || report.Interval != cm.IntervalMonth {
+		spew.Dump(report, err)
+		t.Fatal("Unexpected empty report")
+	}
+}
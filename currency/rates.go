@@ -0,0 +1,179 @@
+// Package currency converts ChartMogul amounts into one reporting currency with a supplied
+// table of dated exchange rates, instead of ChartMogul's own conversion.
+package currency
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Rate is the price of one unit of From in units of To, valid from Date until the next rate of the pair.
+type Rate struct {
+	Date cm.Date `json:"date"`
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
+
+type pair struct {
+	from, to string
+}
+
+// Table holds dated exchange rates. A conversion uses the latest rate on or before the date,
+// the inverse of the opposite pair, or a cross rate through a third currency.
+type Table struct {
+	rates map[pair][]Rate
+	// neighbours are the currencies quoted against a currency, either way
+	neighbours map[string]map[string]bool
+}
+
+// NewTable creates a table of the rates.
+func NewTable(rates ...Rate) (*Table, error) {
+	t := &Table{rates: map[pair][]Rate{}, neighbours: map[string]map[string]bool{}}
+	for _, r := range rates {
+		if err := t.Add(r); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Add adds a rate, replacing the rate of the pair on the same date.
+func (t *Table) Add(r Rate) error {
+	d, err := r.Date.Time()
+	if err != nil {
+		return fmt.Errorf("chartmogul: rate %v/%v: %v", r.From, r.To, err)
+	}
+	r.Date = cm.NewDate(d)
+	r.From, r.To = strings.ToUpper(strings.TrimSpace(r.From)), strings.ToUpper(strings.TrimSpace(r.To))
+	if r.From == "" || r.To == "" || r.From == r.To || r.Rate <= 0 {
+		return fmt.Errorf("chartmogul: invalid rate %v/%v %v on %v", r.From, r.To, r.Rate, r.Date)
+	}
+	p := pair{r.From, r.To}
+	rates := t.rates[p]
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].Date >= r.Date })
+	if i < len(rates) && rates[i].Date == r.Date {
+		rates[i] = r
+		return nil
+	}
+	rates = append(rates, Rate{})
+	copy(rates[i+1:], rates[i:])
+	rates[i] = r
+	t.rates[p] = rates
+
+	for _, c := range [][2]string{{r.From, r.To}, {r.To, r.From}} {
+		if t.neighbours[c[0]] == nil {
+			t.neighbours[c[0]] = map[string]bool{}
+		}
+		t.neighbours[c[0]][c[1]] = true
+	}
+	return nil
+}
+
+// direct returns the rate of the pair or the inverse of the opposite pair on the date.
+func (t *Table) direct(from, to string, date cm.Date) (float64, bool) {
+	if r, ok := latest(t.rates[pair{from, to}], date); ok {
+		return r, true
+	}
+	if r, ok := latest(t.rates[pair{to, from}], date); ok {
+		return 1 / r, true
+	}
+	return 0, false
+}
+
+func latest(rates []Rate, date cm.Date) (float64, bool) {
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].Date > date })
+	if i == 0 {
+		return 0, false
+	}
+	return rates[i-1].Rate, true
+}
+
+// Rate returns the rate converting from one currency to another on the date.
+func (t *Table) Rate(from, to string, date cm.Date) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+	if d, err := date.Time(); err == nil {
+		date = cm.NewDate(d)
+	}
+	if r, ok := t.direct(from, to, date); ok {
+		return r, nil
+	}
+	via := make([]string, 0, len(t.neighbours[from]))
+	for c := range t.neighbours[from] {
+		via = append(via, c)
+	}
+	// the cross rate mustn't depend on map order
+	sort.Strings(via)
+	for _, c := range via {
+		first, ok := t.direct(from, c, date)
+		if !ok {
+			continue
+		}
+		if second, ok := t.direct(c, to, date); ok {
+			return first * second, nil
+		}
+	}
+	return 0, fmt.Errorf("chartmogul: no exchange rate from %v to %v on %v", from, to, date)
+}
+
+// LoadCSV reads rates from CSV with a header of date, from, to and rate columns in any order.
+// Other columns are ignored.
+func LoadCSV(r io.Reader) (*Table, error) {
+	records := csv.NewReader(r)
+	records.FieldsPerRecord = -1
+	header, err := records.Read()
+	if err != nil {
+		return nil, fmt.Errorf("chartmogul: rates header: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "from", "to", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("chartmogul: rates without %v column", name)
+		}
+	}
+	t, _ := NewTable()
+	for line := 2; ; line++ {
+		record, err := records.Read()
+		if err == io.EOF {
+			return t, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		rate, err := strconv.ParseFloat(field("rate"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("chartmogul: rates line %v: %v", line, err)
+		}
+		if err := t.Add(Rate{Date: cm.Date(field("date")), From: field("from"), To: field("to"), Rate: rate}); err != nil {
+			return nil, fmt.Errorf("chartmogul: rates line %v: %v", line, err)
+		}
+	}
+}
+
+// LoadJSON reads rates from a JSON array of objects with date, from, to and rate.
+func LoadJSON(r io.Reader) (*Table, error) {
+	var rates []Rate
+	if err := json.NewDecoder(r).Decode(&rates); err != nil {
+		return nil, err
+	}
+	return NewTable(rates...)
+}
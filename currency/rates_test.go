@@ -0,0 +1,80 @@
+package currency
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+const testRates = `date,from,to,rate,source
+2022-01-01,EUR,USD,1.1,treasury
+2022-02-01,eur,usd,1.2,treasury
+2022-01-01,USD,JPY,100,treasury
+`
+
+func TestLoadCSV(t *testing.T) {
+	table, err := LoadCSV(strings.NewReader(testRates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		from, to string
+		date     string
+		rate     float64
+	}{
+		{"EUR", "USD", "2022-01-15", 1.1},
+		{"EUR", "USD", "2022-02-01", 1.2},
+		{"EUR", "USD", "2023-01-01", 1.2},
+		{"USD", "EUR", "2022-02-10", 1 / 1.2},
+		{"EUR", "JPY", "2022-02-10", 120},
+		{"jpy", "eur", "2022-02-10", 1.0 / 120},
+		{"USD", "USD", "2000-01-01", 1},
+	}
+	for _, c := range cases {
+		rate, err := table.Rate(c.from, c.to, cm.Date(c.date))
+		if err != nil || !near(rate, c.rate) {
+			spew.Dump(c, rate, err)
+			t.Fatalf("Unexpected rate from %v to %v", c.from, c.to)
+		}
+	}
+	if _, err := table.Rate("EUR", "USD", "2021-12-31"); err == nil {
+		t.Fatal("Expected no rate before the first date")
+	}
+	if _, err := table.Rate("EUR", "GBP", "2022-02-10"); err == nil {
+		t.Fatal("Expected no rate for unknown currencies")
+	}
+}
+
+func TestLoadCSVErrors(t *testing.T) {
+	if _, err := LoadCSV(strings.NewReader("date,from,rate\n")); err == nil {
+		t.Fatal("Expected a missing column to fail")
+	}
+	if _, err := LoadCSV(strings.NewReader("date,from,to,rate\n2022-01-01,EUR,USD,x\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		spew.Dump(err)
+		t.Fatal("Expected an invalid rate to fail")
+	}
+	if _, err := LoadCSV(strings.NewReader("date,from,to,rate\n2022-01-01,EUR,EUR,1\n")); err == nil {
+		t.Fatal("Expected a rate of a currency to itself to fail")
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	table, err := LoadJSON(strings.NewReader(`[
+		{"date": "2022-02-01", "from": "GBP", "to": "USD", "rate": 1.35},
+		{"date": "2022-01-01", "from": "GBP", "to": "USD", "rate": 1.3}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate, err := table.Rate("GBP", "USD", "2022-01-31"); err != nil || rate != 1.3 {
+		spew.Dump(rate, err)
+		t.Fatal("Expected rates sorted by date")
+	}
+}
@@ -6,15 +6,15 @@ import "strings"
 type Customer struct {
 	ID uint32 `json:"id,omitempty"`
 	// Basic info
-	DataSourceUUID  string   `json:"data_source_uuid,omitempty"`
-	DataSourceUUIDs []string `json:"data_source_uuids,omitempty"`
-	UUID            string   `json:"uuid,omitempty"`
-	ExternalID      string   `json:"external_id,omitempty"`
-	ExternalIDs     []string `json:"external_ids,omitempty"`
-	Name            string   `json:"name,omitempty"`
-	Email           string   `json:"email,omitempty"`
-	Status          string   `json:"status,omitempty"`
-	CustomerSince   string   `json:"customer-since,omitempty"`
+	DataSourceUUID  string    `json:"data_source_uuid,omitempty"`
+	DataSourceUUIDs []string  `json:"data_source_uuids,omitempty"`
+	UUID            string    `json:"uuid,omitempty"`
+	ExternalID      string    `json:"external_id,omitempty"`
+	ExternalIDs     []string  `json:"external_ids,omitempty"`
+	Name            string    `json:"name,omitempty"`
+	Email           string    `json:"email,omitempty"`
+	Status          string    `json:"status,omitempty"`
+	CustomerSince   Timestamp `json:"customer-since,omitempty"`
 
 	Attributes *Attributes `json:"attributes,omitempty"`
 	Address    *Address    `json:"address,omitempty"`
@@ -29,14 +29,14 @@ type Customer struct {
 	CurrencySign      string  `json:"currency-sign,omitempty"`
 
 	// For update
-	Company            string `json:"company,omitempty"`
-	Country            string `json:"country,omitempty"`
-	State              string `json:"state,omitempty"`
-	City               string `json:"city,omitempty"`
-	Zip                string `json:"zip,omitempty"`
-	LeadCreatedAt      string `json:"lead_created_at,omitempty"`
-	FreeTrialStartedAt string `json:"free_trial_started_at,omitempty"`
-	WebsiteUrl         string `json:"website_url,omitempty"`
+	Company            string    `json:"company,omitempty"`
+	Country            string    `json:"country,omitempty"`
+	State              string    `json:"state,omitempty"`
+	City               string    `json:"city,omitempty"`
+	Zip                string    `json:"zip,omitempty"`
+	LeadCreatedAt      Timestamp `json:"lead_created_at,omitempty"`
+	FreeTrialStartedAt Timestamp `json:"free_trial_started_at,omitempty"`
+	WebsiteUrl         string    `json:"website_url,omitempty"`
 
 	Errors Errors `json:"errors,omitempty"`
 }
@@ -73,8 +73,8 @@ type NewCustomer struct {
 	City    string `json:"city,omitempty"`
 	Zip     string `json:"zip,omitempty"`
 	// Lead/Trial
-	LeadCreatedAt      string `json:"lead_created_at,omitempty"`
-	FreeTrialStartedAt string `json:"free_trial_started_at,omitempty"`
+	LeadCreatedAt      Timestamp `json:"lead_created_at,omitempty"`
+	FreeTrialStartedAt Timestamp `json:"free_trial_started_at,omitempty"`
 	// Website
 	WebsiteUrl string `json:"website_url,omitempty"`
 }
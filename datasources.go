@@ -3,12 +3,12 @@ package chartmogul
 // DataSource represents API data source in ChartMogul.
 // See https://dev.chartmogul.com/v1.0/reference#list-data-sources
 type DataSource struct {
-	UUID      string `json:"uuid"`
-	Name      string `json:"name"`
-	CreatedAt string `json:"created_at"`
-	Status    string `json:"status"`
-	System    string `json:"system"`
-	Errors    Errors `json:"errors,omitempty"`
+	UUID      string    `json:"uuid"`
+	Name      string    `json:"name"`
+	CreatedAt Timestamp `json:"created_at"`
+	Status    string    `json:"status"`
+	System    string    `json:"system"`
+	Errors    Errors    `json:"errors,omitempty"`
 }
 
 // DataSources is the result of listing data sources, but doesn't contain any paging.
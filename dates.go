@@ -0,0 +1,168 @@
+package chartmogul
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the format of calendar dates in the API, eg. metrics filters and entries.
+const DateLayout = "2006-01-02"
+
+// timestampLayouts are all the variants of timestamps the API returns or accepts, eg.
+// "2021-07-12T14:46:56+00:00", "2015-11-01T00:00:00.000Z" or "2020-05-06T01:00:00" (activities).
+// Timestamps without zone are in UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999 MST",
+}
+
+// Date is a calendar date in the API, formatted as "2006-01-02".
+//
+// Date is a string type for compatibility with the plain string fields it replaces:
+// string literals can be assigned directly, and the value received from the API is kept as is.
+// It's encoded unchanged too, use NewDate to create one from time.Time and Normalized to reformat one.
+type Date string
+
+// Timestamp is a point in time in the API, formatted as ISO 8601, eg. "2006-01-02T15:04:05Z".
+//
+// Timestamp is a string type for compatibility with the plain string fields it replaces:
+// string literals can be assigned directly, and the value received from the API is kept as is.
+// It's encoded unchanged too, use NewTimestamp to create one from time.Time and Normalized to reformat one.
+type Timestamp string
+
+// NewDate returns the calendar date of t (in its location).
+func NewDate(t time.Time) Date {
+	return Date(t.Format(DateLayout))
+}
+
+// NewTimestamp returns the timestamp of t including its zone offset.
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp(t.Format(time.RFC3339Nano))
+}
+
+func parseTimestamp(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(DateLayout, s); err == nil {
+		return t, true, nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("chartmogul: cannot parse %q as date/time", s)
+}
+
+// IsZero returns true if the date is not set.
+func (d Date) IsZero() bool {
+	return d == ""
+}
+
+func (d Date) String() string {
+	return string(d)
+}
+
+// Time returns midnight UTC of the date. Timestamps are accepted too,
+// the calendar date is taken as written, disregarding time and zone.
+func (d Date) Time() (time.Time, error) {
+	return d.In(time.UTC)
+}
+
+// In returns midnight of the date in the given location, eg. Account.Location().
+func (d Date) In(loc *time.Location) (time.Time, error) {
+	t, _, err := parseTimestamp(string(d))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// Normalized returns the date formatted as "2006-01-02", eg. of a timestamp.
+func (d Date) Normalized() (Date, error) {
+	t, err := d.Time()
+	if err != nil {
+		return "", err
+	}
+	return NewDate(t), nil
+}
+
+// UnmarshalJSON keeps the value as received, null is the zero Date.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*d = ""
+	if s != nil {
+		*d = Date(*s)
+	}
+	return nil
+}
+
+// IsZero returns true if the timestamp is not set.
+func (ts Timestamp) IsZero() bool {
+	return ts == ""
+}
+
+func (ts Timestamp) String() string {
+	return string(ts)
+}
+
+// Time parses the timestamp, values without zone are in UTC.
+func (ts Timestamp) Time() (time.Time, error) {
+	t, _, err := parseTimestamp(string(ts))
+	return t, err
+}
+
+// In returns the timestamp in the given location, eg. Account.Location().
+func (ts Timestamp) In(loc *time.Location) (time.Time, error) {
+	t, err := ts.Time()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.In(loc), nil
+}
+
+// Date returns the calendar date of the timestamp in the given location.
+func (ts Timestamp) Date(loc *time.Location) (Date, error) {
+	t, dateOnly, err := parseTimestamp(string(ts))
+	if err != nil {
+		return "", err
+	}
+	if !dateOnly {
+		t = t.In(loc)
+	}
+	return NewDate(t), nil
+}
+
+// Normalized returns the timestamp formatted as RFC 3339, keeping its zone offset,
+// values without zone in UTC. Bare dates are kept as they are.
+func (ts Timestamp) Normalized() (Timestamp, error) {
+	t, dateOnly, err := parseTimestamp(string(ts))
+	if err != nil {
+		return "", err
+	}
+	if dateOnly {
+		return ts, nil
+	}
+	return NewTimestamp(t), nil
+}
+
+// UnmarshalJSON keeps the value as received, null is the zero Timestamp.
+func (ts *Timestamp) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*ts = ""
+	if s != nil {
+		*ts = Timestamp(*s)
+	}
+	return nil
+}
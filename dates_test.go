@@ -0,0 +1,128 @@
+package chartmogul
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampVariants(t *testing.T) {
+	expected := time.Date(2020, 5, 6, 1, 0, 0, 0, time.UTC)
+	variants := []Timestamp{
+		"2020-05-06T01:00:00",
+		"2020-05-06T01:00:00Z",
+		"2020-05-06T01:00:00.000Z",
+		"2020-05-06T01:00:00+00:00",
+		"2020-05-06T03:00:00+02:00",
+		"2020-05-06 01:00:00",
+		"2020-05-06 01:00:00 +0000",
+	}
+	for _, ts := range variants {
+		parsed, err := ts.Time()
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", ts, err)
+			continue
+		}
+		if !parsed.Equal(expected) {
+			t.Errorf("%v: expected %v, got %v", ts, expected, parsed)
+		}
+	}
+	if _, err := Timestamp("yesterday").Time(); err == nil {
+		t.Error("Expected error for unparseable timestamp")
+	}
+}
+
+func TestDateAndTimestampJSON(t *testing.T) {
+	var activity MetricsActivity
+	var entry MRRMetrics
+	//nolint
+	json.Unmarshal([]byte(`{"date": "2020-05-06T01:00:00"}`), &activity)
+	//nolint
+	json.Unmarshal([]byte(`{"date": "2022-04-30"}`), &entry)
+	if activity.Date != "2020-05-06T01:00:00" || entry.Date != "2022-04-30" {
+		t.Fatalf("Values should be kept as received: %v %v", activity.Date, entry.Date)
+	}
+
+	export := &MetricsActivitiesExport{}
+	if err := json.Unmarshal([]byte(`{"expires_at": null, "created_at": "2021-07-12T14:46:56+00:00"}`), export); err != nil {
+		t.Fatal(err)
+	}
+	if !export.ExpiresAt.IsZero() || export.CreatedAt.IsZero() {
+		t.Fatalf("Unexpected export %+v", export)
+	}
+
+	body, err := json.Marshal(&Invoice{
+		Date:      "2020-05-06 01:00:00",
+		DueDate:   "2020-05-31",
+		LineItems: []*LineItem{{ServicePeriodStart: NewTimestamp(time.Date(2020, 5, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600)))}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Date      string `json:"date"`
+		DueDate   string `json:"due_date"`
+		LineItems []struct {
+			ServicePeriodStart string `json:"service_period_start"`
+		} `json:"line_items"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw.Date != "2020-05-06 01:00:00" || raw.DueDate != "2020-05-31" ||
+		raw.LineItems[0].ServicePeriodStart != "2020-05-01T00:00:00+01:00" {
+		t.Fatalf("Unexpected JSON %s", body)
+	}
+
+	body, _ = json.Marshal(MetricsFilter{StartDate: "2022-04-01T10:00:00Z", EndDate: NewDate(time.Date(2022, 5, 31, 23, 0, 0, 0, time.UTC))})
+	if string(body) != `{"start-date":"2022-04-01T10:00:00Z","end-date":"2022-05-31"}` {
+		t.Fatalf("Values should be sent unchanged %s", body)
+	}
+}
+
+func TestNormalized(t *testing.T) {
+	if d, err := Date("2022-04-01T10:00:00Z").Normalized(); err != nil || d != "2022-04-01" {
+		t.Fatalf("Unexpected date %v %v", d, err)
+	}
+	for value, expected := range map[Timestamp]Timestamp{
+		"2020-05-06T01:00:00":       "2020-05-06T01:00:00Z",
+		"2020-05-06 01:00:00 +0200": "2020-05-06T01:00:00+02:00",
+		"2020-05-06":                "2020-05-06",
+	} {
+		if ts, err := value.Normalized(); err != nil || ts != expected {
+			t.Fatalf("Unexpected timestamp of %v: %v %v", value, ts, err)
+		}
+	}
+	if _, err := Timestamp("yesterday").Normalized(); err == nil {
+		t.Fatal("Expected an unparseable timestamp to fail")
+	}
+}
+
+func TestAccountTimeZone(t *testing.T) {
+	account := &Account{TimeZone: "Europe/Berlin"}
+	loc, err := account.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	midnight, err := Date("2022-06-30").In(loc)
+	if err != nil || midnight.Format(time.RFC3339) != "2022-06-30T00:00:00+02:00" {
+		t.Fatalf("Unexpected date in account zone %v %v", midnight, err)
+	}
+
+	ts := Timestamp("2022-06-30T23:30:00Z")
+	local, err := ts.In(loc)
+	if err != nil || local.Format(time.RFC3339) != "2022-07-01T01:30:00+02:00" {
+		t.Fatalf("Unexpected timestamp in account zone %v %v", local, err)
+	}
+	if d, err := ts.Date(loc); err != nil || d != "2022-07-01" {
+		t.Fatalf("Unexpected date of timestamp %v %v", d, err)
+	}
+
+	if _, err := (&Account{TimeZone: "Mars/Olympus"}).Location(); err == nil {
+		t.Fatal("Expected unknown time zone error")
+	}
+	if loc, err := (&Account{}).Location(); err != nil || loc != time.UTC {
+		t.Fatalf("Expected UTC by default, got %v %v", loc, err)
+	}
+}
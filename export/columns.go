@@ -0,0 +1,153 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// kind is the type of a column's values.
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindUint
+	kindFloat
+	kindBool
+)
+
+// column is a flattened field of the row type.
+type column struct {
+	name  string
+	index []int
+	kind  kind
+	// encoded columns hold slices, maps and the like as JSON
+	encoded bool
+}
+
+// schema are the columns of a struct type.
+type schema struct {
+	typ     reflect.Type
+	columns []column
+}
+
+func newSchema(sample interface{}) (*schema, error) {
+	t := reflect.TypeOf(sample)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("chartmogul: export rows must be structs, got %T", sample)
+	}
+	s := &schema{typ: t}
+	s.flatten(t, "", nil)
+	if len(s.columns) == 0 {
+		return nil, fmt.Errorf("chartmogul: %v has no exported fields", t)
+	}
+	return s, nil
+}
+
+func (s *schema) flatten(t reflect.Type, prefix string, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		fieldIndex := append(append([]int{}, index...), i)
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && name == "" && f.Anonymous {
+			// embedded structs are flattened like encoding/json does
+			s.flatten(ft, prefix, fieldIndex)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if ft.Kind() == reflect.Struct {
+			s.flatten(ft, prefix+name+".", fieldIndex)
+			continue
+		}
+		c := column{name: prefix + name, index: fieldIndex}
+		switch ft.Kind() {
+		case reflect.String:
+			c.kind = kindString
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			c.kind = kindInt
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			c.kind = kindUint
+		case reflect.Float32, reflect.Float64:
+			c.kind = kindFloat
+		case reflect.Bool:
+			c.kind = kindBool
+		default:
+			c.kind, c.encoded = kindString, true
+		}
+		s.columns = append(s.columns, c)
+	}
+}
+
+func (s *schema) names() []string {
+	names := make([]string, len(s.columns))
+	for i, c := range s.columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// row returns the struct value of a row, which must be of the schema's type.
+func (s *schema) row(row interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(row)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("chartmogul: nil export row")
+		}
+		v = v.Elem()
+	}
+	if v.Type() != s.typ {
+		return reflect.Value{}, fmt.Errorf("chartmogul: export of %v got a %v row", s.typ, v.Type())
+	}
+	return v, nil
+}
+
+// value returns the column of the row, nil if within a nil nested struct.
+// Integers are int64, unsigned integers uint64, floats float64, encoded columns JSON strings.
+func (c *column) value(row reflect.Value) (interface{}, error) {
+	v := row
+	for _, i := range c.index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	if c.encoded {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Slice || v.Kind() == reflect.Map || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil, nil
+		}
+		data, err := json.Marshal(v.Interface())
+		return string(data), err
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	}
+	return v.String(), nil
+}
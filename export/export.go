@@ -0,0 +1,94 @@
+// Package export writes API results as flat rows to CSV, JSON Lines or Parquet files,
+// eg. for loading them into a data warehouse.
+//
+// Columns are named after the JSON tags of the fields, nested structs are flattened
+// with dotted names. Rows are streamed, so exports of the iterators don't need to fit in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Format of the exported file.
+type Format string
+
+// Supported formats.
+const (
+	CSV       Format = "csv"
+	JSONLines Format = "jsonl"
+	Parquet   Format = "parquet"
+)
+
+// Writer writes rows of one struct type, eg. cm.MetricsActivity.
+type Writer interface {
+	// Columns are the names of the columns.
+	Columns() []string
+	// Write writes a struct or a pointer to a struct of the writer's type.
+	Write(row interface{}) error
+	// Close flushes the buffered rows, the underlying writer stays open.
+	Close() error
+}
+
+// NewWriter creates a writer of the format for rows like the sample, eg. cm.MRRMetrics{}.
+func NewWriter(w io.Writer, format Format, sample interface{}) (Writer, error) {
+	switch format {
+	case CSV:
+		return NewCSVWriter(w, sample)
+	case JSONLines:
+		return NewJSONLinesWriter(w, sample)
+	case Parquet:
+		return NewParquetWriter(w, sample)
+	}
+	return nil, fmt.Errorf("chartmogul: unknown export format %q", format)
+}
+
+// Entries writes the entries of a result, eg. *cm.MetricsResult or *cm.MRRResult.
+// It returns the number of rows written.
+func Entries(w Writer, result interface{}) (int, error) {
+	v := reflect.Indirect(reflect.ValueOf(result))
+	if v.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("chartmogul: %T has no entries", result)
+	}
+	entries := v.FieldByName("Entries")
+	if !entries.IsValid() || entries.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("chartmogul: %T has no entries", result)
+	}
+	for i := 0; i < entries.Len(); i++ {
+		if err := w.Write(entries.Index(i).Interface()); err != nil {
+			return i, err
+		}
+	}
+	return entries.Len(), nil
+}
+
+// Activities writes all activities of the source, eg. cm.NewMetricsActivitiesIterator
+// or the reader of API.ExportActivities. It returns the number of rows written.
+func Activities(w Writer, source cm.MetricsActivitiesSource) (int, error) {
+	return stream(w, func() (interface{}, error) { return source.Read() })
+}
+
+// CustomerSubscriptions writes all subscriptions of the iterator. It returns the number of rows written.
+func CustomerSubscriptions(w Writer, it *cm.MetricsCustomerSubscriptionsIterator) (int, error) {
+	return stream(w, func() (interface{}, error) { return it.Read() })
+}
+
+func stream(w Writer, next func() (interface{}, error)) (int, error) {
+	n := 0
+	for {
+		row, err := next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if err := w.Write(row); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
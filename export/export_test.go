@@ -0,0 +1,131 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// activities is a MetricsActivitiesSource over a slice.
+type activities []*cm.MetricsActivity
+
+func (a *activities) Read() (*cm.MetricsActivity, error) {
+	if len(*a) == 0 {
+		return nil, io.EOF
+	}
+	activity := (*a)[0]
+	*a = (*a)[1:]
+	return activity, nil
+}
+
+func testActivities() *activities {
+	return &activities{
+		{Date: "2022-01-10T10:00:00Z", ActivityMrrMovement: 1000, Type: "new_biz", CustomerName: "Acme, Inc.", UUID: "a1"},
+		{Date: "2022-02-10T10:00:00Z", ActivityMrrMovement: -250.5, Type: "contraction", CustomerName: "Acme, Inc.", UUID: "a2"},
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w, err := NewWriter(out, CSV, cm.MetricsActivity{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Activities(w, testActivities())
+	if err != nil || n != 2 {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `date,activity-arr,activity-mrr,activity-mrr-movement,currency,description,type,subscription-external-id,plan-external-id,customer-name,customer-uuid,customer-external-id,billing-connector-uuid,uuid
+2022-01-10T10:00:00Z,0,0,1000,,,new_biz,,,"Acme, Inc.",,,,a1
+2022-02-10T10:00:00Z,0,0,-250.5,,,contraction,,,"Acme, Inc.",,,,a2
+`
+	if out.String() != expected {
+		t.Fatalf("Unexpected CSV:\n%v", out.String())
+	}
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w, err := NewWriter(out, JSONLines, &cm.MRRMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Entries(w, &cm.MRRResult{Entries: []*cm.MRRMetrics{{Date: "2022-01-31", MRR: 1000, MRRNewBusiness: 1000}}})
+	if err != nil || n != 1 {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"date":"2022-01-31","mrr":1000,"mrr-new-business":1000,"mrr-expansion":0,"mrr-contraction":0,"mrr-churn":0,"mrr-reactivation":0,"percentage-change":0}` + "\n"
+	if out.String() != expected {
+		t.Fatalf("Unexpected JSON Lines:\n%v", out.String())
+	}
+}
+
+type nested struct {
+	ID      uint64 `json:"id"`
+	Active  bool   `json:"active"`
+	Address *struct {
+		City string `json:"city"`
+	} `json:"address"`
+	Tags []string `json:"tags"`
+	cm.Pagination
+}
+
+func TestFlattenedColumns(t *testing.T) {
+	out := &bytes.Buffer{}
+	w, err := NewJSONLinesWriter(out, nested{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns := strings.Join(w.Columns(), " "); columns != "id active address.city tags cursor has_more" {
+		t.Fatalf("Unexpected columns %v", columns)
+	}
+	if err := w.Write(nested{ID: 7, Tags: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(cm.MRRMetrics{}); err == nil {
+		t.Fatal("Expected a row of another type to fail")
+	}
+	w.Close() //nolint
+	if out.String() != `{"id":7,"active":false,"address.city":null,"tags":"[\"a\"]","cursor":"","has_more":false}`+"\n" {
+		t.Fatalf("Unexpected JSON Lines:\n%v", out.String())
+	}
+}
+
+func TestUnsignedColumns(t *testing.T) {
+	out := &bytes.Buffer{}
+	w, err := NewCSVWriter(out, nested{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(nested{ID: math.MaxUint64}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "\n18446744073709551615,") {
+		t.Fatalf("Unexpected CSV:\n%v", out.String())
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, "xlsx", cm.MRRMetrics{}); err == nil {
+		t.Fatal("Expected unknown format to fail")
+	}
+	if _, err := NewWriter(&bytes.Buffer{}, CSV, "row"); err == nil {
+		t.Fatal("Expected non-struct rows to fail")
+	}
+}
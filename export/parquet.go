@@ -0,0 +1,387 @@
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// DefaultRowGroupSize is the number of rows buffered in memory for a Parquet row group.
+const DefaultRowGroupSize = 10000
+
+// Parquet physical types, encodings and other constants of the format.
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional = 1
+	parquetUTF8     = 0
+	parquetUint64   = 14
+	parquetPlain    = 0
+	parquetRLE      = 3
+	parquetDataPage = 0
+)
+
+var parquetMagic = []byte("PAR1")
+
+// ParquetWriter writes an uncompressed Parquet file with a row group per RowGroupSize rows.
+// All columns are optional: strings are UTF8 byte arrays, integers INT64, unsigned integers
+// INT64 annotated as UINT_64, floats DOUBLE.
+// Missing values are nulls.
+type ParquetWriter struct {
+	// RowGroupSize is the number of rows per row group, DefaultRowGroupSize if zero.
+	RowGroupSize int
+
+	schema    *schema
+	out       io.Writer
+	offset    int64
+	columns   []parquetColumn
+	rows      int
+	total     int64
+	rowGroups []parquetRowGroup
+}
+
+type parquetColumn struct {
+	defined []bool
+	values  []byte
+	bools   []bool
+}
+
+type parquetRowGroup struct {
+	rows    int
+	size    int64
+	columns []parquetChunk
+}
+
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int
+}
+
+// NewParquetWriter creates a Parquet writer for rows like the sample.
+func NewParquetWriter(w io.Writer, sample interface{}) (*ParquetWriter, error) {
+	s, err := newSchema(sample)
+	if err != nil {
+		return nil, err
+	}
+	return &ParquetWriter{schema: s, out: w, columns: make([]parquetColumn, len(s.columns))}, nil
+}
+
+// Columns returns the names of the columns.
+func (w *ParquetWriter) Columns() []string {
+	return w.schema.names()
+}
+
+func (w *ParquetWriter) write(data []byte) error {
+	n, err := w.out.Write(data)
+	w.offset += int64(n)
+	return err
+}
+
+// Write buffers a row, the row group is written when full.
+func (w *ParquetWriter) Write(row interface{}) error {
+	v, err := w.schema.row(row)
+	if err != nil {
+		return err
+	}
+	if w.offset == 0 {
+		if err := w.write(parquetMagic); err != nil {
+			return err
+		}
+	}
+	for i := range w.schema.columns {
+		value, err := w.schema.columns[i].value(v)
+		if err != nil {
+			return err
+		}
+		c := &w.columns[i]
+		c.defined = append(c.defined, value != nil)
+		switch value := value.(type) {
+		case int64:
+			c.values = appendUint64(c.values, uint64(value))
+		case uint64:
+			c.values = appendUint64(c.values, value)
+		case float64:
+			c.values = appendUint64(c.values, math.Float64bits(value))
+		case bool:
+			c.bools = append(c.bools, value)
+		case string:
+			c.values = appendUint32(c.values, uint32(len(value)))
+			c.values = append(c.values, value...)
+		}
+	}
+	w.rows++
+	size := w.RowGroupSize
+	if size <= 0 {
+		size = DefaultRowGroupSize
+	}
+	if w.rows >= size {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered rows as a row group, a data page per column.
+func (w *ParquetWriter) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	group := parquetRowGroup{rows: w.rows}
+	for i := range w.columns {
+		c := &w.columns[i]
+		levels := rleBooleans(c.defined)
+		values := c.values
+		if w.schema.columns[i].kind == kindBool {
+			values = packBooleans(c.bools)
+		}
+		page := appendUint32(make([]byte, 0, 4+len(levels)+len(values)), uint32(len(levels)))
+		page = append(append(page, levels...), values...)
+
+		header := &thrift{}
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.beginStruct(5)
+		header.i32(1, int32(w.rows))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.endStruct()
+		header.stop()
+
+		chunk := parquetChunk{offset: w.offset, size: int64(len(header.buf) + len(page)), values: w.rows}
+		if err := w.write(header.buf); err != nil {
+			return err
+		}
+		if err := w.write(page); err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+		*c = parquetColumn{defined: c.defined[:0], values: c.values[:0], bools: c.bools[:0]}
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.total += int64(w.rows)
+	w.rows = 0
+	return nil
+}
+
+// Close writes the remaining rows and the file footer.
+func (w *ParquetWriter) Close() error {
+	if w.offset == 0 {
+		if err := w.write(parquetMagic); err != nil {
+			return err
+		}
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	meta := &thrift{}
+	meta.i32(1, 1)
+	meta.listHeader(2, thriftStruct, len(w.schema.columns)+1)
+	meta.beginElement()
+	meta.binary(4, []byte("schema"))
+	meta.i32(5, int32(len(w.schema.columns)))
+	meta.endStruct()
+	for _, c := range w.schema.columns {
+		meta.beginElement()
+		meta.i32(1, c.parquetType())
+		meta.i32(3, parquetOptional)
+		meta.binary(4, []byte(c.name))
+		switch c.kind {
+		case kindString:
+			meta.i32(6, parquetUTF8)
+		case kindUint:
+			meta.i32(6, parquetUint64)
+		}
+		meta.endStruct()
+	}
+	meta.i64(3, w.total)
+	meta.listHeader(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		meta.beginElement()
+		meta.listHeader(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			c := w.schema.columns[i]
+			meta.beginElement()
+			meta.i64(2, chunk.offset)
+			meta.beginStruct(3)
+			meta.i32(1, c.parquetType())
+			meta.listHeader(2, thriftI32, 2)
+			meta.element(parquetPlain)
+			meta.element(parquetRLE)
+			meta.listHeader(3, thriftBinary, 1)
+			meta.elementBinary([]byte(c.name))
+			meta.i32(4, 0) // uncompressed
+			meta.i64(5, int64(chunk.values))
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.endStruct()
+			meta.endStruct()
+		}
+		meta.i64(2, group.size)
+		meta.i64(3, int64(group.rows))
+		meta.endStruct()
+	}
+	meta.binary(6, []byte("chartmogul-go"))
+	meta.stop()
+
+	if err := w.write(meta.buf); err != nil {
+		return err
+	}
+	if err := w.write(appendUint32(nil, uint32(len(meta.buf)))); err != nil {
+		return err
+	}
+	return w.write(parquetMagic)
+}
+
+func (c *column) parquetType() int32 {
+	switch c.kind {
+	case kindInt, kindUint:
+		return parquetInt64
+	case kindFloat:
+		return parquetDouble
+	case kindBool:
+		return parquetBoolean
+	}
+	return parquetByteArray
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// rleBooleans encodes definition levels of bit width 1 as runs of the RLE/bit-packing hybrid.
+func rleBooleans(values []bool) []byte {
+	var out []byte
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j] == values[i] {
+			j++
+		}
+		out = appendUvarint(out, uint64(j-i)<<1)
+		if values[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+// packBooleans encodes booleans as PLAIN, a bit per value from the least significant.
+func packBooleans(values []bool) []byte {
+	out := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			out[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return out
+}
+
+// Types of the Thrift compact protocol used by the Parquet metadata.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thrift encodes structs in the Thrift compact protocol.
+type thrift struct {
+	buf   []byte
+	last  int16
+	stack []int16
+}
+
+func (t *thrift) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+func (t *thrift) varint(v int64) {
+	t.buf = appendUvarint(t.buf, uint64(v<<1)^uint64(v>>63))
+}
+
+func (t *thrift) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thrift) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thrift) binary(id int16, v []byte) {
+	t.field(id, thriftBinary)
+	t.elementBinary(v)
+}
+
+func (t *thrift) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElement()
+}
+
+// beginElement starts a struct within a list.
+func (t *thrift) beginElement() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thrift) endStruct() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thrift) stop() {
+	t.buf = append(t.buf, 0)
+}
+
+func (t *thrift) listHeader(id int16, elementType byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elementType)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elementType)
+	t.buf = appendUvarint(t.buf, uint64(size))
+}
+
+// element writes an i32 within a list.
+func (t *thrift) element(v int32) {
+	t.varint(int64(v))
+}
+
+// elementBinary writes a binary within a list.
+func (t *thrift) elementBinary(v []byte) {
+	t.buf = appendUvarint(t.buf, uint64(len(v)))
+	t.buf = append(t.buf, v...)
+}
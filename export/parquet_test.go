@@ -0,0 +1,270 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// thriftReader decodes the Thrift compact protocol, structs as maps by field ID.
+type thriftReader struct {
+	buf []byte
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		v := r.uvarint()
+		return int64(v>>1) ^ -int64(v&1)
+	case thriftBinary:
+		n := r.uvarint()
+		v := r.buf[:n]
+		r.buf = r.buf[n:]
+		return string(v)
+	case thriftList:
+		header := r.buf[0]
+		r.buf = r.buf[1:]
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		fields := map[int16]interface{}{}
+		last := int16(0)
+		for {
+			header := r.buf[0]
+			r.buf = r.buf[1:]
+			if header == 0 {
+				return fields
+			}
+			if delta := header >> 4; delta != 0 {
+				last += int16(delta)
+			} else {
+				last = int16(r.value(thriftI32).(int64))
+			}
+			fields[last] = r.value(header & 0x0f)
+		}
+	}
+	panic("unexpected thrift type")
+}
+
+// readParquet reads the footer and the columns of the first row group, nulls are nil.
+func readParquet(t *testing.T, file []byte) (map[int16]interface{}, map[string][]interface{}) {
+	if !bytes.HasPrefix(file, parquetMagic) || !bytes.HasSuffix(file, parquetMagic) {
+		t.Fatal("Expected magic bytes")
+	}
+	size := binary.LittleEndian.Uint32(file[len(file)-8:])
+	footer := (&thriftReader{file[len(file)-8-int(size) : len(file)-8]}).value(thriftStruct).(map[int16]interface{})
+
+	columns := map[string][]interface{}{}
+	schema := footer[2].([]interface{})
+	groups := footer[4].([]interface{})
+	if len(groups) == 0 {
+		return footer, columns
+	}
+	for i, chunk := range groups[0].(map[int16]interface{})[1].([]interface{}) {
+		element := schema[i+1].(map[int16]interface{})
+		offset := chunk.(map[int16]interface{})[2].(int64)
+		r := &thriftReader{file[offset:]}
+		header := r.value(thriftStruct).(map[int16]interface{})
+		count := int(header[5].(map[int16]interface{})[1].(int64))
+		page := r.buf[:header[2].(int64)]
+
+		levelsSize := binary.LittleEndian.Uint32(page)
+		levels := &thriftReader{page[4 : 4+levelsSize]}
+		values := page[4+levelsSize:]
+		var defined []bool
+		for len(defined) < count {
+			run := int(levels.uvarint() >> 1)
+			bit := levels.buf[0] == 1
+			levels.buf = levels.buf[1:]
+			for j := 0; j < run; j++ {
+				defined = append(defined, bit)
+			}
+		}
+		var column []interface{}
+		bits := 0
+		for _, ok := range defined {
+			if !ok {
+				column = append(column, nil)
+				continue
+			}
+			switch element[1].(int64) {
+			case parquetInt64:
+				if element[6] == int64(parquetUint64) {
+					column = append(column, binary.LittleEndian.Uint64(values))
+				} else {
+					column = append(column, int64(binary.LittleEndian.Uint64(values)))
+				}
+				values = values[8:]
+			case parquetDouble:
+				column = append(column, math.Float64frombits(binary.LittleEndian.Uint64(values)))
+				values = values[8:]
+			case parquetBoolean:
+				column = append(column, values[bits/8]&(1<<uint(bits%8)) != 0)
+				bits++
+			case parquetByteArray:
+				n := binary.LittleEndian.Uint32(values)
+				column = append(column, string(values[4:4+n]))
+				values = values[4+n:]
+			}
+		}
+		columns[element[4].(string)] = column
+	}
+	return footer, columns
+}
+
+func TestParquetWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w, err := NewParquetWriter(out, nested{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.RowGroupSize = 2
+	rows := []nested{{ID: 1, Active: true, Tags: []string{"a"}}, {ID: math.MaxUint64}, {ID: 3, Active: true}}
+	rows[1].Address = &struct {
+		City string `json:"city"`
+	}{"Berlin"}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	footer, columns := readParquet(t, out.Bytes())
+	if footer[3].(int64) != 3 || len(footer[4].([]interface{})) != 2 || footer[6] != "chartmogul-go" {
+		spew.Dump(footer)
+		t.Fatal("Unexpected footer")
+	}
+	schema := footer[2].([]interface{})
+	if len(schema) != 7 || schema[3].(map[int16]interface{})[4] != "address.city" || schema[3].(map[int16]interface{})[6] != int64(parquetUTF8) {
+		spew.Dump(schema)
+		t.Fatal("Unexpected schema")
+	}
+	expected := map[string][]interface{}{
+		"id":           {uint64(1), uint64(math.MaxUint64)},
+		"active":       {true, false},
+		"address.city": {nil, "Berlin"},
+		"tags":         {`["a"]`, nil},
+		"cursor":       {"", ""},
+		"has_more":     {false, false},
+	}
+	for name, values := range expected {
+		if len(columns[name]) != 2 || columns[name][0] != values[0] || columns[name][1] != values[1] {
+			spew.Dump(columns)
+			t.Fatalf("Unexpected column %v", name)
+		}
+	}
+}
+
+func TestParquetWriterActivities(t *testing.T) {
+	out := &bytes.Buffer{}
+	w, err := NewWriter(out, Parquet, cm.MetricsActivity{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Activities(w, testActivities()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, columns := readParquet(t, out.Bytes())
+	if columns["activity-mrr-movement"][1] != -250.5 || columns["uuid"][0] != "a1" || columns["date"][1] != "2022-02-10T10:00:00Z" {
+		spew.Dump(columns)
+		t.Fatal("Unexpected columns")
+	}
+}
+
+func TestParquetWriterEmpty(t *testing.T) {
+	out := &bytes.Buffer{}
+	w, err := NewParquetWriter(out, cm.MRRMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if footer, _ := readParquet(t, out.Bytes()); footer[3].(int64) != 0 {
+		spew.Dump(footer)
+		t.Fatal("Expected no rows")
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+type goldenRow struct {
+	ID      uint64  `json:"id"`
+	Delta   int64   `json:"delta"`
+	Amount  float64 `json:"amount"`
+	Active  bool    `json:"active"`
+	Address *struct {
+		City string `json:"city"`
+	} `json:"address"`
+	Tags []string `json:"tags"`
+}
+
+// TestParquetGolden compares the output with testdata/rows.parquet. As readParquet follows only
+// this writer's layout, the parquetcheck module reads the file with parquet-go, run it after
+// rewriting the file with -update.
+func TestParquetGolden(t *testing.T) {
+	out := &bytes.Buffer{}
+	w, err := NewParquetWriter(out, goldenRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.RowGroupSize = 2
+	berlin := &struct {
+		City string `json:"city"`
+	}{"Berlin"}
+	rows := []goldenRow{
+		{ID: 1, Delta: -5, Amount: 10.5, Active: true, Tags: []string{"a"}},
+		{ID: math.MaxUint64, Delta: math.MinInt64, Address: berlin},
+		{ID: 3, Amount: -0.25, Active: true, Address: &struct {
+			City string `json:"city"`
+		}{"Zürich"}},
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "rows.parquet")
+	if *update {
+		if err := ioutil.WriteFile(golden, out.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Fatal("Output differs from testdata/rows.parquet, run with -update and check it in export/parquetcheck")
+	}
+}
@@ -0,0 +1,53 @@
+// Package parquetcheck reads the export golden files with another Parquet reader.
+// It's a separate module to keep parquet-go out of the library's dependencies, run it with
+// `go test` in this directory after updating the golden files.
+package parquetcheck
+
+import (
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+type row struct {
+	ID     *uint64  `parquet:"id,optional"`
+	Delta  *int64   `parquet:"delta,optional"`
+	Amount *float64 `parquet:"amount,optional"`
+	Active *bool    `parquet:"active,optional"`
+	City   *string  `parquet:"address.city,optional"`
+	Tags   *string  `parquet:"tags,optional"`
+}
+
+func TestGoldenRows(t *testing.T) {
+	f, err := os.Open("../testdata/rows.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := parquet.Read[row](f, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []row{
+		{ID: ptr(uint64(1)), Delta: ptr(int64(-5)), Amount: ptr(10.5), Active: ptr(true), Tags: ptr(`["a"]`)},
+		{ID: ptr(uint64(math.MaxUint64)), Delta: ptr(int64(math.MinInt64)), Amount: ptr(0.0), Active: ptr(false), City: ptr("Berlin")},
+		{ID: ptr(uint64(3)), Delta: ptr(int64(0)), Amount: ptr(-0.25), Active: ptr(true), City: ptr("Zürich")},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		for _, r := range rows {
+			t.Logf("%+v", r)
+		}
+		t.Fatal("Unexpected rows in testdata/rows.parquet")
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
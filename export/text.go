@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// CSVWriter writes a header line with the column names and a line per row.
+// Missing values are empty cells.
+type CSVWriter struct {
+	schema *schema
+	csv    *csv.Writer
+	record []string
+	header bool
+}
+
+// NewCSVWriter creates a CSV writer for rows like the sample.
+func NewCSVWriter(w io.Writer, sample interface{}) (*CSVWriter, error) {
+	s, err := newSchema(sample)
+	if err != nil {
+		return nil, err
+	}
+	return &CSVWriter{schema: s, csv: csv.NewWriter(w), record: make([]string, len(s.columns))}, nil
+}
+
+// Columns returns the names of the columns.
+func (w *CSVWriter) Columns() []string {
+	return w.schema.names()
+}
+
+func (w *CSVWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return w.csv.Write(w.schema.names())
+}
+
+// Write writes a row.
+func (w *CSVWriter) Write(row interface{}) error {
+	v, err := w.schema.row(row)
+	if err != nil {
+		return err
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	for i := range w.schema.columns {
+		value, err := w.schema.columns[i].value(v)
+		if err != nil {
+			return err
+		}
+		switch value := value.(type) {
+		case nil:
+			w.record[i] = ""
+		case int64:
+			w.record[i] = strconv.FormatInt(value, 10)
+		case uint64:
+			w.record[i] = strconv.FormatUint(value, 10)
+		case float64:
+			w.record[i] = strconv.FormatFloat(value, 'f', -1, 64)
+		case bool:
+			w.record[i] = strconv.FormatBool(value)
+		case string:
+			w.record[i] = value
+		}
+	}
+	return w.csv.Write(w.record)
+}
+
+// Close writes the header if there were no rows and flushes.
+func (w *CSVWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// JSONLinesWriter writes a JSON object per line, with the keys in the order of the columns.
+// Missing values are null.
+type JSONLinesWriter struct {
+	schema *schema
+	out    *bufio.Writer
+}
+
+// NewJSONLinesWriter creates a JSON Lines writer for rows like the sample.
+func NewJSONLinesWriter(w io.Writer, sample interface{}) (*JSONLinesWriter, error) {
+	s, err := newSchema(sample)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesWriter{schema: s, out: bufio.NewWriter(w)}, nil
+}
+
+// Columns returns the names of the columns.
+func (w *JSONLinesWriter) Columns() []string {
+	return w.schema.names()
+}
+
+// Write writes a row.
+func (w *JSONLinesWriter) Write(row interface{}) error {
+	v, err := w.schema.row(row)
+	if err != nil {
+		return err
+	}
+	line := []byte{'{'}
+	for i := range w.schema.columns {
+		c := &w.schema.columns[i]
+		value, err := c.value(v)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			line = append(line, ',')
+		}
+		name, _ := json.Marshal(c.name)
+		line = append(append(line, name...), ':')
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		line = append(line, data...)
+	}
+	line = append(line, '}', '\n')
+	_, err = w.out.Write(line)
+	return err
+}
+
+// Close flushes the buffered lines.
+func (w *JSONLinesWriter) Close() error {
+	return w.out.Flush()
+}
@@ -0,0 +1,330 @@
+// Package forecast projects metrics history, eg. MRR or customer count, with simple deterministic models.
+// It doesn't call the API, the history comes from Metrics API results.
+package forecast
+
+import (
+	"fmt"
+	"math"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// DefaultConfidence is the confidence level of intervals when not set.
+const DefaultConfidence = 0.95
+
+// Series are metric values in chronological order, dated at the end of each period.
+type Series struct {
+	Interval cm.Interval
+	Dates    []cm.Date
+	Values   []float64
+}
+
+// MRRSeries returns the MRR history of the result, retrieved with the given interval.
+func MRRSeries(mrr *cm.MRRResult, interval cm.Interval) Series {
+	series := Series{Interval: interval}
+	for _, entry := range mrr.Entries {
+		series.Dates = append(series.Dates, entry.Date)
+		series.Values = append(series.Values, entry.MRR)
+	}
+	return series
+}
+
+// CustomerCountSeries returns the customer count history of the result, retrieved with the given interval.
+func CustomerCountSeries(customers *cm.CustomerCountResult, interval cm.Interval) Series {
+	series := Series{Interval: interval}
+	for _, entry := range customers.Entries {
+		series.Dates = append(series.Dates, entry.Date)
+		series.Values = append(series.Values, float64(entry.Customers))
+	}
+	return series
+}
+
+// Point is a forecast value with its confidence interval. Values don't go below zero.
+type Point struct {
+	Date  cm.Date `json:"date"`
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// z returns the two-sided standard normal quantile of the confidence level.
+func z(confidence float64) (float64, error) {
+	if confidence == 0 {
+		confidence = DefaultConfidence
+	}
+	if confidence <= 0 || confidence >= 1 {
+		return 0, fmt.Errorf("chartmogul: confidence must be between 0 and 1, got %v", confidence)
+	}
+	return math.Sqrt2 * math.Erfinv(confidence), nil
+}
+
+// point makes a point with the interval of the given half-width.
+func point(date cm.Date, value, width float64) Point {
+	return Point{
+		Date:  date,
+		Value: math.Max(value, 0),
+		Lower: math.Max(value-width, 0),
+		Upper: math.Max(value+width, 0),
+	}
+}
+
+// futureDates returns the end dates of the periods following the last date.
+func futureDates(interval cm.Interval, last cm.Date, horizon int) ([]cm.Date, error) {
+	if interval == "" {
+		interval = cm.IntervalMonth
+	}
+	if !interval.IsValid() {
+		return nil, fmt.Errorf("chartmogul: invalid interval %q", interval)
+	}
+	if horizon < 0 {
+		return nil, fmt.Errorf("chartmogul: invalid horizon %d", horizon)
+	}
+	t, err := last.Time()
+	if err != nil {
+		return nil, fmt.Errorf("chartmogul: invalid date %q in history", last)
+	}
+	// the last date ends its week
+	weekStart := (t.Weekday() + 1) % 7
+	start := interval.PeriodStart(t, weekStart)
+	dates := make([]cm.Date, horizon)
+	for k := range dates {
+		dates[k] = cm.NewDate(interval.PeriodEnd(interval.AddTo(start, k+1), weekStart))
+	}
+	return dates, nil
+}
+
+func (s Series) validate(minimum int) error {
+	if len(s.Dates) != len(s.Values) {
+		return fmt.Errorf("chartmogul: series has %d dates and %d values", len(s.Dates), len(s.Values))
+	}
+	if len(s.Values) < minimum {
+		return fmt.Errorf("chartmogul: forecast needs at least %d values of history, got %d", minimum, len(s.Values))
+	}
+	return nil
+}
+
+// Linear fits a least-squares trend line to the history and extends it by horizon periods.
+// The interval is the prediction interval of the regression at the confidence level,
+// DefaultConfidence if zero.
+func Linear(history Series, horizon int, confidence float64) ([]Point, error) {
+	if err := history.validate(3); err != nil {
+		return nil, err
+	}
+	zc, err := z(confidence)
+	if err != nil {
+		return nil, err
+	}
+	dates, err := futureDates(history.Interval, history.Dates[len(history.Dates)-1], horizon)
+	if err != nil {
+		return nil, err
+	}
+
+	n := float64(len(history.Values))
+	meanX, meanY := (n-1)/2, 0.0
+	for _, y := range history.Values {
+		meanY += y / n
+	}
+	var sxx, sxy float64
+	for i, y := range history.Values {
+		dx := float64(i) - meanX
+		sxx += dx * dx
+		sxy += dx * (y - meanY)
+	}
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+
+	var sse float64
+	for i, y := range history.Values {
+		e := y - (intercept + slope*float64(i))
+		sse += e * e
+	}
+	s := math.Sqrt(sse / (n - 2))
+
+	points := make([]Point, horizon)
+	for k := range points {
+		x := n + float64(k)
+		width := zc * s * math.Sqrt(1+1/n+(x-meanX)*(x-meanX)/sxx)
+		points[k] = point(dates[k], intercept+slope*x, width)
+	}
+	return points, nil
+}
+
+// HoltWintersParams are the smoothing factors of HoltWinters, between 0 and 1.
+type HoltWintersParams struct {
+	// Alpha smooths the level, 0.5 if zero.
+	Alpha float64
+	// Beta smooths the trend, 0.1 if zero.
+	Beta float64
+	// Gamma smooths the seasonal components, 0.1 if zero.
+	Gamma float64
+	// Season is the number of periods in a season, eg. 12 for months, which is the default.
+	Season int
+	// Confidence is the confidence level of the intervals, DefaultConfidence if zero.
+	Confidence float64
+}
+
+// HoltWinters forecasts with additive Holt-Winters triple exponential smoothing.
+// The history must cover at least two seasons, the first two initialize the level, trend and seasonality.
+// The interval widens with the square root of the horizon from the one-step-ahead errors on the history.
+func HoltWinters(history Series, horizon int, params HoltWintersParams) ([]Point, error) {
+	alpha, beta, gamma, season := params.Alpha, params.Beta, params.Gamma, params.Season
+	if alpha == 0 {
+		alpha = 0.5
+	}
+	if beta == 0 {
+		beta = 0.1
+	}
+	if gamma == 0 {
+		gamma = 0.1
+	}
+	if season == 0 {
+		season = 12
+	}
+	for _, f := range []float64{alpha, beta, gamma} {
+		if f < 0 || f > 1 {
+			return nil, fmt.Errorf("chartmogul: smoothing factors must be between 0 and 1, got %v", f)
+		}
+	}
+	if season < 2 {
+		return nil, fmt.Errorf("chartmogul: invalid season of %d periods", season)
+	}
+	if err := history.validate(2 * season); err != nil {
+		return nil, err
+	}
+	zc, err := z(params.Confidence)
+	if err != nil {
+		return nil, err
+	}
+	dates, err := futureDates(history.Interval, history.Dates[len(history.Dates)-1], horizon)
+	if err != nil {
+		return nil, err
+	}
+
+	values := history.Values
+	var first, second float64
+	for i := 0; i < season; i++ {
+		first += values[i] / float64(season)
+		second += values[season+i] / float64(season)
+	}
+	level, trend := first, (second-first)/float64(season)
+	seasonal := make([]float64, season)
+	for i := range seasonal {
+		seasonal[i] = values[i] - first
+	}
+
+	var sse float64
+	for i := season; i < len(values); i++ {
+		predicted := level + trend + seasonal[i%season]
+		e := values[i] - predicted
+		sse += e * e
+		previous := level
+		level = alpha*(values[i]-seasonal[i%season]) + (1-alpha)*(level+trend)
+		trend = beta*(level-previous) + (1-beta)*trend
+		seasonal[i%season] = gamma*(values[i]-level) + (1-gamma)*seasonal[i%season]
+	}
+	sigma := math.Sqrt(sse / float64(len(values)-season))
+
+	points := make([]Point, horizon)
+	for k := range points {
+		h := float64(k + 1)
+		value := level + h*trend + seasonal[(len(values)+k)%season]
+		points[k] = point(dates[k], value, zc*sigma*math.Sqrt(h))
+	}
+	return points, nil
+}
+
+// ComponentParams configure Components.
+type ComponentParams struct {
+	// Window is the number of latest periods averaged for the flows, all of the history if zero.
+	Window int
+	// Confidence is the confidence level of the intervals, DefaultConfidence if zero.
+	Confidence float64
+}
+
+// ComponentFlows are the projected MRR movements of one period.
+type ComponentFlows struct {
+	Date         cm.Date `json:"date"`
+	NewBusiness  float64 `json:"mrr-new-business"`
+	Expansion    float64 `json:"mrr-expansion"`
+	Contraction  float64 `json:"mrr-contraction"`
+	Churn        float64 `json:"mrr-churn"`
+	Reactivation float64 `json:"mrr-reactivation"`
+}
+
+// ComponentForecast is the result of Components.
+type ComponentForecast struct {
+	Points []Point          `json:"points"`
+	Flows  []ComponentFlows `json:"flows"`
+}
+
+// Components projects the MRR flows separately: new business and reactivation as average amounts
+// per period, expansion, contraction and churn as average rates of the MRR at the start of the period.
+// The interval widens with the square root of the horizon from the one-step-ahead errors on the history.
+func Components(mrr *cm.MRRResult, interval cm.Interval, horizon int, params ComponentParams) (*ComponentForecast, error) {
+	history := MRRSeries(mrr, interval)
+	if err := history.validate(2); err != nil {
+		return nil, err
+	}
+	zc, err := z(params.Confidence)
+	if err != nil {
+		return nil, err
+	}
+	dates, err := futureDates(interval, history.Dates[len(history.Dates)-1], horizon)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first entry has no starting MRR to take rates of
+	entries := mrr.Entries[1:]
+	if params.Window > 0 && params.Window < len(entries) {
+		entries = entries[len(entries)-params.Window:]
+	}
+	var newBusiness, reactivation, expansion, contraction, churn float64
+	rated := 0
+	for _, entry := range entries {
+		n := float64(len(entries))
+		newBusiness += entry.MRRNewBusiness / n
+		reactivation += entry.MRRReactivation / n
+		start := entry.MRR - entry.MRRNewBusiness - entry.MRRExpansion - entry.MRRContraction - entry.MRRChurn - entry.MRRReactivation
+		if start > 0 {
+			expansion += entry.MRRExpansion / start
+			contraction += entry.MRRContraction / start
+			churn += entry.MRRChurn / start
+			rated++
+		}
+	}
+	if rated != 0 {
+		expansion, contraction, churn = expansion/float64(rated), contraction/float64(rated), churn/float64(rated)
+	}
+	step := func(start float64) ComponentFlows {
+		return ComponentFlows{
+			NewBusiness:  newBusiness,
+			Expansion:    start * expansion,
+			Contraction:  start * contraction,
+			Churn:        start * churn,
+			Reactivation: reactivation,
+		}
+	}
+	next := func(start float64, f ComponentFlows) float64 {
+		return start + f.NewBusiness + f.Expansion + f.Contraction + f.Churn + f.Reactivation
+	}
+
+	var sse float64
+	for i := 1; i < len(mrr.Entries); i++ {
+		start := mrr.Entries[i-1].MRR
+		e := mrr.Entries[i].MRR - next(start, step(start))
+		sse += e * e
+	}
+	sigma := math.Sqrt(sse / float64(len(mrr.Entries)-1))
+
+	result := &ComponentForecast{Points: make([]Point, horizon), Flows: make([]ComponentFlows, horizon)}
+	current := history.Values[len(history.Values)-1]
+	for k := range result.Points {
+		flows := step(current)
+		flows.Date = dates[k]
+		current = next(current, flows)
+		result.Flows[k] = flows
+		result.Points[k] = point(dates[k], current, zc*sigma*math.Sqrt(float64(k+1)))
+	}
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// monthly returns a series of months ending in December 2021.
+func monthly(values ...float64) Series {
+	series := Series{Interval: cm.IntervalMonth, Values: values}
+	december := time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC)
+	for i := range values {
+		month := cm.IntervalMonth.AddTo(december, i-len(values)+1)
+		series.Dates = append(series.Dates, cm.NewDate(cm.IntervalMonth.PeriodEnd(month, time.Monday)))
+	}
+	return series
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestLinear(t *testing.T) {
+	points, err := Linear(monthly(100, 110, 120, 130, 140, 150), 3, 0)
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	expected := []Point{
+		{Date: "2022-01-31", Value: 160, Lower: 160, Upper: 160},
+		{Date: "2022-02-28", Value: 170, Lower: 170, Upper: 170},
+		{Date: "2022-03-31", Value: 180, Lower: 180, Upper: 180},
+	}
+	for i, p := range points {
+		if p.Date != expected[i].Date || !near(p.Value, expected[i].Value) || !near(p.Lower, p.Value) || !near(p.Upper, p.Value) {
+			spew.Dump(points)
+			t.Fatal("Expected an exact line")
+		}
+	}
+
+	noisy, err := Linear(monthly(100, 115, 118, 133, 138, 152), 12, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, last := noisy[0], noisy[11]
+	if last.Date != "2022-12-31" || !(first.Lower < first.Value && first.Value < first.Upper) ||
+		last.Upper-last.Lower <= first.Upper-first.Lower {
+		spew.Dump(noisy)
+		t.Fatal("Expected intervals widening with the horizon")
+	}
+}
+
+func TestHoltWinters(t *testing.T) {
+	// a season of four months repeated three times
+	history := monthly(100, 120, 90, 90, 100, 120, 90, 90, 100, 120, 90, 90)
+	points, err := HoltWinters(history, 5, HoltWintersParams{Season: 4})
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	for i, expected := range []float64{100, 120, 90, 90, 100} {
+		if !near(points[i].Value, expected) || !near(points[i].Upper, expected) {
+			spew.Dump(points)
+			t.Fatal("Expected the season repeated")
+		}
+	}
+
+	if _, err := HoltWinters(monthly(1, 2, 3, 4, 5), 5, HoltWintersParams{Season: 4}); err == nil {
+		t.Fatal("Expected short history to fail")
+	}
+	if _, err := HoltWinters(history, 5, HoltWintersParams{Season: 4, Alpha: 2}); err == nil {
+		t.Fatal("Expected invalid smoothing factor to fail")
+	}
+}
+
+func TestCustomerCountSeries(t *testing.T) {
+	series := CustomerCountSeries(&cm.CustomerCountResult{Entries: []*cm.CustomerCountMetrics{
+		{Date: "2022-01-02", Customers: 10},
+		{Date: "2022-01-09", Customers: 12},
+		{Date: "2022-01-16", Customers: 14},
+	}}, cm.IntervalWeek)
+	points, err := Linear(series, 2, 0)
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	// weeks end on Sunday
+	if points[0].Date != "2022-01-23" || points[1].Date != "2022-01-30" || !near(points[1].Value, 18) {
+		spew.Dump(points)
+		t.Fatal("Unexpected forecast")
+	}
+}
+
+func TestComponents(t *testing.T) {
+	// 100 new business, 5% expansion and 2% churn every month
+	mrr := &cm.MRRResult{Entries: []*cm.MRRMetrics{{Date: "2021-01-31", MRR: 1000, MRRNewBusiness: 1000}}}
+	for month := 2; month <= 12; month++ {
+		start := mrr.Entries[len(mrr.Entries)-1].MRR
+		entry := &cm.MRRMetrics{
+			Date:           cm.NewDate(cm.IntervalMonth.PeriodEnd(time.Date(2021, time.Month(month), 1, 0, 0, 0, 0, time.UTC), time.Monday)),
+			MRRNewBusiness: 100,
+			MRRExpansion:   start * 0.05,
+			MRRChurn:       -start * 0.02,
+		}
+		entry.MRR = start + entry.MRRNewBusiness + entry.MRRExpansion + entry.MRRChurn
+		mrr.Entries = append(mrr.Entries, entry)
+	}
+
+	forecast, err := Components(mrr, cm.IntervalMonth, 2, ComponentParams{Window: 6})
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	last := mrr.Entries[len(mrr.Entries)-1].MRR
+	january := last*1.03 + 100
+	flows := forecast.Flows[0]
+	if flows.Date != "2022-01-31" || !near(flows.NewBusiness, 100) || !near(flows.Expansion, last*0.05) || !near(flows.Churn, -last*0.02) {
+		spew.Dump(forecast)
+		t.Fatal("Unexpected flows")
+	}
+	if !near(forecast.Points[0].Value, january) || !near(forecast.Points[1].Value, january*1.03+100) || !near(forecast.Points[1].Upper, forecast.Points[1].Value) {
+		spew.Dump(forecast)
+		t.Fatal("Unexpected points")
+	}
+}
@@ -27,10 +27,12 @@ func (api API) create(path string, input interface{}, output interface{}) error
 	// https://godoc.org/github.com/cenkalti/backoff#pkg-constants
 	// nolint:errcheck
 	backoff.Retry(func() error {
+		release := acquireRequestSlot()
 		res, body, errs = api.req(gorequest.New().
 			Post(prepareURL(path))).
 			SendStruct(input).
 			EndStruct(output)
+		release()
 
 		if networkErrors(errs) || isHTTPStatusRetryable(res) {
 			return errRetry
@@ -55,7 +57,9 @@ func (api API) list(path string, output interface{}, query ...interface{}) error
 			req.Query(q)
 		}
 
+		release := acquireRequestSlot()
 		res, body, errs = req.EndStruct(output)
+		release()
 		if networkErrors(errs) || isHTTPStatusRetryable(res) {
 			return errRetry
 		}
@@ -76,8 +80,10 @@ func (api API) retrieve(path string, uuid string, output interface{}) error {
 
 	// nolint:errcheck
 	backoff.Retry(func() error {
+		release := acquireRequestSlot()
 		res, body, errs = api.req(gorequest.New().Get(prepareURL(path))).
 			EndStruct(output)
+		release()
 
 		if networkErrors(errs) || isHTTPStatusRetryable(res) {
 			return errRetry
@@ -96,10 +102,12 @@ func (api API) merge(path string, input interface{}) error {
 
 	// nolint:errcheck
 	backoff.Retry(func() error {
+		release := acquireRequestSlot()
 		res, body, errs = api.req(gorequest.New().
 			Post(prepareURL(path))).
 			SendStruct(input).
 			End()
+		release()
 
 		if networkErrors(errs) || isHTTPStatusRetryable(res) {
 			return errRetry
@@ -132,7 +140,9 @@ func (api API) updateImpl(path string, uuid string, input interface{}, output in
 			req = req.Put(path)
 		}
 		req = api.req(req).SendStruct(input)
+		release := acquireRequestSlot()
 		res, body, errs = req.EndStruct(output)
+		release()
 
 		if networkErrors(errs) || isHTTPStatusRetryable(res) {
 			return errRetry
@@ -166,8 +176,10 @@ func (api API) delete(path string, uuid string) error {
 
 	// nolint:errcheck
 	backoff.Retry(func() error {
+		release := acquireRequestSlot()
 		res, body, errs = api.req(gorequest.New().Delete(prepareURL(path))).
 			End()
+		release()
 
 		if networkErrors(errs) || isHTTPStatusRetryable(res) {
 			return errRetry
@@ -186,9 +198,11 @@ func (api API) deleteWhat(path string, uuid string, input interface{}, output in
 
 	// nolint:errcheck
 	backoff.Retry(func() error {
+		release := acquireRequestSlot()
 		res, body, errs = api.req(gorequest.New().Delete(prepareURL(path))).
 			SendStruct(input).
 			EndStruct(output)
+		release()
 
 		if networkErrors(errs) || isHTTPStatusRetryable(res) {
 			return errRetry
@@ -205,10 +219,12 @@ func (api API) deleteWithData(path string, input interface{}) error {
 	var errs []error
 
 	backoff.Retry(func() error {
+		release := acquireRequestSlot()
 		res, body, errs = api.req(gorequest.New().
 			Delete(prepareURL(path))).
 			SendStruct(input).
 			End()
+		release()
 
 		if networkErrors(errs) || isHTTPStatusRetryable(res) {
 			return errRetry
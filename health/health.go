@@ -0,0 +1,235 @@
+// Package health scores customers' health from weighted signals, eg. their MRR trend, subscription status,
+// calls, open opportunities and custom attributes, and explains how each signal contributed.
+package health
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// now is the time of scoring, replaced in tests.
+var now = time.Now
+
+// Client retrieves the customer's data and saves scores, eg. *chartmogul.API.
+type Client interface {
+	cm.IApi
+	ListCustomerOpporunities(listOpportunitiesParams *cm.ListOpportunitiesParams, customerUUID string) (*cm.Opportunities, error)
+}
+
+// Customer is the data the signals score.
+type Customer struct {
+	UUID string
+	// Activities are in chronological order.
+	Activities    []*cm.MetricsCustomerActivity
+	Subscriptions []*cm.MetricsCustomerSubscription
+	Notes         []*cm.Note
+	Opportunities []*cm.Opportunity
+	Attributes    *cm.Attributes
+}
+
+// Signal scores one aspect of a customer's health.
+type Signal interface {
+	// Name identifies the signal in explanations.
+	Name() string
+	// Score returns a score from 0 (unhealthy) to 1 (healthy) with the reason for it.
+	// Signals which don't apply, eg. a missing custom attribute, return false and are left out.
+	Score(customer *Customer, now time.Time) (score float64, reason string, ok bool)
+}
+
+// Weighted is a signal with its weight in the score.
+type Weighted struct {
+	Signal Signal
+	Weight float64
+}
+
+// Contribution is how one signal contributed to a score.
+type Contribution struct {
+	Signal string  `json:"signal"`
+	Weight float64 `json:"weight"`
+	// Score of the signal from 0 to 1, Points its share of the overall score.
+	Score  float64 `json:"score"`
+	Points float64 `json:"points"`
+	Reason string  `json:"reason"`
+	// Applied is false for signals which didn't apply, they have no points.
+	Applied bool `json:"applied"`
+}
+
+// Score is the health of a customer from 0 to 100, the weighted average of the signals which applied.
+type Score struct {
+	CustomerUUID  string          `json:"customer-uuid"`
+	Score         float64         `json:"score"`
+	Contributions []*Contribution `json:"contributions"`
+}
+
+// Explain writes the score and a line per signal, eg. "MRR trend: 20.0 points (weight 2, score 0.60): MRR grew by 10%".
+func (s *Score) Explain(w io.Writer) error {
+	lines := []string{fmt.Sprintf("Health score of %v: %.0f", s.CustomerUUID, s.Score)}
+	for _, c := range s.Contributions {
+		if !c.Applied {
+			lines = append(lines, fmt.Sprintf("%v: not applied: %v", c.Signal, c.Reason))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%v: %.1f points (weight %g, score %.2f): %v", c.Signal, c.Points, c.Weight, c.Score, c.Reason))
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// DefaultSignals are equally weighted built-in signals with their default settings.
+func DefaultSignals() []Weighted {
+	return []Weighted{
+		{Signal: MRRTrend{}, Weight: 1},
+		{Signal: SubscriptionStatus{}, Weight: 1},
+		{Signal: CallRecency{}, Weight: 1},
+		{Signal: OpenOpportunities{}, Weight: 1},
+	}
+}
+
+// Scorer scores customers by the signals.
+type Scorer struct {
+	Signals []Weighted
+	// Attribute is the key of the custom attribute Save writes scores to, "health_score" if empty.
+	// It must be defined as an Integer attribute.
+	Attribute string
+
+	client Client
+}
+
+// NewScorer creates a scorer with the signals, DefaultSignals if none.
+func NewScorer(client Client, signals ...Weighted) *Scorer {
+	if len(signals) == 0 {
+		signals = DefaultSignals()
+	}
+	return &Scorer{Signals: signals, client: client}
+}
+
+// Score retrieves the customer's activities, subscriptions, notes, opportunities and attributes
+// concurrently and scores them.
+func (s *Scorer) Score(customerUUID string) (*Score, error) {
+	customer, err := s.Retrieve(customerUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ScoreCustomer(customer)
+}
+
+// ScoreCustomer scores customer data already retrieved.
+func (s *Scorer) ScoreCustomer(customer *Customer) (*Score, error) {
+	score := &Score{CustomerUUID: customer.UUID, Contributions: make([]*Contribution, len(s.Signals))}
+	at := now()
+	var weights float64
+	for i, weighted := range s.Signals {
+		if weighted.Weight < 0 {
+			return nil, fmt.Errorf("chartmogul: signal %v has a negative weight", weighted.Signal.Name())
+		}
+		c := &Contribution{Signal: weighted.Signal.Name(), Weight: weighted.Weight}
+		c.Score, c.Reason, c.Applied = weighted.Signal.Score(customer, at)
+		if c.Applied {
+			c.Score = clamp(c.Score)
+			weights += weighted.Weight
+		}
+		score.Contributions[i] = c
+	}
+	for _, c := range score.Contributions {
+		if c.Applied && weights > 0 {
+			c.Points = 100 * c.Score * c.Weight / weights
+			score.Score += c.Points
+		}
+	}
+	return score, nil
+}
+
+// Save writes the score, rounded, to the customer's custom attribute.
+func (s *Scorer) Save(score *Score) error {
+	attribute := s.Attribute
+	if attribute == "" {
+		attribute = "health_score"
+	}
+	_, err := s.client.UpdateCustomAttributesOfCustomer(score.CustomerUUID, map[string]interface{}{
+		attribute: int(score.Score + 0.5),
+	})
+	return err
+}
+
+// Retrieve reads all data of the customer the signals score, concurrently.
+func (s *Scorer) Retrieve(customerUUID string) (*Customer, error) {
+	customer := &Customer{UUID: customerUUID}
+	fetches := []func() error{
+		func() error {
+			it := cm.NewMetricsCustomerActivitiesIterator(s.client, customerUUID, nil)
+			for {
+				activity, err := it.Read()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				customer.Activities = append(customer.Activities, activity)
+			}
+		},
+		func() error {
+			it := cm.NewMetricsCustomerSubscriptionsIterator(s.client, customerUUID, nil)
+			for {
+				subscription, err := it.Read()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				customer.Subscriptions = append(customer.Subscriptions, subscription)
+			}
+		},
+		func() error {
+			it := cm.NewCustomerNotesIterator(s.client, customerUUID, nil)
+			for {
+				note, err := it.Read()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				customer.Notes = append(customer.Notes, note)
+			}
+		},
+		func() error {
+			it := cm.NewCustomerOpportunitiesIterator(s.client, customerUUID, nil)
+			for {
+				opportunity, err := it.Read()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				customer.Opportunities = append(customer.Opportunities, opportunity)
+			}
+		},
+		func() (err error) {
+			customer.Attributes, err = s.client.RetrieveCustomersAttributes(customerUUID)
+			return err
+		},
+	}
+
+	if err := cm.Parallel(fetches...); err != nil {
+		return nil, err
+	}
+	sortActivities(customer.Activities)
+	return customer, nil
+}
+
+func clamp(score float64) float64 {
+	switch {
+	case score < 0:
+		return 0
+	case score > 1:
+		return 1
+	}
+	return score
+}
@@ -0,0 +1,105 @@
+package health
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// fakeClient serves one customer, notes in two pages.
+type fakeClient struct {
+	cm.IApi
+	saved map[string]interface{}
+	fail  bool
+}
+
+func (c *fakeClient) MetricsListCustomerActivities(cursor *cm.Cursor, customerUUID string) (*cm.MetricsCustomerActivities, error) {
+	return &cm.MetricsCustomerActivities{Entries: []*cm.MetricsCustomerActivity{
+		{ID: 2, Date: "2022-05-01T00:00:00Z", ActivityMrr: 1100},
+		{ID: 1, Date: "2022-01-01T00:00:00Z", ActivityMrr: 1000},
+	}}, nil
+}
+
+func (c *fakeClient) MetricsListCustomerSubscriptions(cursor *cm.Cursor, customerUUID string) (*cm.MetricsCustomerSubscriptions, error) {
+	return &cm.MetricsCustomerSubscriptions{Entries: []*cm.MetricsCustomerSubscription{
+		{ID: 1, Status: "active"}, {ID: 2, Status: "cancelled"},
+	}}, nil
+}
+
+func (c *fakeClient) ListCustomerNotes(params *cm.ListNotesParams, customerUUID string) (*cm.Notes, error) {
+	if params.Cursor.Cursor == "" {
+		return &cm.Notes{
+			Entries:    []*cm.Note{{Type: "note", CreatedAt: "2022-05-30T00:00:00Z"}},
+			Pagination: cm.Pagination{HasMore: true, Cursor: "c2"},
+		}, nil
+	}
+	return &cm.Notes{Entries: []*cm.Note{{Type: "call", CreatedAt: "2022-05-20T00:00:00Z"}}}, nil
+}
+
+func (c *fakeClient) ListCustomerOpporunities(params *cm.ListOpportunitiesParams, customerUUID string) (*cm.Opportunities, error) {
+	if c.fail {
+		return nil, errors.New("unavailable")
+	}
+	return &cm.Opportunities{Entries: []*cm.Opportunity{{ForecastCategory: "lost", WinLikelihood: 90}}}, nil
+}
+
+func (c *fakeClient) RetrieveCustomersAttributes(customerUUID string) (*cm.Attributes, error) {
+	return &cm.Attributes{Custom: map[string]interface{}{"nps": float64(8)}}, nil
+}
+
+func (c *fakeClient) UpdateCustomAttributesOfCustomer(customerUUID string, custom map[string]interface{}) (*cm.CustomAttributes, error) {
+	c.saved = custom
+	return &cm.CustomAttributes{Custom: custom}, nil
+}
+
+func TestScorer(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	client := &fakeClient{}
+	scorer := NewScorer(client, append(DefaultSignals(), Weighted{Signal: Attribute{Key: "nps", Max: 10}, Weight: 2})...)
+	score, err := scorer.Score("cus_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// MRR trend 0.6, subscriptions 0.5, call 1, opportunities left out, NPS 0.8 counted twice: 3.7 of 5.
+	if len(score.Contributions) != 5 || score.Contributions[3].Applied || score.Contributions[2].Score != 1 ||
+		score.Score < 73.99 || score.Score > 74.01 {
+		spew.Dump(score)
+		t.Fatal("Unexpected score")
+	}
+	out := &bytes.Buffer{}
+	if err := score.Explain(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"Health score of cus_1: 74\n",
+		"MRR trend: 12.0 points (weight 1, score 0.60): MRR grew by 10% in 3 months\n",
+		"Open opportunities: not applied: no open opportunities\n",
+		"nps: 32.0 points (weight 2, score 0.80): nps is 8\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Log(out.String())
+			t.Fatalf("Expected %q in the explanation", expected)
+		}
+	}
+
+	scorer.Attribute = "cs_health"
+	if err := scorer.Save(score); err != nil || client.saved["cs_health"] != 74 {
+		spew.Dump(err, client.saved)
+		t.Fatal("Expected the score to be saved")
+	}
+
+	client.fail = true
+	if _, err := scorer.Score("cus_1"); err == nil {
+		t.Fatal("Expected a failed request to fail")
+	}
+	if _, err := NewScorer(client, Weighted{Signal: SubscriptionStatus{}, Weight: -1}).ScoreCustomer(&Customer{}); err == nil {
+		t.Fatal("Expected a negative weight to fail")
+	}
+}
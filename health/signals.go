@@ -0,0 +1,196 @@
+package health
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/chartmogul/chartmogul-go/v4/pipeline"
+)
+
+// Values of Subscription.Status and Note.Type the signals look for.
+const (
+	activeStatus = "active"
+	callNote     = "call"
+)
+
+// MRRTrend scores the change of MRR over the last months: 0.5 for flat MRR, plus or minus the relative change,
+// eg. 1 for MRR grown by half or more, 0 for MRR halved or churned. Customers whose MRR started within
+// the months score 1, customers without activities are left out.
+type MRRTrend struct {
+	// Months to look back, 3 if zero.
+	Months int
+}
+
+// Name is "MRR trend".
+func (s MRRTrend) Name() string { return "MRR trend" }
+
+// Score compares the MRR of the latest activity with that of the latest activity the months before.
+func (s MRRTrend) Score(customer *Customer, now time.Time) (float64, string, bool) {
+	months := s.Months
+	if months == 0 {
+		months = 3
+	}
+	if len(customer.Activities) == 0 {
+		return 0, "no activities", false
+	}
+	since := now.AddDate(0, -months, 0)
+	current := customer.Activities[len(customer.Activities)-1].ActivityMrr
+	before := 0.0
+	for _, activity := range customer.Activities {
+		if at, err := activity.Date.Time(); err == nil && at.After(since) {
+			break
+		}
+		before = activity.ActivityMrr
+	}
+	switch {
+	case current <= 0:
+		return 0, "churned", true
+	case before <= 0:
+		return 1, fmt.Sprintf("MRR started within %v months", months), true
+	}
+	change := (current - before) / before
+	verb := "grew"
+	if change < 0 {
+		verb = "fell"
+	}
+	return 0.5 + change, fmt.Sprintf("MRR %v by %.0f%% in %v months", verb, math.Abs(change)*100, months), true
+}
+
+// SubscriptionStatus scores the share of active subscriptions, customers without subscriptions score 0.
+type SubscriptionStatus struct{}
+
+// Name is "Subscription status".
+func (s SubscriptionStatus) Name() string { return "Subscription status" }
+
+// Score returns the active subscriptions of all.
+func (s SubscriptionStatus) Score(customer *Customer, now time.Time) (float64, string, bool) {
+	if len(customer.Subscriptions) == 0 {
+		return 0, "no subscriptions", true
+	}
+	active := 0
+	for _, subscription := range customer.Subscriptions {
+		if subscription.Status == activeStatus {
+			active++
+		}
+	}
+	return float64(active) / float64(len(customer.Subscriptions)),
+		fmt.Sprintf("%v of %v subscriptions active", active, len(customer.Subscriptions)), true
+}
+
+// CallRecency scores the days since the latest call note: 1 up to Recent, falling linearly to 0 at Stale.
+// Customers without calls score 0.
+type CallRecency struct {
+	// Recent and Stale are 30 and 180 days if zero.
+	Recent, Stale time.Duration
+}
+
+// Name is "Call recency".
+func (s CallRecency) Name() string { return "Call recency" }
+
+// Score finds the latest note of type call.
+func (s CallRecency) Score(customer *Customer, now time.Time) (float64, string, bool) {
+	recent, stale := s.Recent, s.Stale
+	if recent == 0 {
+		recent = 30 * 24 * time.Hour
+	}
+	if stale == 0 {
+		stale = 180 * 24 * time.Hour
+	}
+	var latest time.Time
+	for _, note := range customer.Notes {
+		if note.Type != callNote {
+			continue
+		}
+		if at, err := note.CreatedAt.Time(); err == nil && at.After(latest) {
+			latest = at
+		}
+	}
+	if latest.IsZero() {
+		return 0, "no calls", true
+	}
+	since := now.Sub(latest)
+	reason := fmt.Sprintf("last call %.0f days ago", math.Floor(since.Hours()/24))
+	switch {
+	case since <= recent:
+		return 1, reason, true
+	case since >= stale:
+		return 0, reason, true
+	}
+	return float64(stale-since) / float64(stale-recent), reason, true
+}
+
+// OpenOpportunities scores the highest win likelihood of the open opportunities, those not won or lost.
+// Customers without open opportunities are left out.
+type OpenOpportunities struct{}
+
+// Name is "Open opportunities".
+func (s OpenOpportunities) Name() string { return "Open opportunities" }
+
+// Score returns the highest win likelihood in percents as a fraction.
+func (s OpenOpportunities) Score(customer *Customer, now time.Time) (float64, string, bool) {
+	open, likelihood := 0, 0
+	for _, opportunity := range customer.Opportunities {
+		if opportunity.ForecastCategory == pipeline.CategoryWon || opportunity.ForecastCategory == pipeline.CategoryLost {
+			continue
+		}
+		open++
+		if opportunity.WinLikelihood > likelihood {
+			likelihood = opportunity.WinLikelihood
+		}
+	}
+	if open == 0 {
+		return 0, "no open opportunities", false
+	}
+	return float64(likelihood) / 100, fmt.Sprintf("%v open, highest win likelihood %v%%", open, likelihood), true
+}
+
+// Attribute scores a numeric custom attribute linearly from Min (0) to Max (1), eg. an NPS from 0 to 10.
+// Customers without the attribute or with a value which isn't a number are left out.
+type Attribute struct {
+	Key      string
+	Min, Max float64
+}
+
+// Name is the key of the attribute.
+func (s Attribute) Name() string { return s.Key }
+
+// Score scales the value of the attribute.
+func (s Attribute) Score(customer *Customer, now time.Time) (float64, string, bool) {
+	if customer.Attributes == nil || customer.Attributes.Custom[s.Key] == nil {
+		return 0, "not set", false
+	}
+	value, ok := number(customer.Attributes.Custom[s.Key])
+	if !ok || s.Max == s.Min {
+		return 0, fmt.Sprintf("%v isn't a number in a range", customer.Attributes.Custom[s.Key]), false
+	}
+	return (value - s.Min) / (s.Max - s.Min), fmt.Sprintf("%v is %g", s.Key, value), true
+}
+
+// number converts the value of a custom attribute, as decoded from JSON or set in code.
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// sortActivities orders the activities chronologically, those with invalid dates first.
+func sortActivities(activities []*cm.MetricsCustomerActivity) {
+	times := make(map[*cm.MetricsCustomerActivity]time.Time, len(activities))
+	for _, activity := range activities {
+		times[activity], _ = activity.Date.Time()
+	}
+	sort.SliceStable(activities, func(i, j int) bool { return times[activities[i]].Before(times[activities[j]]) })
+}
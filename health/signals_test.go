@@ -0,0 +1,49 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+func TestSignals(t *testing.T) {
+	at := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	activities := func(mrr ...float64) []*cm.MetricsCustomerActivity {
+		dates := []cm.Timestamp{"2022-01-15T00:00:00Z", "2022-04-15T00:00:00Z"}
+		var list []*cm.MetricsCustomerActivity
+		for i, m := range mrr {
+			list = append(list, &cm.MetricsCustomerActivity{Date: dates[i], ActivityMrr: m})
+		}
+		return list
+	}
+	cases := []struct {
+		name     string
+		signal   Signal
+		customer *Customer
+		score    float64
+		reason   string
+		applied  bool
+	}{
+		{"shrinking", MRRTrend{}, &Customer{Activities: activities(1000, 800)}, 0.3, "MRR fell by 20% in 3 months", true},
+		{"new", MRRTrend{}, &Customer{Activities: activities(0, 800)}, 1, "MRR started within 3 months", true},
+		{"churned", MRRTrend{}, &Customer{Activities: activities(1000, 0)}, 0, "churned", true},
+		{"no activities", MRRTrend{}, &Customer{}, 0, "no activities", false},
+		{"no subscriptions", SubscriptionStatus{}, &Customer{}, 0, "no subscriptions", true},
+		{"old call", CallRecency{}, &Customer{Notes: []*cm.Note{{Type: "call", CreatedAt: "2022-01-02T00:00:00Z"}}}, 0.2, "last call 150 days ago", true},
+		{"stale call", CallRecency{Stale: 100 * 24 * time.Hour}, &Customer{Notes: []*cm.Note{{Type: "call", CreatedAt: "2022-01-02T00:00:00Z"}}}, 0, "last call 150 days ago", true},
+		{"no calls", CallRecency{}, &Customer{Notes: []*cm.Note{{Type: "email", CreatedAt: "2022-05-31T00:00:00Z"}}}, 0, "no calls", true},
+		{"open opportunities", OpenOpportunities{}, &Customer{Opportunities: []*cm.Opportunity{
+			{ForecastCategory: "won", WinLikelihood: 100}, {ForecastCategory: "pipeline", WinLikelihood: 20}, {ForecastCategory: "committed", WinLikelihood: 70},
+		}}, 0.7, "2 open, highest win likelihood 70%", true},
+		{"text attribute", Attribute{Key: "nps", Min: -100, Max: 100}, &Customer{Attributes: &cm.Attributes{Custom: map[string]interface{}{"nps": "50"}}}, 0.75, "nps is 50", true},
+		{"missing attribute", Attribute{Key: "nps", Max: 10}, &Customer{Attributes: &cm.Attributes{}}, 0, "not set", false},
+		{"invalid attribute", Attribute{Key: "nps", Max: 10}, &Customer{Attributes: &cm.Attributes{Custom: map[string]interface{}{"nps": true}}}, 0, "true isn't a number in a range", false},
+	}
+	for _, c := range cases {
+		score, reason, applied := c.signal.Score(c.customer, at)
+		if score < c.score-1e-9 || score > c.score+1e-9 || reason != c.reason || applied != c.applied {
+			t.Fatalf("Unexpected %v: %v %q %v", c.name, score, reason, applied)
+		}
+	}
+}
@@ -66,7 +66,7 @@ func TestConnectSubscriptions(t *testing.T) {
 		t.Fatal(err)
 	}
 	invoice1 := &cm.Invoice{
-		Date:       time.Now().Format(time.RFC3339),
+		Date:       cm.NewTimestamp(time.Now()),
 		ExternalID: "INV_to_be_retrieved",
 		Currency:   "EUR",
 		LineItems: []*cm.LineItem{
@@ -89,14 +89,14 @@ func TestConnectSubscriptions(t *testing.T) {
 		},
 		Transactions: []*cm.Transaction{
 			{
-				Date:   time.Now().Add(3 * time.Hour).Format(time.RFC3339),
+				Date:   cm.NewTimestamp(time.Now().Add(3 * time.Hour)),
 				Result: "successful",
 				Type:   "payment",
 			},
 		},
 	}
 	invoice2 := &cm.Invoice{
-		Date:       time.Now().Format(time.RFC3339),
+		Date:       cm.NewTimestamp(time.Now()),
 		ExternalID: "INV_to_be_retrieved_2",
 		Currency:   "EUR",
 		LineItems: []*cm.LineItem{
@@ -113,7 +113,7 @@ func TestConnectSubscriptions(t *testing.T) {
 		},
 		Transactions: []*cm.Transaction{
 			{
-				Date:   time.Now().Add(3 * time.Hour).Format(time.RFC3339),
+				Date:   cm.NewTimestamp(time.Now().Add(3 * time.Hour)),
 				Result: "successful",
 				Type:   "payment",
 			},
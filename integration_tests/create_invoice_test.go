@@ -74,7 +74,7 @@ func TestCreateInvoice(t *testing.T) {
 	}
 
 	invoice := &cm.Invoice{
-		Date:               time.Now().Format(time.RFC3339),
+		Date:               cm.NewTimestamp(time.Now()),
 		ExternalID:         "INV_to_be_retrieved",
 		CustomerExternalID: cus1.ExternalID,
 		Currency:           "EUR",
@@ -99,7 +99,7 @@ func TestCreateInvoice(t *testing.T) {
 		},
 		Transactions: []*cm.Transaction{
 			{
-				Date:   time.Now().Add(3 * time.Hour).Format(time.RFC3339),
+				Date:   cm.NewTimestamp(time.Now().Add(3 * time.Hour)),
 				Result: "successful",
 				Type:   "payment",
 			},
@@ -58,7 +58,7 @@ func TestEmptyDataSource(t *testing.T) {
 
 	_, err = api.CreateInvoices([]*cm.Invoice{
 		{
-			Date:       time.Now().Format(time.RFC3339),
+			Date:       cm.NewTimestamp(time.Now()),
 			ExternalID: "INV_to_be_deleted",
 			Currency:   "EUR",
 			LineItems: []*cm.LineItem{
@@ -81,7 +81,7 @@ func TestEmptyDataSource(t *testing.T) {
 			},
 			Transactions: []*cm.Transaction{
 				{
-					Date:   time.Now().Add(3 * time.Hour).Format(time.RFC3339),
+					Date:   cm.NewTimestamp(time.Now().Add(3 * time.Hour)),
 					Result: "successful",
 					Type:   "payment",
 				},
@@ -14,7 +14,7 @@ import (
 func createTestInvoicesForCustomer(api *cm.API, cus cm.Customer, planUUID string, t *testing.T) *cm.Invoices {
 	testInvoices := []*cm.Invoice{
 		{
-			Date:               time.Now().Format(time.RFC3339),
+			Date:               cm.NewTimestamp(time.Now()),
 			ExternalID:         "INV_to_be_retrieved",
 			CustomerUUID:       cus.UUID,
 			CustomerExternalID: cus.ExternalID,
@@ -40,14 +40,14 @@ func createTestInvoicesForCustomer(api *cm.API, cus cm.Customer, planUUID string
 			},
 			Transactions: []*cm.Transaction{
 				{
-					Date:   time.Now().Add(3 * time.Hour).Format(time.RFC3339),
+					Date:   cm.NewTimestamp(time.Now().Add(3 * time.Hour)),
 					Result: "successful",
 					Type:   "payment",
 				},
 			},
 		},
 		{
-			Date:               time.Now().Format(time.RFC3339),
+			Date:               cm.NewTimestamp(time.Now()),
 			ExternalID:         "INV_to_be_retrieved1",
 			CustomerUUID:       cus.UUID,
 			CustomerExternalID: cus.ExternalID,
@@ -73,7 +73,7 @@ func createTestInvoicesForCustomer(api *cm.API, cus cm.Customer, planUUID string
 			},
 			Transactions: []*cm.Transaction{
 				{
-					Date:   time.Now().Add(3 * time.Hour).Format(time.RFC3339),
+					Date:   cm.NewTimestamp(time.Now().Add(3 * time.Hour)),
 					Result: "successful",
 					Type:   "payment",
 				},
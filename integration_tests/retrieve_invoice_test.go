@@ -57,7 +57,7 @@ func TestRetrieveInvoice(t *testing.T) {
 		t.Fatal(err)
 	}
 	invoice := &cm.Invoice{
-		Date:       time.Now().Format(time.RFC3339),
+		Date:       cm.NewTimestamp(time.Now()),
 		ExternalID: "INV_to_be_retrieved",
 		Currency:   "EUR",
 		LineItems: []*cm.LineItem{
@@ -81,7 +81,7 @@ func TestRetrieveInvoice(t *testing.T) {
 		},
 		Transactions: []*cm.Transaction{
 			{
-				Date:   time.Now().Add(3 * time.Hour).Format(time.RFC3339),
+				Date:   cm.NewTimestamp(time.Now().Add(3 * time.Hour)),
 				Result: "successful",
 				Type:   "payment",
 			},
@@ -0,0 +1,237 @@
+package chartmogul
+
+import "io"
+
+// MetricsActivitiesSource yields activities one by one, eg. MetricsActivitiesIterator
+// walking the API or MetricsActivitiesReader of an activities export.
+type MetricsActivitiesSource interface {
+	// Read returns the next activity, or io.EOF after the last one.
+	Read() (*MetricsActivity, error)
+}
+
+// MetricsActivitiesIterator reads all activities matching the parameters page by page.
+type MetricsActivitiesIterator struct {
+	api    IApi
+	params MetricsListActivitiesParams
+	page   []*MetricsActivity
+	more   bool
+}
+
+// NewMetricsActivitiesIterator iterates over MetricsListActivities, following the anchor cursor.
+// The parameters can be nil.
+func NewMetricsActivitiesIterator(api IApi, params *MetricsListActivitiesParams) *MetricsActivitiesIterator {
+	it := &MetricsActivitiesIterator{api: api, more: true}
+	if params != nil {
+		it.params = *params
+	}
+	return it
+}
+
+// Read returns the next activity, or io.EOF after the last one.
+func (it *MetricsActivitiesIterator) Read() (*MetricsActivity, error) {
+	for len(it.page) == 0 {
+		if !it.more {
+			return nil, io.EOF
+		}
+		result, err := it.api.MetricsListActivities(&it.params)
+		if err != nil {
+			return nil, err
+		}
+		it.page, it.more = result.Entries, result.HasMore && len(result.Entries) != 0
+		if len(result.Entries) != 0 {
+			it.params.StartAfter = result.Entries[len(result.Entries)-1].UUID
+		}
+	}
+	activity := it.page[0]
+	it.page = it.page[1:]
+	return activity, nil
+}
+
+// MetricsCustomerSubscriptionsIterator reads all subscriptions of a customer page by page.
+type MetricsCustomerSubscriptionsIterator struct {
+	api          IApi
+	customerUUID string
+	cursor       Cursor
+	page         []*MetricsCustomerSubscription
+	more         bool
+}
+
+// NewMetricsCustomerSubscriptionsIterator iterates over MetricsListCustomerSubscriptions, following the cursor.
+// The cursor can be nil.
+func NewMetricsCustomerSubscriptionsIterator(api IApi, customerUUID string, cursor *Cursor) *MetricsCustomerSubscriptionsIterator {
+	it := &MetricsCustomerSubscriptionsIterator{api: api, customerUUID: customerUUID, more: true}
+	if cursor != nil {
+		it.cursor = *cursor
+	}
+	return it
+}
+
+// Read returns the next subscription, or io.EOF after the last one.
+func (it *MetricsCustomerSubscriptionsIterator) Read() (*MetricsCustomerSubscription, error) {
+	for len(it.page) == 0 {
+		if !it.more {
+			return nil, io.EOF
+		}
+		result, err := it.api.MetricsListCustomerSubscriptions(&it.cursor, it.customerUUID)
+		if err != nil {
+			return nil, err
+		}
+		it.page, it.more = result.Entries, result.HasMore && result.Cursor != ""
+		it.cursor.Cursor = result.Cursor
+	}
+	subscription := it.page[0]
+	it.page = it.page[1:]
+	return subscription, nil
+}
+
+// MetricsCustomerActivitiesIterator reads all activities of a customer page by page.
+type MetricsCustomerActivitiesIterator struct {
+	api          IApi
+	customerUUID string
+	cursor       Cursor
+	page         []*MetricsCustomerActivity
+	more         bool
+}
+
+// NewMetricsCustomerActivitiesIterator iterates over MetricsListCustomerActivities, following the cursor.
+// The cursor can be nil.
+func NewMetricsCustomerActivitiesIterator(api IApi, customerUUID string, cursor *Cursor) *MetricsCustomerActivitiesIterator {
+	it := &MetricsCustomerActivitiesIterator{api: api, customerUUID: customerUUID, more: true}
+	if cursor != nil {
+		it.cursor = *cursor
+	}
+	return it
+}
+
+// Read returns the next activity, or io.EOF after the last one.
+func (it *MetricsCustomerActivitiesIterator) Read() (*MetricsCustomerActivity, error) {
+	for len(it.page) == 0 {
+		if !it.more {
+			return nil, io.EOF
+		}
+		result, err := it.api.MetricsListCustomerActivities(&it.cursor, it.customerUUID)
+		if err != nil {
+			return nil, err
+		}
+		it.page, it.more = result.Entries, result.HasMore && result.Cursor != ""
+		it.cursor.Cursor = result.Cursor
+	}
+	activity := it.page[0]
+	it.page = it.page[1:]
+	return activity, nil
+}
+
+// InvoicesIterator reads all invoices matching the parameters page by page.
+type InvoicesIterator struct {
+	api    IApi
+	params ListAllInvoicesParams
+	page   []*Invoice
+	more   bool
+}
+
+// NewInvoicesIterator iterates over ListAllInvoices, following the cursor.
+// The parameters can be nil.
+func NewInvoicesIterator(api IApi, params *ListAllInvoicesParams) *InvoicesIterator {
+	it := &InvoicesIterator{api: api, more: true}
+	if params != nil {
+		it.params = *params
+	}
+	return it
+}
+
+// Read returns the next invoice, or io.EOF after the last one.
+func (it *InvoicesIterator) Read() (*Invoice, error) {
+	for len(it.page) == 0 {
+		if !it.more {
+			return nil, io.EOF
+		}
+		result, err := it.api.ListAllInvoices(&it.params)
+		if err != nil {
+			return nil, err
+		}
+		it.page, it.more = result.Invoices, result.HasMore && result.Cursor != ""
+		it.params.Cursor.Cursor = result.Cursor
+	}
+	invoice := it.page[0]
+	it.page = it.page[1:]
+	return invoice, nil
+}
+
+// CustomerNotesIterator reads all notes of a customer page by page.
+type CustomerNotesIterator struct {
+	api          IApi
+	customerUUID string
+	params       ListNotesParams
+	page         []*Note
+	more         bool
+}
+
+// NewCustomerNotesIterator iterates over ListCustomerNotes, following the cursor.
+// The parameters can be nil.
+func NewCustomerNotesIterator(api IApi, customerUUID string, params *ListNotesParams) *CustomerNotesIterator {
+	it := &CustomerNotesIterator{api: api, customerUUID: customerUUID, more: true}
+	if params != nil {
+		it.params = *params
+	}
+	return it
+}
+
+// Read returns the next note, or io.EOF after the last one.
+func (it *CustomerNotesIterator) Read() (*Note, error) {
+	for len(it.page) == 0 {
+		if !it.more {
+			return nil, io.EOF
+		}
+		result, err := it.api.ListCustomerNotes(&it.params, it.customerUUID)
+		if err != nil {
+			return nil, err
+		}
+		it.page, it.more = result.Entries, result.HasMore && result.Cursor != ""
+		it.params.Cursor.Cursor = result.Cursor
+	}
+	note := it.page[0]
+	it.page = it.page[1:]
+	return note, nil
+}
+
+// CustomerOpportunitiesLister lists the opportunities of a customer, eg. *API.
+type CustomerOpportunitiesLister interface {
+	ListCustomerOpporunities(listOpportunitiesParams *ListOpportunitiesParams, customerUUID string) (*Opportunities, error)
+}
+
+// CustomerOpportunitiesIterator reads all opportunities of a customer page by page.
+type CustomerOpportunitiesIterator struct {
+	api          CustomerOpportunitiesLister
+	customerUUID string
+	params       ListOpportunitiesParams
+	page         []*Opportunity
+	more         bool
+}
+
+// NewCustomerOpportunitiesIterator iterates over ListCustomerOpporunities, following the cursor.
+// The parameters can be nil.
+func NewCustomerOpportunitiesIterator(api CustomerOpportunitiesLister, customerUUID string, params *ListOpportunitiesParams) *CustomerOpportunitiesIterator {
+	it := &CustomerOpportunitiesIterator{api: api, customerUUID: customerUUID, more: true}
+	if params != nil {
+		it.params = *params
+	}
+	return it
+}
+
+// Read returns the next opportunity, or io.EOF after the last one.
+func (it *CustomerOpportunitiesIterator) Read() (*Opportunity, error) {
+	for len(it.page) == 0 {
+		if !it.more {
+			return nil, io.EOF
+		}
+		result, err := it.api.ListCustomerOpporunities(&it.params, it.customerUUID)
+		if err != nil {
+			return nil, err
+		}
+		it.page, it.more = result.Entries, result.HasMore && result.Cursor != ""
+		it.params.Cursor.Cursor = result.Cursor
+	}
+	opportunity := it.page[0]
+	it.page = it.page[1:]
+	return opportunity, nil
+}
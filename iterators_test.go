@@ -0,0 +1,233 @@
+package chartmogul
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestMetricsActivitiesIterator(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/v/activities?per-page=2&type=new_biz":
+					w.Write([]byte(`{"entries": [{"uuid": "a1"}, {"uuid": "a2"}], "has_more": true, "per_page": 2}`)) //nolint
+				case "/v/activities?per-page=2&start-after=a2&type=new_biz":
+					w.Write([]byte(`{"entries": [{"uuid": "a3"}], "has_more": false, "per_page": 2}`)) //nolint
+				default:
+					t.Errorf("Unexpected URI %v", r.RequestURI)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	var tested IApi = &API{
+		ApiKey: "token",
+	}
+	var source MetricsActivitiesSource = NewMetricsActivitiesIterator(tested,
+		&MetricsListActivitiesParams{Type: "new_biz", AnchorCursor: AnchorCursor{PerPage: 2}})
+
+	var uuids []string
+	for {
+		activity, err := source.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			spew.Dump(err)
+			t.Fatal("Not expected to fail")
+		}
+		uuids = append(uuids, activity.UUID)
+	}
+	if len(uuids) != 3 || uuids[2] != "a3" {
+		spew.Dump(uuids)
+		t.Fatal("Unexpected result")
+	}
+}
+
+func TestMetricsCustomerSubscriptionsIterator(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/v/customers/cus_1/subscriptions":
+					w.Write([]byte(`{"entries": [{"id": 1}, {"id": 2}], "has_more": true, "cursor": "c2"}`)) //nolint
+				case "/v/customers/cus_1/subscriptions?cursor=c2":
+					w.Write([]byte(`{"entries": [], "has_more": true, "cursor": "c3"}`)) //nolint
+				case "/v/customers/cus_1/subscriptions?cursor=c3":
+					w.Write([]byte(`{"entries": [{"id": 3}], "has_more": false}`)) //nolint
+				default:
+					t.Errorf("Unexpected URI %v", r.RequestURI)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	it := NewMetricsCustomerSubscriptionsIterator(&API{ApiKey: "token"}, "cus_1", nil)
+	var ids []uint64
+	for {
+		subscription, err := it.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			spew.Dump(err)
+			t.Fatal("Not expected to fail")
+		}
+		ids = append(ids, subscription.ID)
+	}
+	if len(ids) != 3 || ids[2] != 3 {
+		spew.Dump(ids)
+		t.Fatal("Unexpected result")
+	}
+}
+
+func TestMetricsCustomerActivitiesIterator(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/v/customers/cus_1/activities":
+					w.Write([]byte(`{"entries": [{"id": 1}], "has_more": true, "cursor": "c2"}`)) //nolint
+				case "/v/customers/cus_1/activities?cursor=c2":
+					w.Write([]byte(`{"entries": [{"id": 2}], "has_more": false, "cursor": "c3"}`)) //nolint
+				default:
+					t.Errorf("Unexpected URI %v", r.RequestURI)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	it := NewMetricsCustomerActivitiesIterator(&API{ApiKey: "token"}, "cus_1", nil)
+	var ids []uint64
+	for {
+		activity, err := it.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			spew.Dump(err)
+			t.Fatal("Not expected to fail")
+		}
+		ids = append(ids, activity.ID)
+	}
+	if len(ids) != 2 || ids[1] != 2 {
+		spew.Dump(ids)
+		t.Fatal("Unexpected result")
+	}
+}
+
+func TestInvoicesIterator(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/v/invoices?customer_uuid=cus_1":
+					w.Write([]byte(`{"invoices": [{"uuid": "inv_1"}, {"uuid": "inv_2"}], "has_more": true, "cursor": "c2"}`)) //nolint
+				case "/v/invoices?cursor=c2&customer_uuid=cus_1":
+					w.Write([]byte(`{"invoices": [{"uuid": "inv_3"}], "has_more": false}`)) //nolint
+				default:
+					t.Errorf("Unexpected URI %v", r.RequestURI)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	it := NewInvoicesIterator(&API{ApiKey: "token"}, &ListAllInvoicesParams{CustomerUUID: "cus_1"})
+	var uuids []string
+	for {
+		invoice, err := it.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			spew.Dump(err)
+			t.Fatal("Not expected to fail")
+		}
+		uuids = append(uuids, invoice.UUID)
+	}
+	if len(uuids) != 3 || uuids[2] != "inv_3" {
+		spew.Dump(uuids)
+		t.Fatal("Unexpected result")
+	}
+}
+
+func TestCustomerNotesIterator(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/v/customer_notes?customer_uuid=cus_1":
+					w.Write([]byte(`{"entries": [{"uuid": "note_1"}, {"uuid": "note_2"}], "has_more": true, "cursor": "c2"}`)) //nolint
+				case "/v/customer_notes?cursor=c2&customer_uuid=cus_1":
+					w.Write([]byte(`{"entries": [{"uuid": "note_3"}], "has_more": false}`)) //nolint
+				default:
+					t.Errorf("Unexpected URI %v", r.RequestURI)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	it := NewCustomerNotesIterator(&API{ApiKey: "token"}, "cus_1", nil)
+	var uuids []string
+	for {
+		note, err := it.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			spew.Dump(err)
+			t.Fatal("Not expected to fail")
+		}
+		uuids = append(uuids, note.UUID)
+	}
+	if len(uuids) != 3 || uuids[2] != "note_3" {
+		spew.Dump(uuids)
+		t.Fatal("Unexpected result")
+	}
+}
+
+func TestCustomerOpportunitiesIterator(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/v/opportunities?customer_uuid=cus_1":
+					w.Write([]byte(`{"entries": [{"uuid": "opp_1"}], "has_more": true, "cursor": "c2"}`)) //nolint
+				case "/v/opportunities?cursor=c2&customer_uuid=cus_1":
+					w.Write([]byte(`{"entries": [{"uuid": "opp_2"}], "has_more": false}`)) //nolint
+				default:
+					t.Errorf("Unexpected URI %v", r.RequestURI)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	it := NewCustomerOpportunitiesIterator(&API{ApiKey: "token"}, "cus_1", nil)
+	var uuids []string
+	for {
+		opportunity, err := it.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			spew.Dump(err)
+			t.Fatal("Not expected to fail")
+		}
+		uuids = append(uuids, opportunity.UUID)
+	}
+	if len(uuids) != 2 || uuids[1] != "opp_2" {
+		spew.Dump(uuids)
+		t.Fatal("Unexpected result")
+	}
+}
@@ -1,17 +1,18 @@
 package chartmogul
 
 // MetricsFilter convenient object to hold all filtering parameters.
+// Use NewMetricsFilter to build a validated filter, eg. with a relative date range.
 type MetricsFilter struct {
-	StartDate string `json:"start-date,omitempty"`
-	EndDate   string `json:"end-date,omitempty"`
-	Interval  string `json:"interval,omitempty"`
-	Geo       string `json:"geo,omitempty"`
-	Plans     string `json:"plans,omitempty"`
+	StartDate Date     `json:"start-date,omitempty"`
+	EndDate   Date     `json:"end-date,omitempty"`
+	Interval  Interval `json:"interval,omitempty"`
+	Geo       string   `json:"geo,omitempty"`
+	Plans     string   `json:"plans,omitempty"`
 }
 
 // AllMetrics represents results of Metrics API.
 type AllMetrics struct {
-	Date                              string  `json:"date"`
+	Date                              Date    `json:"date"`
 	CustomerChurnRate                 float64 `json:"customer-churn-rate"`
 	MrrChurnRate                      float64 `json:"mrr-churn-rate"`
 	Ltv                               float64 `json:"ltv"`
@@ -43,6 +44,15 @@ type Summary struct {
 	PercentageChange float64 `json:"percentage-change"`
 }
 
+// PercentageChange is the change from previous to current in percents, zero if previous is zero,
+// as in summaries of the Metrics API.
+func PercentageChange(previous, current float64) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return (current - previous) / previous * 100
+}
+
 // Summary represents results of Metrics API.
 type AllSummary struct {
 	CurrentCustomerChurnRate          float64 `json:"current-customer-churn-rate"`
@@ -73,7 +83,7 @@ type AllSummary struct {
 
 // MRRMetrics represents results of Metrics API.
 type MRRMetrics struct {
-	Date             string  `json:"date"`
+	Date             Date    `json:"date"`
 	MRR              float64 `json:"mrr"`
 	MRRNewBusiness   float64 `json:"mrr-new-business"`
 	MRRExpansion     float64 `json:"mrr-expansion"`
@@ -91,7 +101,7 @@ type MRRResult struct {
 
 // ARRMetrics represents results of Metrics API.
 type ARRMetrics struct {
-	Date             string  `json:"date"`
+	Date             Date    `json:"date"`
 	ARR              float64 `json:"arr"`
 	PercentageChange float64 `json:"percentage-change"`
 }
@@ -104,7 +114,7 @@ type ARRResult struct {
 
 // ARPAMetrics represents results of Metrics API.
 type ARPAMetrics struct {
-	Date             string  `json:"date"`
+	Date             Date    `json:"date"`
 	ARPA             float64 `json:"arpa"`
 	PercentageChange float64 `json:"percentage-change"`
 }
@@ -117,7 +127,7 @@ type ARPAResult struct {
 
 // ASPMetrics represents results of Metrics API.
 type ASPMetrics struct {
-	Date             string  `json:"date"`
+	Date             Date    `json:"date"`
 	ASP              float64 `json:"asp"`
 	PercentageChange float64 `json:"percentage-change"`
 }
@@ -130,7 +140,7 @@ type ASPResult struct {
 
 // CustomerCountMetrics represents results of Metrics API.
 type CustomerCountMetrics struct {
-	Date             string  `json:"date"`
+	Date             Date    `json:"date"`
 	Customers        uint32  `json:"customers"`
 	PercentageChange float64 `json:"percentage-change"`
 }
@@ -143,7 +153,7 @@ type CustomerCountResult struct {
 
 // CustomerChurnRateMetrics represents results of Metrics API.
 type CustomerChurnRateMetrics struct {
-	Date              string  `json:"date"`
+	Date              Date    `json:"date"`
 	CustomerChurnRate float64 `json:"customer-churn-rate"`
 	PercentageChange  float64 `json:"percentage-change"`
 }
@@ -156,7 +166,7 @@ type CustomerChurnRateResult struct {
 
 // MRRChurnRateMetrics represents results of Metrics API.
 type MRRChurnRateMetrics struct {
-	Date             string  `json:"date"`
+	Date             Date    `json:"date"`
 	MRRChurnRate     float64 `json:"mrr-churn-rate"`
 	PercentageChange float64 `json:"percentage-change"`
 }
@@ -169,7 +179,7 @@ type MRRChurnRateResult struct {
 
 // LTVMetrics represents results of Metrics API.
 type LTVMetrics struct {
-	Date             string  `json:"date"`
+	Date             Date    `json:"date"`
 	LTV              float64 `json:"ltv"`
 	PercentageChange float64 `json:"percentage-change"`
 }
@@ -197,7 +207,7 @@ const (
 // See https://dev.chartmogul.com/v1.0/reference#retrieve-all-key-metrics
 func (api API) MetricsRetrieveAll(metricsFilter *MetricsFilter) (*MetricsResult, error) {
 	output := &MetricsResult{}
-	err := api.list(metricsEndpoint, output, *metricsFilter)
+	err := api.retrieveMetrics(metricsEndpoint, metricsFilter, output)
 	return output, err
 }
 
@@ -206,7 +216,7 @@ func (api API) MetricsRetrieveAll(metricsFilter *MetricsFilter) (*MetricsResult,
 // See https://dev.chartmogul.com/v1.0/reference#retrieve-mrr
 func (api API) MetricsRetrieveMRR(metricsFilter *MetricsFilter) (*MRRResult, error) {
 	output := &MRRResult{}
-	err := api.list(metricsMRREndpoint, output, *metricsFilter)
+	err := api.retrieveMetrics(metricsMRREndpoint, metricsFilter, output)
 	return output, err
 }
 
@@ -215,7 +225,7 @@ func (api API) MetricsRetrieveMRR(metricsFilter *MetricsFilter) (*MRRResult, err
 // See https://dev.chartmogul.com/v1.0/reference#retrieve-arr
 func (api API) MetricsRetrieveARR(metricsFilter *MetricsFilter) (*ARRResult, error) {
 	output := &ARRResult{}
-	err := api.list(metricsARREndpoint, output, *metricsFilter)
+	err := api.retrieveMetrics(metricsARREndpoint, metricsFilter, output)
 	return output, err
 }
 
@@ -224,7 +234,7 @@ func (api API) MetricsRetrieveARR(metricsFilter *MetricsFilter) (*ARRResult, err
 // See https://dev.chartmogul.com/v1.0/reference#retrieve-arpa
 func (api API) MetricsRetrieveARPA(metricsFilter *MetricsFilter) (*ARPAResult, error) {
 	output := &ARPAResult{}
-	err := api.list(metricsARPAEndpoint, output, *metricsFilter)
+	err := api.retrieveMetrics(metricsARPAEndpoint, metricsFilter, output)
 	return output, err
 }
 
@@ -233,7 +243,7 @@ func (api API) MetricsRetrieveARPA(metricsFilter *MetricsFilter) (*ARPAResult, e
 // See https://dev.chartmogul.com/v1.0/reference#retrieve-asp
 func (api API) MetricsRetrieveASP(metricsFilter *MetricsFilter) (*ASPResult, error) {
 	output := &ASPResult{}
-	err := api.list(metricsASPEndpoint, output, *metricsFilter)
+	err := api.retrieveMetrics(metricsASPEndpoint, metricsFilter, output)
 	return output, err
 }
 
@@ -242,7 +252,7 @@ func (api API) MetricsRetrieveASP(metricsFilter *MetricsFilter) (*ASPResult, err
 // See https://dev.chartmogul.com/v1.0/reference#retrieve-customer-count
 func (api API) MetricsRetrieveCustomerCount(metricsFilter *MetricsFilter) (*CustomerCountResult, error) {
 	output := &CustomerCountResult{}
-	err := api.list(metricsCustomerCountEndpoint, output, *metricsFilter)
+	err := api.retrieveMetrics(metricsCustomerCountEndpoint, metricsFilter, output)
 	return output, err
 }
 
@@ -251,7 +261,7 @@ func (api API) MetricsRetrieveCustomerCount(metricsFilter *MetricsFilter) (*Cust
 // See https://dev.chartmogul.com/v1.0/reference#retrieve-customer-churn-rate
 func (api API) MetricsRetrieveCustomerChurnRate(metricsFilter *MetricsFilter) (*CustomerChurnRateResult, error) {
 	output := &CustomerChurnRateResult{}
-	err := api.list(metricsCustomerChurnRateEndpoint, output, *metricsFilter)
+	err := api.retrieveMetrics(metricsCustomerChurnRateEndpoint, metricsFilter, output)
 	return output, err
 }
 
@@ -260,7 +270,7 @@ func (api API) MetricsRetrieveCustomerChurnRate(metricsFilter *MetricsFilter) (*
 // See https://dev.chartmogul.com/v1.0/reference#retrieve-mrr-churn-rate
 func (api API) MetricsRetrieveMRRChurnRate(metricsFilter *MetricsFilter) (*MRRChurnRateResult, error) {
 	output := &MRRChurnRateResult{}
-	err := api.list(metricsMRRChurnRateEndpoint, output, *metricsFilter)
+	err := api.retrieveMetrics(metricsMRRChurnRateEndpoint, metricsFilter, output)
 	return output, err
 }
 
@@ -269,6 +279,23 @@ func (api API) MetricsRetrieveMRRChurnRate(metricsFilter *MetricsFilter) (*MRRCh
 // See https://dev.chartmogul.com/v1.0/reference#retrieve-ltv
 func (api API) MetricsRetrieveLTV(metricsFilter *MetricsFilter) (*LTVResult, error) {
 	output := &LTVResult{}
-	err := api.list(metricsLTVEndpoint, output, *metricsFilter)
+	err := api.retrieveMetrics(metricsLTVEndpoint, metricsFilter, output)
 	return output, err
 }
+
+// retrieveMetrics validates the filter before querying the metrics endpoint.
+// Long date ranges are split into chunks, see metricsMaxPeriods. Their summary is recomputed from
+// the stitched entries, the value at the end of the range compared with that at its start.
+func (api API) retrieveMetrics(path string, metricsFilter *MetricsFilter, output metricsSeries) error {
+	if err := metricsFilter.Validate(); err != nil {
+		return err
+	}
+	if chunks := api.metricsChunks(metricsFilter); len(chunks) > 1 {
+		return api.retrieveChunkedMetrics(path, chunks, output)
+	}
+	query := make([]interface{}, 0, 1)
+	if metricsFilter != nil {
+		query = append(query, *metricsFilter)
+	}
+	return api.list(path, output, query...)
+}
@@ -2,12 +2,12 @@ package chartmogul
 
 // MetricsActivitiesExport represents Metrics API activity export in ChartMogul.
 type MetricsActivitiesExport struct {
-	ID        string `json:"id"`
-	Status    string `json:"status"`
-	FileURL   string `json:"file_url"`
-	Params    Params `json:"params"`
-	ExpiresAt string `json:"expires_at"`
-	CreatedAt string `json:"created_at"`
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	FileURL   string    `json:"file_url"`
+	Params    Params    `json:"params"`
+	ExpiresAt Timestamp `json:"expires_at"`
+	CreatedAt Timestamp `json:"created_at"`
 }
 
 // Params provides information on the requested export.
@@ -18,16 +18,16 @@ type Params struct {
 
 // NestedParams represents the params of the requested type of export.
 type NestedParams struct {
-	ActivityType string `json:"activity_type,omitempty"`
-	StartDate    string `json:"start_date,omitempty"`
-	EndDate      string `json:"end_date,omitempty"`
+	ActivityType string    `json:"activity_type,omitempty"`
+	StartDate    Timestamp `json:"start_date,omitempty"`
+	EndDate      Timestamp `json:"end_date,omitempty"`
 }
 
 // CreateMetricsActivitiesExportParam to create a MetricsActivitiesExport.
 type CreateMetricsActivitiesExportParam struct {
-	Type      string `json:"type,omitempty"`
-	StartDate string `json:"start-date,omitempty"`
-	EndDate   string `json:"end-date,omitempty"`
+	Type      string    `json:"type,omitempty"`
+	StartDate Timestamp `json:"start-date,omitempty"`
+	EndDate   Timestamp `json:"end-date,omitempty"`
 }
 
 const (
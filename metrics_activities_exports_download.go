@@ -0,0 +1,246 @@
+package chartmogul
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v3"
+	"github.com/pkg/errors"
+)
+
+// Statuses of MetricsActivitiesExport.
+const (
+	ActivitiesExportPending   = "pending"
+	ActivitiesExportSucceeded = "succeeded"
+	ActivitiesExportFailed    = "failed"
+	ActivitiesExportExpired   = "expired"
+)
+
+var (
+	// activitiesExportPollInterval is the initial interval of polling for the export status.
+	activitiesExportPollInterval = 2 * time.Second
+	// activitiesExportMaxWait is how long ExportActivities waits for the export at most.
+	activitiesExportMaxWait = 30 * time.Minute
+)
+
+// ExportActivities creates an activities export, waits until it's ready and downloads it.
+// The status is polled with exponential back-off until the export succeeds, fails or expires,
+// for 30 minutes at most; use the context for a shorter deadline or to cancel waiting.
+//
+// The returned reader streams the activities from the downloaded file, it must be closed.
+//
+// See https://dev.chartmogul.com/v1.0/reference#activities_export
+func (api API) ExportActivities(ctx context.Context, params *CreateMetricsActivitiesExportParam) (*MetricsActivitiesReader, error) {
+	export, err := api.MetricsCreateActivitiesExport(params)
+	if err != nil {
+		return nil, err
+	}
+	if export, err = api.waitForActivitiesExport(ctx, export); err != nil {
+		return nil, err
+	}
+	return api.downloadActivitiesExport(ctx, export.FileURL)
+}
+
+func (api API) waitForActivitiesExport(ctx context.Context, export *MetricsActivitiesExport) (*MetricsActivitiesExport, error) {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = activitiesExportPollInterval
+	b.MaxElapsedTime = activitiesExportMaxWait
+
+	polled, pending := false, false
+	err := backoff.Retry(func() error {
+		if polled {
+			current, err := api.MetricsRetrieveActivitiesExport(export.ID)
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			export = current
+		}
+		polled, pending = true, false
+
+		switch export.Status {
+		case ActivitiesExportSucceeded:
+			if expiresAt, err := export.ExpiresAt.Time(); err == nil && expiresAt.Before(time.Now()) {
+				return backoff.Permanent(fmt.Errorf("chartmogul: activities export %v expired at %v", export.ID, export.ExpiresAt))
+			}
+			if export.FileURL == "" {
+				return backoff.Permanent(fmt.Errorf("chartmogul: activities export %v has no file", export.ID))
+			}
+			return nil
+		case ActivitiesExportFailed, ActivitiesExportExpired:
+			return backoff.Permanent(fmt.Errorf("chartmogul: activities export %v %v", export.ID, export.Status))
+		}
+		pending = true
+		return fmt.Errorf("chartmogul: activities export %v is still %v", export.ID, export.Status)
+	}, backoff.WithContext(b, ctx))
+	if err != nil && ctx.Err() != nil {
+		return export, ctx.Err()
+	}
+	if _, ok := ctx.Deadline(); ok && err != nil && pending {
+		// back-off gives up early when the next poll would be past the deadline
+		return export, context.DeadlineExceeded
+	}
+	return export, err
+}
+
+func (api API) downloadActivitiesExport(ctx context.Context, fileURL string) (*MetricsActivitiesReader, error) {
+	req, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := api.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(&requestErrors{[]error{err}}, "Request error")
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close() //nolint
+		return nil, errors.Wrap(&httpError{statusCode: res.StatusCode, status: res.Status, response: string(body)}, "API error")
+	}
+
+	body := bufio.NewReader(res.Body)
+	if magic, _ := body.Peek(4); !bytes.Equal(magic, []byte("PK\x03\x04")) {
+		return newMetricsActivitiesReader(body, res.Body)
+	}
+
+	// Zip archives need random access, so the download is kept in a temporary file.
+	file, err := ioutil.TempFile("", "chartmogul-activities-*.zip")
+	if err != nil {
+		res.Body.Close() //nolint
+		return nil, err
+	}
+	closeAll := func() {
+		res.Body.Close()       //nolint
+		file.Close()           //nolint
+		os.Remove(file.Name()) //nolint
+	}
+	size, err := io.Copy(file, body)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	res.Body.Close() //nolint
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	for _, entry := range archive.File {
+		if !strings.HasSuffix(strings.ToLower(entry.Name), ".csv") {
+			continue
+		}
+		csvFile, err := entry.Open()
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		return newMetricsActivitiesReader(csvFile, csvFile, file, removeFile(file.Name()))
+	}
+	closeAll()
+	return nil, fmt.Errorf("chartmogul: no CSV file in activities export")
+}
+
+type removeFile string
+
+func (f removeFile) Close() error {
+	return os.Remove(string(f))
+}
+
+// MetricsActivitiesReader streams activities from an activities export file, see ExportActivities.
+type MetricsActivitiesReader struct {
+	csv     *csv.Reader
+	columns []int
+	line    int
+	closers []io.Closer
+}
+
+// activityColumns maps CSV headers to indexes of MetricsActivity fields by their JSON names.
+var activityColumns = func() map[string]int {
+	columns := map[string]int{}
+	t := reflect.TypeOf(MetricsActivity{})
+	for i := 0; i < t.NumField(); i++ {
+		columns[strings.Split(t.Field(i).Tag.Get("json"), ",")[0]] = i
+	}
+	return columns
+}()
+
+func newMetricsActivitiesReader(r io.Reader, closers ...io.Closer) (*MetricsActivitiesReader, error) {
+	reader := &MetricsActivitiesReader{csv: csv.NewReader(r), closers: closers}
+	reader.csv.ReuseRecord = true
+	header, err := reader.csv.Read()
+	if err != nil {
+		reader.Close() //nolint
+		if err == io.EOF {
+			err = fmt.Errorf("chartmogul: empty activities export")
+		}
+		return nil, err
+	}
+	reader.columns = make([]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		name = strings.NewReplacer(" ", "-", "_", "-").Replace(name)
+		field, ok := activityColumns[name]
+		if !ok {
+			field = -1
+		}
+		reader.columns[i] = field
+	}
+	return reader, nil
+}
+
+// Read returns the next activity, or io.EOF after the last one.
+func (r *MetricsActivitiesReader) Read() (*MetricsActivity, error) {
+	record, err := r.csv.Read()
+	if err != nil {
+		return nil, err
+	}
+	r.line++
+	activity := &MetricsActivity{}
+	value := reflect.ValueOf(activity).Elem()
+	for i, cell := range record {
+		if i >= len(r.columns) || r.columns[i] < 0 {
+			continue
+		}
+		field := value.Field(r.columns[i])
+		switch field.Kind() {
+		case reflect.Float64:
+			if cell == "" {
+				continue
+			}
+			f, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				return nil, fmt.Errorf("chartmogul: activities export record %d: invalid number %q", r.line, cell)
+			}
+			field.SetFloat(f)
+		case reflect.String:
+			field.SetString(cell)
+		}
+	}
+	return activity, nil
+}
+
+// Close releases the downloaded file.
+func (r *MetricsActivitiesReader) Close() error {
+	var first error
+	for _, closer := range r.closers {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
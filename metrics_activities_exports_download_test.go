@@ -0,0 +1,148 @@
+package chartmogul
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+const activitiesExportCSV = `date,activity_arr,activity_mrr,activity_mrr_movement,currency,description,type,subscription_external_id,plan_external_id,customer_name,customer_uuid,customer_external_id,billing_connector_uuid,uuid
+2020-05-06T01:00:00,72000,6000,6000,USD,purchased the plan_11 plan,new_biz,sub_2,11,customer_2,8bc55ab6-c3b5-11eb-ac45-2f9a49d75af7,customer_2,99076cb8-97a1-11eb-8798-a73b507e7929,f1a49735-21c7-4e3f-9ddc-67927aaadcf4
+2020-06-06T01:00:00,36000,3000,-3000,USD,downgraded to plan_12,contraction,sub_2,12,customer_2,8bc55ab6-c3b5-11eb-ac45-2f9a49d75af7,customer_2,99076cb8-97a1-11eb-8798-a73b507e7929,a3d2a2a1-0c5e-4f0b-8ad0-8e2c8f1b2a11
+`
+
+func zipped(t *testing.T, name, content string) []byte {
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+	w, err := archive.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, content) //nolint
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// activitiesExportServer stands in for both the API and the file host.
+// The export is pending for the first polls, then ends with the given status.
+func activitiesExportServer(t *testing.T, status string, file []byte) *httptest.Server {
+	polls := 0
+	var server *httptest.Server
+	server = httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "POST" && r.RequestURI == "/v/activities_export":
+					w.Write([]byte(`{"id": "7f554dba", "status": "pending", "file_url": null, "expires_at": null}`)) //nolint
+				case r.Method == "GET" && r.RequestURI == "/v/activities_export/7f554dba":
+					if polls++; polls < 3 {
+						w.Write([]byte(`{"id": "7f554dba", "status": "pending", "file_url": null, "expires_at": null}`)) //nolint
+						return
+					}
+					fmt.Fprintf(w, `{"id": "7f554dba", "status": %q, "file_url": "%v/files/activities.zip", "expires_at": %q}`,
+						status, server.URL, time.Now().Add(time.Hour).Format(time.RFC3339))
+				case r.Method == "GET" && r.RequestURI == "/files/activities.zip":
+					if r.Header.Get("Authorization") != "" {
+						t.Error("API credentials must not be sent to the file host")
+					}
+					w.Write(file) //nolint
+				default:
+					t.Errorf("Unexpected request %v %v", r.Method, r.RequestURI)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+	return server
+}
+
+func TestExportActivities(t *testing.T) {
+	defer func(interval time.Duration) { activitiesExportPollInterval = interval }(activitiesExportPollInterval)
+	activitiesExportPollInterval = time.Millisecond
+
+	for _, file := range [][]byte{zipped(t, "activities.csv", activitiesExportCSV), []byte(activitiesExportCSV)} {
+		server := activitiesExportServer(t, ActivitiesExportSucceeded, file)
+		SetURL(server.URL + "/v/%v")
+
+		var tested IApi = &API{
+			ApiKey: "token",
+		}
+		reader, err := tested.ExportActivities(context.Background(), &CreateMetricsActivitiesExportParam{StartDate: "2020-01-01", EndDate: "2020-12-31"})
+		if err != nil {
+			spew.Dump(err)
+			t.Fatal("Not expected to fail")
+		}
+
+		var activities []*MetricsActivity
+		for {
+			activity, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			activities = append(activities, activity)
+		}
+		if err := reader.Close(); err != nil {
+			t.Fatal(err)
+		}
+		server.Close()
+
+		expected := MetricsActivity{
+			Date:                   "2020-06-06T01:00:00",
+			ActivityArr:            36000,
+			ActivityMrr:            3000,
+			ActivityMrrMovement:    -3000,
+			Currency:               "USD",
+			Description:            "downgraded to plan_12",
+			Type:                   "contraction",
+			SubscriptionExternalID: "sub_2",
+			PlanExternalID:         "12",
+			CustomerName:           "customer_2",
+			CustomerUUID:           "8bc55ab6-c3b5-11eb-ac45-2f9a49d75af7",
+			CustomerExternalID:     "customer_2",
+			BillingConnectorUUID:   "99076cb8-97a1-11eb-8798-a73b507e7929",
+			UUID:                   "a3d2a2a1-0c5e-4f0b-8ad0-8e2c8f1b2a11",
+		}
+		if len(activities) != 2 || *activities[1] != expected {
+			spew.Dump(activities)
+			t.Fatal("Unexpected result")
+		}
+	}
+}
+
+func TestExportActivitiesFailed(t *testing.T) {
+	defer func(interval time.Duration) { activitiesExportPollInterval = interval }(activitiesExportPollInterval)
+	activitiesExportPollInterval = time.Millisecond
+
+	server := activitiesExportServer(t, ActivitiesExportFailed, nil)
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	_, err := (&API{ApiKey: "token"}).ExportActivities(context.Background(), &CreateMetricsActivitiesExportParam{})
+	if err == nil {
+		t.Fatal("Expected the failed export to fail")
+	}
+}
+
+func TestExportActivitiesCancelled(t *testing.T) {
+	server := activitiesExportServer(t, ActivitiesExportSucceeded, nil)
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := (&API{ApiKey: "token"}).ExportActivities(ctx, &CreateMetricsActivitiesExportParam{})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected deadline to be exceeded, got %v", err)
+	}
+}
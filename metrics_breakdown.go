@@ -0,0 +1,280 @@
+package chartmogul
+
+import (
+	"sort"
+	"strings"
+)
+
+// OtherGroup is the group of a breakdown left over from the unfiltered total,
+// eg. plans not in any plan group.
+const OtherGroup = "other"
+
+// MetricsBreakdown are all key metrics by group, eg. plan group or region, aligned by date.
+type MetricsBreakdown struct {
+	// Groups are the names of the groups, OtherGroup last.
+	Groups []string `json:"groups"`
+	// Dates are the dates of the unfiltered total.
+	Dates []Date `json:"dates"`
+	// Entries are a series per group with an entry per date, zero where the API returned none.
+	//
+	// The entries of OtherGroup are the remainder of the total: MRR, ARR and customers are subtracted,
+	// ARPA follows from those. Groups overlapping, eg. a plan in two plan groups, are counted twice
+	// and make the remainder too low.
+	Entries [][]*AllMetrics `json:"entries"`
+	// Total is the unfiltered series.
+	Total []*AllMetrics `json:"total"`
+	// Missing are the metrics of OtherGroup that aren't additive and can't be computed,
+	// by JSON name, eg. "ltv". Their fields are zero.
+	Missing []string `json:"missing"`
+}
+
+// otherMissing are the metrics of OtherGroup left zero, see MetricsBreakdown.Missing.
+var otherMissing = []string{
+	"customer-churn-rate", "mrr-churn-rate", "ltv", "asp",
+	"customer-churn-rate-percentage-change", "mrr-churn-rate-percentage-change", "ltv-percentage-change",
+	"customers-percentage-change", "asp-percentage-change", "arpa-percentage-change",
+	"arr-percentage-change", "mrr-percentage-change",
+}
+
+// Values returns the matrix of a metric with a row per date and a column per group,
+// eg. b.Values(func(m *AllMetrics) float64 { return m.Mrr }).
+func (b *MetricsBreakdown) Values(metric func(*AllMetrics) float64) [][]float64 {
+	values := make([][]float64, len(b.Dates))
+	for d := range b.Dates {
+		values[d] = make([]float64, len(b.Groups))
+		for g := range b.Groups {
+			values[d][g] = metric(b.Entries[g][d])
+		}
+	}
+	return values
+}
+
+// Shares returns the matrix of each group's share of the total in percents, with a row per date
+// and a column per group, eg. b.Shares(func(m *AllMetrics) float64 { return m.Mrr }).
+// Shares are zero where the total is zero; only additive metrics like MRR, ARR and customers add up to 100.
+func (b *MetricsBreakdown) Shares(metric func(*AllMetrics) float64) [][]float64 {
+	shares := b.Values(metric)
+	for d, row := range shares {
+		total := metric(b.Total[d])
+		for g := range row {
+			if total == 0 {
+				row[g] = 0
+				continue
+			}
+			row[g] = row[g] / total * 100
+		}
+	}
+	return shares
+}
+
+// Series returns the entries of a group, nil for an unknown group.
+func (b *MetricsBreakdown) Series(group string) []*AllMetrics {
+	for g, name := range b.Groups {
+		if name == group {
+			return b.Entries[g]
+		}
+	}
+	return nil
+}
+
+// MetricsBreakdownByPlans retrieves all key metrics of each group of plans, by plan external IDs,
+// next to the unfiltered total and the remainder as OtherGroup. Groups are sorted by name.
+// The filter's Plans are replaced by those of the groups, the total isn't filtered by plans.
+func (api API) MetricsBreakdownByPlans(metricsFilter *MetricsFilter, groups map[string][]string) (*MetricsBreakdown, error) {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	filters := make([]*MetricsFilter, len(names))
+	for i, name := range names {
+		filters[i] = withPlans(metricsFilter, groups[name])
+	}
+	total := copyFilter(metricsFilter)
+	total.Plans = ""
+	return api.metricsBreakdown(total, names, filters)
+}
+
+// MetricsBreakdownByPlanGroup retrieves all key metrics of each plan group, in the order of ListPlanGroups,
+// next to the unfiltered total and the remainder of plans not in any group as OtherGroup.
+// The filter's Plans are replaced by those of the groups, the total isn't filtered by plans.
+func (api API) MetricsBreakdownByPlanGroup(metricsFilter *MetricsFilter) (*MetricsBreakdown, error) {
+	var groups []*PlanGroup
+	cursor := &Cursor{}
+	for {
+		result, err := api.ListPlanGroups(cursor)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, result.PlanGroups...)
+		if !result.HasMore || result.Cursor == "" {
+			break
+		}
+		cursor.Cursor = result.Cursor
+	}
+
+	names := make([]string, len(groups))
+	plans := make([][]string, len(groups))
+	errs := parallel(len(groups), func(i int) error {
+		names[i] = groups[i].Name
+		cursor := &Cursor{}
+		for {
+			result, err := api.ListPlanGroupPlans(cursor, groups[i].UUID)
+			if err != nil {
+				return err
+			}
+			for _, plan := range result.Plans {
+				plans[i] = append(plans[i], plan.ExternalID)
+			}
+			if !result.HasMore || result.Cursor == "" {
+				return nil
+			}
+			cursor.Cursor = result.Cursor
+		}
+	})
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+
+	filters := make([]*MetricsFilter, len(groups))
+	for i := range groups {
+		filters[i] = withPlans(metricsFilter, plans[i])
+	}
+	total := copyFilter(metricsFilter)
+	total.Plans = ""
+	return api.metricsBreakdown(total, names, filters)
+}
+
+// MetricsBreakdownByGeo retrieves all key metrics of each country, by ISO 3166-1 alpha-2 code in the given order,
+// next to the unfiltered total and the remainder of other countries as OtherGroup.
+// The filter's Geo is replaced by the country, the total isn't filtered by country.
+func (api API) MetricsBreakdownByGeo(metricsFilter *MetricsFilter, countries []string) (*MetricsBreakdown, error) {
+	names := make([]string, len(countries))
+	filters := make([]*MetricsFilter, len(countries))
+	for i, country := range countries {
+		names[i] = strings.ToUpper(strings.TrimSpace(country))
+		filters[i] = withGeo(metricsFilter, []string{names[i]})
+	}
+	total := copyFilter(metricsFilter)
+	total.Geo = ""
+	return api.metricsBreakdown(total, names, filters)
+}
+
+// MetricsBreakdownByRegion retrieves all key metrics of each region, eg. "EMEA" or "APAC",
+// by the country codes it's made of, next to the unfiltered total and the remainder as OtherGroup.
+// Regions are sorted by name. The filter's Geo is replaced by the countries of the regions,
+// the total isn't filtered by country.
+func (api API) MetricsBreakdownByRegion(metricsFilter *MetricsFilter, regions map[string][]string) (*MetricsBreakdown, error) {
+	names := make([]string, 0, len(regions))
+	for name := range regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	filters := make([]*MetricsFilter, len(names))
+	for i, name := range names {
+		filters[i] = withGeo(metricsFilter, regions[name])
+	}
+	total := copyFilter(metricsFilter)
+	total.Geo = ""
+	return api.metricsBreakdown(total, names, filters)
+}
+
+// copyFilter returns a copy of the filter, empty for nil.
+func copyFilter(metricsFilter *MetricsFilter) *MetricsFilter {
+	f := &MetricsFilter{}
+	if metricsFilter != nil {
+		*f = *metricsFilter
+	}
+	return f
+}
+
+// withGeo returns a copy of the filter for the countries, nil for no countries.
+func withGeo(metricsFilter *MetricsFilter, countries []string) *MetricsFilter {
+	var codes []string
+	for _, country := range countries {
+		if code := strings.ToUpper(strings.TrimSpace(country)); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	f := copyFilter(metricsFilter)
+	f.Geo = strings.Join(codes, ",")
+	return f
+}
+
+// withPlans returns a copy of the filter for the plans, nil for no plans.
+func withPlans(metricsFilter *MetricsFilter, plans []string) *MetricsFilter {
+	if len(plans) == 0 {
+		return nil
+	}
+	f := copyFilter(metricsFilter)
+	f.Plans = strings.Join(plans, ",")
+	return f
+}
+
+// metricsBreakdown retrieves the total of the filter and the groups in parallel and aligns them by the dates of the total.
+// Groups with a nil filter match nothing and have zero entries.
+func (api API) metricsBreakdown(totalFilter *MetricsFilter, names []string, filters []*MetricsFilter) (*MetricsBreakdown, error) {
+	results := make([]*MetricsResult, len(filters)+1)
+	errs := parallel(len(results), func(i int) error {
+		var err error
+		switch {
+		case i == len(filters):
+			results[i], err = api.MetricsRetrieveAll(totalFilter)
+		case filters[i] != nil:
+			results[i], err = api.MetricsRetrieveAll(filters[i])
+		default:
+			results[i] = &MetricsResult{}
+		}
+		return err
+	})
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+
+	total := results[len(filters)]
+	b := &MetricsBreakdown{
+		Groups:  append(append([]string{}, names...), OtherGroup),
+		Dates:   make([]Date, len(total.Entries)),
+		Entries: make([][]*AllMetrics, len(names)+1),
+		Total:   total.Entries,
+		Missing: append([]string{}, otherMissing...),
+	}
+	index := map[Date]int{}
+	for d, entry := range total.Entries {
+		b.Dates[d] = entry.Date
+		index[entry.Date] = d
+	}
+	for g := range b.Entries {
+		b.Entries[g] = make([]*AllMetrics, len(b.Dates))
+		for d, date := range b.Dates {
+			b.Entries[g][d] = &AllMetrics{Date: date}
+		}
+		if g < len(names) {
+			for _, entry := range results[g].Entries {
+				if d, ok := index[entry.Date]; ok {
+					b.Entries[g][d] = entry
+				}
+			}
+		}
+	}
+
+	other := b.Entries[len(names)]
+	for d, entry := range total.Entries {
+		rest := other[d]
+		rest.Mrr, rest.Arr = entry.Mrr, entry.Arr
+		customers := int64(entry.Customers)
+		for g := range names {
+			rest.Mrr -= b.Entries[g][d].Mrr
+			rest.Arr -= b.Entries[g][d].Arr
+			customers -= int64(b.Entries[g][d].Customers)
+		}
+		if customers > 0 {
+			rest.Customers = uint32(customers)
+			rest.Arpa = rest.Mrr / float64(customers)
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,172 @@
+package chartmogul
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// breakdownServer answers all key metrics by plans filter, plan groups and their plans.
+func breakdownServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v/plan_groups":
+					if r.URL.Query().Get("cursor") == "" {
+						w.Write([]byte(`{"plan_groups": [{"uuid": "plg_1", "name": "Pro"}], "has_more": true, "cursor": "c2"}`)) //nolint
+						return
+					}
+					w.Write([]byte(`{"plan_groups": [{"uuid": "plg_2", "name": "Basic"}, {"uuid": "plg_3", "name": "Empty"}], "has_more": false}`)) //nolint
+				case "/v/plan_groups/plg_1/plans":
+					w.Write([]byte(`{"plans": [{"external_id": "pro_monthly"}, {"external_id": "pro_yearly"}], "has_more": false}`)) //nolint
+				case "/v/plan_groups/plg_2/plans":
+					w.Write([]byte(`{"plans": [{"external_id": "basic"}], "has_more": false}`)) //nolint
+				case "/v/plan_groups/plg_3/plans":
+					w.Write([]byte(`{"plans": [], "has_more": false}`)) //nolint
+				case "/v/metrics/all":
+					switch r.URL.Query().Get("plans") {
+					case "":
+						w.Write([]byte(`{"entries": [{"date": "2022-01-31", "mrr": 1000, "arr": 12000, "customers": 10}, {"date": "2022-02-28", "mrr": 1200, "arr": 14400, "customers": 12}]}`)) //nolint
+					case "pro_monthly,pro_yearly":
+						w.Write([]byte(`{"entries": [{"date": "2022-01-31", "mrr": 600, "arr": 7200, "customers": 3, "ltv": 5000}, {"date": "2022-02-28", "mrr": 700, "arr": 8400, "customers": 4}]}`)) //nolint
+					case "basic":
+						w.Write([]byte(`{"entries": [{"date": "2022-02-28", "mrr": 300, "arr": 3600, "customers": 6}]}`)) //nolint
+					default:
+						t.Errorf("Unexpected plans %v", r.URL.Query().Get("plans"))
+						w.WriteHeader(http.StatusBadRequest)
+					}
+				default:
+					t.Errorf("Unexpected URI %v", r.RequestURI)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+}
+
+func TestMetricsBreakdownByPlanGroup(t *testing.T) {
+	server := breakdownServer(t)
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	var tested IApi = &API{ApiKey: "token"}
+	b, err := tested.MetricsBreakdownByPlanGroup(&MetricsFilter{StartDate: "2022-01-01", EndDate: "2022-02-28"})
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	if len(b.Groups) != 4 || b.Groups[0] != "Pro" || b.Groups[1] != "Basic" || b.Groups[3] != OtherGroup || len(b.Dates) != 2 {
+		spew.Dump(b)
+		t.Fatal("Unexpected groups")
+	}
+	mrr := b.Values(func(m *AllMetrics) float64 { return m.Mrr })
+	expected := [][]float64{{600, 0, 0, 400}, {700, 300, 0, 200}}
+	for d := range expected {
+		for g := range expected[d] {
+			if mrr[d][g] != expected[d][g] {
+				spew.Dump(mrr)
+				t.Fatal("Unexpected MRR matrix")
+			}
+		}
+	}
+	other := b.Series(OtherGroup)
+	if other[0].Customers != 7 || other[0].Arpa != 400.0/7 || other[0].Arr != 4800 || other[0].Ltv != 0 ||
+		other[1].Customers != 2 || b.Series("Pro")[0].Ltv != 5000 || b.Series("Enterprise") != nil {
+		spew.Dump(other)
+		t.Fatal("Unexpected remainder")
+	}
+
+	// the missing metrics are the zero fields of the remainder, the others are computed
+	data, err := json.Marshal(other[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	missing := map[string]bool{}
+	for _, name := range b.Missing {
+		missing[name] = true
+		if value, ok := fields[name]; !ok || value != 0.0 {
+			t.Fatalf("Expected %v to be a zero field of AllMetrics", name)
+		}
+	}
+	for _, name := range []string{"date", "mrr", "arr", "customers", "arpa"} {
+		if missing[name] {
+			t.Fatalf("Expected %v not to be missing", name)
+		}
+	}
+	if len(fields) != len(b.Missing)+5 {
+		spew.Dump(b.Missing)
+		t.Fatal("Expected the other metrics to be missing")
+	}
+}
+
+func TestMetricsBreakdownByPlans(t *testing.T) {
+	server := breakdownServer(t)
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	b, err := (&API{ApiKey: "token"}).MetricsBreakdownByPlans(nil, map[string][]string{
+		"self-serve": {"basic"},
+		"sales":      {"pro_monthly", "pro_yearly"},
+	})
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	if len(b.Groups) != 3 || b.Groups[0] != "sales" || b.Series("self-serve")[1].Mrr != 300 || b.Series(OtherGroup)[1].Mrr != 200 {
+		spew.Dump(b)
+		t.Fatal("Unexpected breakdown")
+	}
+}
+
+func TestMetricsBreakdownByRegion(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v/metrics/all" {
+					t.Errorf("Unexpected URI %v", r.RequestURI)
+				}
+				switch r.URL.Query().Get("geo") {
+				case "":
+					w.Write([]byte(`{"entries": [{"date": "2022-01-31", "mrr": 1000, "customers": 10}, {"date": "2022-02-28", "mrr": 0}]}`)) //nolint
+				case "GB,DE":
+					w.Write([]byte(`{"entries": [{"date": "2022-01-31", "mrr": 500, "customers": 5}]}`)) //nolint
+				case "JP":
+					w.Write([]byte(`{"entries": [{"date": "2022-01-31", "mrr": 200, "customers": 1}]}`)) //nolint
+				case "US":
+					w.Write([]byte(`{"entries": [{"date": "2022-01-31", "mrr": 250, "customers": 4}]}`)) //nolint
+				default:
+					t.Errorf("Unexpected geo %v", r.URL.Query().Get("geo"))
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	var tested IApi = &API{ApiKey: "token"}
+	b, err := tested.MetricsBreakdownByRegion(&MetricsFilter{Geo: "FR"}, map[string][]string{"EMEA": {"gb", " DE"}, "APAC": {"JP"}})
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	shares := b.Shares(func(m *AllMetrics) float64 { return m.Mrr })
+	if len(b.Groups) != 3 || b.Groups[0] != "APAC" || shares[0][0] != 20 || shares[0][1] != 50 || shares[0][2] != 30 || shares[1][1] != 0 {
+		spew.Dump(b.Groups, shares)
+		t.Fatal("Unexpected region shares")
+	}
+
+	b, err = tested.MetricsBreakdownByGeo(nil, []string{"us", ""})
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	if b.Groups[0] != "US" || b.Series("US")[0].Mrr != 250 || b.Series("")[0].Mrr != 0 || b.Series(OtherGroup)[0].Mrr != 750 {
+		spew.Dump(b)
+		t.Fatal("Unexpected country breakdown")
+	}
+}
@@ -0,0 +1,259 @@
+package chartmogul
+
+import (
+	"sync"
+	"time"
+)
+
+// metricsMaxPeriods is the number of entries requested at once, per interval.
+// Longer date ranges are split into chunks fetched in parallel and stitched together,
+// so that responses stay small enough for the server to answer in time.
+var metricsMaxPeriods = map[Interval]int{
+	IntervalDay:     365,
+	IntervalWeek:    156,
+	IntervalMonth:   120,
+	IntervalQuarter: 40,
+}
+
+// metricsSeries is implemented by all metrics results, so that chunks can be stitched.
+type metricsSeries interface {
+	empty() metricsSeries
+	entryCount() int
+	entryDate(i int) Date
+	// appendEntry appends i-th entry of the other series of the same type.
+	appendEntry(other metricsSeries, i int)
+	// summarize recomputes the summary of the stitched entries as the value at the end of the range
+	// compared with the value at its start, for amounts, averages and rates alike.
+	summarize()
+}
+
+// metricsChunks splits the filter into chunks aligned to interval periods,
+// or returns nil if the range doesn't need splitting.
+func (api API) metricsChunks(metricsFilter *MetricsFilter) []MetricsFilter {
+	if metricsFilter == nil || metricsFilter.StartDate == "" || metricsFilter.EndDate == "" {
+		return nil
+	}
+	interval := metricsFilter.Interval
+	if interval == "" {
+		interval = IntervalMonth
+	}
+	maxPeriods := metricsMaxPeriods[interval]
+	start, err := metricsFilter.StartDate.Time()
+	if err != nil || maxPeriods == 0 {
+		return nil
+	}
+	end, err := metricsFilter.EndDate.Time()
+	if err != nil || !interval.AddTo(interval.PeriodStart(start, time.Monday), maxPeriods).Before(end) {
+		return nil
+	}
+
+	weekStart := time.Monday
+	if interval == IntervalWeek {
+		// weekly entries follow the account's week start
+		weekStart = api.weekStart()
+	}
+
+	var chunks []MetricsFilter
+	for from := start; !from.After(end); {
+		to := interval.PeriodEnd(interval.AddTo(from, maxPeriods-1), weekStart)
+		if to.After(end) {
+			to = end
+		}
+		chunk := *metricsFilter
+		chunk.StartDate, chunk.EndDate = NewDate(from), NewDate(to)
+		chunks = append(chunks, chunk)
+		from = to.AddDate(0, 0, 1)
+	}
+	return chunks
+}
+
+// weekStarts caches the week start of accounts by API key, so it's retrieved once per process.
+var weekStarts sync.Map
+
+// weekStart returns the week start of the account, Monday if it can't be retrieved.
+func (api API) weekStart() time.Weekday {
+	if weekStart, ok := weekStarts.Load(api.ApiKey); ok {
+		return weekStart.(time.Weekday)
+	}
+	account, err := api.RetrieveAccount()
+	if err != nil {
+		// not cached, so it's retried with the next weekly range
+		return time.Monday
+	}
+	weekStarts.Store(api.ApiKey, account.WeekStart())
+	return account.WeekStart()
+}
+
+// retrieveChunkedMetrics fetches the chunks in parallel and stitches them into output.
+// Entries are kept in order of dates, boundary dates returned by two chunks are kept once.
+func (api API) retrieveChunkedMetrics(path string, chunks []MetricsFilter, output metricsSeries) error {
+	results := make([]metricsSeries, len(chunks))
+	errs := parallel(len(chunks), func(i int) error {
+		results[i] = output.empty()
+		return api.list(path, results[i], chunks[i])
+	})
+	if err := firstError(errs); err != nil {
+		return err
+	}
+
+	var last time.Time
+	for _, result := range results {
+		for i := 0; i < result.entryCount(); i++ {
+			date, err := result.entryDate(i).Time()
+			if err == nil && !last.IsZero() && !date.After(last) {
+				continue
+			}
+			if err == nil {
+				last = date
+			}
+			output.appendEntry(result, i)
+		}
+	}
+	output.summarize()
+	return nil
+}
+
+// summaryOf compares the value at the end of the range with the value at its start.
+func summaryOf(previous, current float64) *Summary {
+	return &Summary{
+		Current:          current,
+		Previous:         previous,
+		PercentageChange: PercentageChange(previous, current),
+	}
+}
+
+func (r *MetricsResult) empty() metricsSeries { return &MetricsResult{} }
+func (r *MetricsResult) entryCount() int      { return len(r.Entries) }
+func (r *MetricsResult) entryDate(i int) Date { return r.Entries[i].Date }
+func (r *MetricsResult) appendEntry(other metricsSeries, i int) {
+	r.Entries = append(r.Entries, other.(*MetricsResult).Entries[i])
+}
+func (r *MetricsResult) summarize() {
+	n := len(r.Entries)
+	if n == 0 {
+		return
+	}
+	first, last := r.Entries[0], r.Entries[n-1]
+	r.Summary = &AllSummary{
+		CurrentCustomerChurnRate:          last.CustomerChurnRate,
+		PreviousCustomerChurnRate:         first.CustomerChurnRate,
+		CustomerChurnRatePercentageChange: PercentageChange(first.CustomerChurnRate, last.CustomerChurnRate),
+		CurrentMrrChurnRate:               last.MrrChurnRate,
+		PreviousMrrChurnRate:              first.MrrChurnRate,
+		MrrChurnRatePercentageChange:      PercentageChange(first.MrrChurnRate, last.MrrChurnRate),
+		CurrentLtv:                        last.Ltv,
+		PreviousLtv:                       first.Ltv,
+		LtvPercentageChange:               PercentageChange(first.Ltv, last.Ltv),
+		CurrentCustomers:                  last.Customers,
+		PreviousCustomers:                 first.Customers,
+		CustomersPercentageChange:         PercentageChange(float64(first.Customers), float64(last.Customers)),
+		CurrentAsp:                        last.Asp,
+		PreviousAsp:                       first.Asp,
+		AspPercentageChange:               PercentageChange(first.Asp, last.Asp),
+		CurrentArpa:                       last.Arpa,
+		PreviousArpa:                      first.Arpa,
+		ArpaPercentageChange:              PercentageChange(first.Arpa, last.Arpa),
+		CurrentArr:                        last.Arr,
+		PreviousArr:                       first.Arr,
+		ArrPercentageChange:               PercentageChange(first.Arr, last.Arr),
+		CurrentMrr:                        last.Mrr,
+		PreviousMrr:                       first.Mrr,
+		MrrPercentageChange:               PercentageChange(first.Mrr, last.Mrr),
+	}
+}
+
+func (r *MRRResult) empty() metricsSeries { return &MRRResult{} }
+func (r *MRRResult) entryCount() int      { return len(r.Entries) }
+func (r *MRRResult) entryDate(i int) Date { return r.Entries[i].Date }
+func (r *MRRResult) appendEntry(other metricsSeries, i int) {
+	r.Entries = append(r.Entries, other.(*MRRResult).Entries[i])
+}
+func (r *MRRResult) summarize() {
+	if n := len(r.Entries); n != 0 {
+		r.Summary = summaryOf(r.Entries[0].MRR, r.Entries[n-1].MRR)
+	}
+}
+
+func (r *ARRResult) empty() metricsSeries { return &ARRResult{} }
+func (r *ARRResult) entryCount() int      { return len(r.Entries) }
+func (r *ARRResult) entryDate(i int) Date { return r.Entries[i].Date }
+func (r *ARRResult) appendEntry(other metricsSeries, i int) {
+	r.Entries = append(r.Entries, other.(*ARRResult).Entries[i])
+}
+func (r *ARRResult) summarize() {
+	if n := len(r.Entries); n != 0 {
+		r.Summary = summaryOf(r.Entries[0].ARR, r.Entries[n-1].ARR)
+	}
+}
+
+func (r *ARPAResult) empty() metricsSeries { return &ARPAResult{} }
+func (r *ARPAResult) entryCount() int      { return len(r.Entries) }
+func (r *ARPAResult) entryDate(i int) Date { return r.Entries[i].Date }
+func (r *ARPAResult) appendEntry(other metricsSeries, i int) {
+	r.Entries = append(r.Entries, other.(*ARPAResult).Entries[i])
+}
+func (r *ARPAResult) summarize() {
+	if n := len(r.Entries); n != 0 {
+		r.Summary = summaryOf(r.Entries[0].ARPA, r.Entries[n-1].ARPA)
+	}
+}
+
+func (r *ASPResult) empty() metricsSeries { return &ASPResult{} }
+func (r *ASPResult) entryCount() int      { return len(r.Entries) }
+func (r *ASPResult) entryDate(i int) Date { return r.Entries[i].Date }
+func (r *ASPResult) appendEntry(other metricsSeries, i int) {
+	r.Entries = append(r.Entries, other.(*ASPResult).Entries[i])
+}
+func (r *ASPResult) summarize() {
+	if n := len(r.Entries); n != 0 {
+		r.Summary = summaryOf(r.Entries[0].ASP, r.Entries[n-1].ASP)
+	}
+}
+
+func (r *CustomerCountResult) empty() metricsSeries { return &CustomerCountResult{} }
+func (r *CustomerCountResult) entryCount() int      { return len(r.Entries) }
+func (r *CustomerCountResult) entryDate(i int) Date { return r.Entries[i].Date }
+func (r *CustomerCountResult) appendEntry(other metricsSeries, i int) {
+	r.Entries = append(r.Entries, other.(*CustomerCountResult).Entries[i])
+}
+func (r *CustomerCountResult) summarize() {
+	if n := len(r.Entries); n != 0 {
+		r.Summary = summaryOf(float64(r.Entries[0].Customers), float64(r.Entries[n-1].Customers))
+	}
+}
+
+func (r *CustomerChurnRateResult) empty() metricsSeries { return &CustomerChurnRateResult{} }
+func (r *CustomerChurnRateResult) entryCount() int      { return len(r.Entries) }
+func (r *CustomerChurnRateResult) entryDate(i int) Date { return r.Entries[i].Date }
+func (r *CustomerChurnRateResult) appendEntry(other metricsSeries, i int) {
+	r.Entries = append(r.Entries, other.(*CustomerChurnRateResult).Entries[i])
+}
+func (r *CustomerChurnRateResult) summarize() {
+	if n := len(r.Entries); n != 0 {
+		r.Summary = summaryOf(r.Entries[0].CustomerChurnRate, r.Entries[n-1].CustomerChurnRate)
+	}
+}
+
+func (r *MRRChurnRateResult) empty() metricsSeries { return &MRRChurnRateResult{} }
+func (r *MRRChurnRateResult) entryCount() int      { return len(r.Entries) }
+func (r *MRRChurnRateResult) entryDate(i int) Date { return r.Entries[i].Date }
+func (r *MRRChurnRateResult) appendEntry(other metricsSeries, i int) {
+	r.Entries = append(r.Entries, other.(*MRRChurnRateResult).Entries[i])
+}
+func (r *MRRChurnRateResult) summarize() {
+	if n := len(r.Entries); n != 0 {
+		r.Summary = summaryOf(r.Entries[0].MRRChurnRate, r.Entries[n-1].MRRChurnRate)
+	}
+}
+
+func (r *LTVResult) empty() metricsSeries { return &LTVResult{} }
+func (r *LTVResult) entryCount() int      { return len(r.Entries) }
+func (r *LTVResult) entryDate(i int) Date { return r.Entries[i].Date }
+func (r *LTVResult) appendEntry(other metricsSeries, i int) {
+	r.Entries = append(r.Entries, other.(*LTVResult).Entries[i])
+}
+func (r *LTVResult) summarize() {
+	if n := len(r.Entries); n != 0 {
+		r.Summary = summaryOf(r.Entries[0].LTV, r.Entries[n-1].LTV)
+	}
+}
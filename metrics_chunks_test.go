@@ -0,0 +1,239 @@
+package chartmogul
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// dailyMRRServer answers MRR requests with one entry per day of the requested range, MRR growing by 100 a day.
+// It repeats the end date of each range to test stitching.
+func dailyMRRServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(requests, 1)
+				if r.URL.Path != "/v/metrics/mrr" {
+					t.Errorf("Unexpected URI %v", r.RequestURI)
+				}
+				start, _ := Date(r.URL.Query().Get("start-date")).Time()
+				end, _ := Date(r.URL.Query().Get("end-date")).Time()
+				result := &MRRResult{Summary: &Summary{Current: -1}}
+				for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+					mrr := float64(d.Sub(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))/(24*time.Hour)) * 100
+					result.Entries = append(result.Entries, &MRRMetrics{Date: NewDate(d), MRR: mrr})
+				}
+				result.Entries = append(result.Entries, result.Entries[len(result.Entries)-1])
+				json.NewEncoder(w).Encode(result) //nolint
+			}))
+}
+
+func TestMetricsRetrieveChunked(t *testing.T) {
+	defer func(limit int) { metricsMaxPeriods[IntervalDay] = limit }(metricsMaxPeriods[IntervalDay])
+	metricsMaxPeriods[IntervalDay] = 10
+
+	var requests int32
+	server := dailyMRRServer(t, &requests)
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	var tested IApi = &API{
+		ApiKey: "token",
+	}
+	result, err := tested.MetricsRetrieveMRR(&MetricsFilter{StartDate: "2020-01-01", EndDate: "2020-01-25", Interval: IntervalDay})
+	if err != nil {
+		spew.Dump(err)
+		t.Fatal("Not expected to fail")
+	}
+	if requests != 3 {
+		t.Fatalf("Expected 3 chunks, got %v requests", requests)
+	}
+	if len(result.Entries) != 25 {
+		spew.Dump(result)
+		t.Fatalf("Expected 25 entries, got %v", len(result.Entries))
+	}
+	for i, entry := range result.Entries {
+		if expected := NewDate(time.Date(2020, 1, 1+i, 0, 0, 0, 0, time.UTC)); entry.Date != expected {
+			t.Fatalf("Expected entry %v to be %v, got %v", i, expected, entry.Date)
+		}
+	}
+	if result.Summary.Current != 2400 || result.Summary.Previous != 0 || result.Summary.PercentageChange != 0 {
+		t.Fatalf("Unexpected summary %+v", result.Summary)
+	}
+
+	// short ranges are passed as they are
+	requests = 0
+	result, err = tested.MetricsRetrieveMRR(&MetricsFilter{StartDate: "2020-01-02", EndDate: "2020-01-05", Interval: IntervalDay})
+	if err != nil || requests != 1 || len(result.Entries) != 5 || result.Summary.Current != -1 {
+		spew.Dump(result)
+		t.Fatalf("Expected one unchanged response, got %v requests, %v", requests, err)
+	}
+}
+
+func TestMetricsChunksAlignToPeriods(t *testing.T) {
+	defer func(limit int) { metricsMaxPeriods[IntervalMonth] = limit }(metricsMaxPeriods[IntervalMonth])
+	metricsMaxPeriods[IntervalMonth] = 12
+
+	chunks := API{}.metricsChunks(&MetricsFilter{StartDate: "2019-03-15", EndDate: "2021-06-10", Interval: IntervalMonth, Geo: "US"})
+	expected := [][2]Date{{"2019-03-15", "2020-02-29"}, {"2020-03-01", "2021-02-28"}, {"2021-03-01", "2021-06-10"}}
+	if len(chunks) != len(expected) {
+		t.Fatalf("Unexpected chunks %+v", chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.StartDate != expected[i][0] || chunk.EndDate != expected[i][1] || chunk.Geo != "US" {
+			t.Errorf("Expected chunk %v to be %v, got %+v", i, expected[i], chunk)
+		}
+	}
+
+	if chunks := (API{}).metricsChunks(&MetricsFilter{StartDate: "2019-03-15", EndDate: "2019-12-31"}); chunks != nil {
+		t.Errorf("Expected no chunks, got %+v", chunks)
+	}
+}
+
+func TestMetricsRetrieveChunkedError(t *testing.T) {
+	defer func(limit int) { metricsMaxPeriods[IntervalDay] = limit }(metricsMaxPeriods[IntervalDay])
+	metricsMaxPeriods[IntervalDay] = 10
+
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("start-date") == "2020-01-11" {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					w.Write([]byte(`{"error": "nope"}`)) //nolint
+					return
+				}
+				w.Write([]byte(`{"entries": [], "summary": {}}`)) //nolint
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	_, err := (&API{ApiKey: "token"}).MetricsRetrieveARR(&MetricsFilter{StartDate: "2020-01-01", EndDate: "2020-01-25", Interval: IntervalDay})
+	if err == nil {
+		t.Fatal("Expected error of the failed chunk")
+	}
+}
+
+func TestMetricsRetrieveChunkedWeekly(t *testing.T) {
+	defer func(limit int) { metricsMaxPeriods[IntervalWeek] = limit }(metricsMaxPeriods[IntervalWeek])
+	metricsMaxPeriods[IntervalWeek] = 2
+
+	var lock sync.Mutex
+	accountRequests, starts := 0, map[string]bool{}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				lock.Lock()
+				defer lock.Unlock()
+				if r.URL.Path == "/v/account" {
+					accountRequests++
+					w.Write([]byte(`{"week_start_on": "sunday"}`)) //nolint
+					return
+				}
+				starts[r.URL.Query().Get("start-date")] = true
+				rate := "2.5"
+				if r.URL.Query().Get("end-date") == "2020-01-31" {
+					rate = "5"
+				}
+				w.Write([]byte(`{"entries": [{"date": "` + r.URL.Query().Get("end-date") + `", "customer-churn-rate": ` + rate + `}], "summary": {"current": 2.5}}`)) //nolint
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	api := &API{ApiKey: "weekly-token"}
+	for i := 0; i < 2; i++ {
+		result, err := api.MetricsRetrieveCustomerChurnRate(&MetricsFilter{StartDate: "2020-01-01", EndDate: "2020-01-31", Interval: IntervalWeek})
+		if err != nil || len(result.Entries) != 3 || result.Summary == nil ||
+			result.Summary.Current != 5 || result.Summary.Previous != 2.5 || result.Summary.PercentageChange != 100 {
+			spew.Dump(err, result)
+			t.Fatal("Expected stitched entries with the rate at the end of the range")
+		}
+	}
+	if accountRequests != 1 || !starts["2020-01-12"] || !starts["2020-01-26"] {
+		t.Fatalf("Expected chunks starting on Sundays and one account request, got %v %v", accountRequests, starts)
+	}
+}
+
+func TestMetricsRetrieveAllChunked(t *testing.T) {
+	defer func(limit int) { metricsMaxPeriods[IntervalMonth] = limit }(metricsMaxPeriods[IntervalMonth])
+	metricsMaxPeriods[IntervalMonth] = 2
+
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				start, _ := Date(r.URL.Query().Get("start-date")).Time()
+				end, _ := Date(r.URL.Query().Get("end-date")).Time()
+				result := &MetricsResult{}
+				for d := IntervalMonth.PeriodEnd(start, time.Monday); !d.After(end); d = IntervalMonth.PeriodEnd(d.AddDate(0, 0, 1), time.Monday) {
+					n := float64(d.Month())
+					result.Entries = append(result.Entries, &AllMetrics{Date: NewDate(d), Mrr: n * 100, Customers: uint32(n),
+						Arpa: n * 10, CustomerChurnRate: n})
+				}
+				json.NewEncoder(w).Encode(result) //nolint
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	result, err := (&API{ApiKey: "token"}).MetricsRetrieveAll(&MetricsFilter{StartDate: "2020-01-01", EndDate: "2020-04-30"})
+	if err != nil || len(result.Entries) != 4 || result.Summary == nil {
+		spew.Dump(err, result)
+		t.Fatal("Expected stitched entries with a summary")
+	}
+	if s := result.Summary; s.CurrentMrr != 400 || s.PreviousMrr != 100 || s.MrrPercentageChange != 300 ||
+		s.CurrentCustomers != 4 || s.PreviousCustomers != 1 || s.CurrentArpa != 40 ||
+		s.CurrentCustomerChurnRate != 4 || s.PreviousCustomerChurnRate != 1 || s.CurrentLtv != 0 {
+		spew.Dump(s)
+		t.Fatal("Unexpected summary")
+	}
+}
+
+func TestSetMaxConcurrency(t *testing.T) {
+	if requestSlots != nil {
+		t.Fatal("Expected no global limit by default")
+	}
+	defer SetMaxConcurrency(0)
+	SetMaxConcurrency(2)
+
+	var inFlight, maxInFlight int32
+	errs := parallel(10, func(i int) error {
+		release := acquireRequestSlot()
+		defer release()
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return nil
+	})
+	if firstError(errs) != nil || maxInFlight > 2 {
+		t.Fatalf("Expected at most 2 requests in flight, got %v", maxInFlight)
+	}
+}
+
+func TestParallelLimit(t *testing.T) {
+	var inFlight, maxInFlight int32
+	errs := parallel(3*maxParallel, func(i int) error {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return nil
+	})
+	if firstError(errs) != nil || maxInFlight > maxParallel {
+		t.Fatalf("Expected at most %v calls in parallel, got %v", maxParallel, maxInFlight)
+	}
+}
@@ -4,15 +4,15 @@ import "strings"
 
 // MetricsCustomerActivity represents Metrics API activity in ChartMogul.
 type MetricsCustomerActivity struct {
-	ID                  uint64  `json:"id"`
-	Date                string  `json:"date"`
-	ActivityArr         float64 `json:"activity-arr"`
-	ActivityMrr         float64 `json:"activity-mrr"`
-	ActivityMrrMovement float64 `json:"activity-mrr-movement"`
-	Currency            string  `json:"currency"`
-	CurrencySign        string  `json:"currency-sign"`
-	Description         string  `json:"description"`
-	Type                string  `json:"type"`
+	ID                  uint64    `json:"id"`
+	Date                Timestamp `json:"date"`
+	ActivityArr         float64   `json:"activity-arr"`
+	ActivityMrr         float64   `json:"activity-mrr"`
+	ActivityMrrMovement float64   `json:"activity-mrr-movement"`
+	Currency            string    `json:"currency"`
+	CurrencySign        string    `json:"currency-sign"`
+	Description         string    `json:"description"`
+	Type                string    `json:"type"`
 }
 
 // MetricsCustomerActivities is the result of listing activities in Metrics API.
@@ -4,19 +4,19 @@ import "strings"
 
 // MetricsCustomerSubscription represents Metrics API subscription in ChartMogul.
 type MetricsCustomerSubscription struct {
-	ID                uint64  `json:"id"`
-	ExternalID        string  `json:"external_id"`
-	Plan              string  `json:"plan"`
-	Quantity          uint32  `json:"quantity"`
-	BillingCycleCount uint32  `json:"billing-cycle-count"`
-	MRR               float64 `json:"mrr"`
-	ARR               float64 `json:"arr"`
-	Status            string  `json:"status"`
-	BillingCycle      string  `json:"billing-cycle"`
-	StartDate         string  `json:"start-date"`
-	EndDate           string  `json:"end-date"`
-	Currency          string  `json:"currency"`
-	CurrencySign      string  `json:"currency-sign"`
+	ID                uint64    `json:"id"`
+	ExternalID        string    `json:"external_id"`
+	Plan              string    `json:"plan"`
+	Quantity          uint32    `json:"quantity"`
+	BillingCycleCount uint32    `json:"billing-cycle-count"`
+	MRR               float64   `json:"mrr"`
+	ARR               float64   `json:"arr"`
+	Status            string    `json:"status"`
+	BillingCycle      string    `json:"billing-cycle"`
+	StartDate         Timestamp `json:"start-date"`
+	EndDate           Timestamp `json:"end-date"`
+	Currency          string    `json:"currency"`
+	CurrencySign      string    `json:"currency-sign"`
 }
 
 // MetricsCustomerSubscriptions is the result of listing subscriptions in Metrics API.
@@ -0,0 +1,196 @@
+package chartmogul
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DashboardMetrics joins the entries of all metric series for one date.
+type DashboardMetrics struct {
+	Date              Date    `json:"date"`
+	MRR               float64 `json:"mrr"`
+	MRRNewBusiness    float64 `json:"mrr-new-business"`
+	MRRExpansion      float64 `json:"mrr-expansion"`
+	MRRContraction    float64 `json:"mrr-contraction"`
+	MRRChurn          float64 `json:"mrr-churn"`
+	MRRReactivation   float64 `json:"mrr-reactivation"`
+	ARR               float64 `json:"arr"`
+	ARPA              float64 `json:"arpa"`
+	ASP               float64 `json:"asp"`
+	Customers         uint32  `json:"customers"`
+	CustomerChurnRate float64 `json:"customer-churn-rate"`
+	MRRChurnRate      float64 `json:"mrr-churn-rate"`
+	LTV               float64 `json:"ltv"`
+
+	// Percentage changes from the previous entry, of the key metrics series ("all").
+	MRRPercentageChange               float64 `json:"mrr-percentage-change"`
+	ARRPercentageChange               float64 `json:"arr-percentage-change"`
+	ARPAPercentageChange              float64 `json:"arpa-percentage-change"`
+	ASPPercentageChange               float64 `json:"asp-percentage-change"`
+	CustomersPercentageChange         float64 `json:"customers-percentage-change"`
+	CustomerChurnRatePercentageChange float64 `json:"customer-churn-rate-percentage-change"`
+	MRRChurnRatePercentageChange      float64 `json:"mrr-churn-rate-percentage-change"`
+	LTVPercentageChange               float64 `json:"ltv-percentage-change"`
+
+	// Missing are the series without an entry for the date, eg. because they failed,
+	// their fields are zero. See DashboardErrors for the series names.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// DashboardResult is the result of MetricsRetrieveDashboard.
+type DashboardResult struct {
+	Entries []*DashboardMetrics `json:"entries"`
+}
+
+// DashboardErrors are the errors of the series which failed to load, keyed by series name:
+// "all", "mrr", "arr", "arpa", "asp", "customer-count", "customer-churn-rate", "mrr-churn-rate" or "ltv".
+type DashboardErrors map[string]error
+
+func (e DashboardErrors) Error() string {
+	series := make([]string, 0, len(e))
+	for name, err := range e {
+		series = append(series, fmt.Sprintf("%v: %v", name, err))
+	}
+	sort.Strings(series)
+	return "chartmogul: dashboard series failed: " + strings.Join(series, "; ")
+}
+
+// MetricsRetrieveDashboard retrieves the key metrics with their percentage changes, MRR with its movements,
+// ARR, ARPA, ASP, customer count, churn rates and LTV in parallel and joins them by date.
+//
+// If some of the series fail, the entries are returned with the remaining series filled in,
+// together with DashboardErrors listing the failed ones. The failed series are Missing in every entry.
+func (api API) MetricsRetrieveDashboard(metricsFilter *MetricsFilter) (*DashboardResult, error) {
+	if err := metricsFilter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		all               *MetricsResult
+		mrr               *MRRResult
+		arr               *ARRResult
+		arpa              *ARPAResult
+		asp               *ASPResult
+		customerCount     *CustomerCountResult
+		customerChurnRate *CustomerChurnRateResult
+		mrrChurnRate      *MRRChurnRateResult
+		ltv               *LTVResult
+	)
+	series := []struct {
+		name  string
+		fetch func() error
+	}{
+		{"all", func() (err error) { all, err = api.MetricsRetrieveAll(metricsFilter); return }},
+		{"mrr", func() (err error) { mrr, err = api.MetricsRetrieveMRR(metricsFilter); return }},
+		{"arr", func() (err error) { arr, err = api.MetricsRetrieveARR(metricsFilter); return }},
+		{"arpa", func() (err error) { arpa, err = api.MetricsRetrieveARPA(metricsFilter); return }},
+		{"asp", func() (err error) { asp, err = api.MetricsRetrieveASP(metricsFilter); return }},
+		{"customer-count", func() (err error) { customerCount, err = api.MetricsRetrieveCustomerCount(metricsFilter); return }},
+		{"customer-churn-rate", func() (err error) {
+			customerChurnRate, err = api.MetricsRetrieveCustomerChurnRate(metricsFilter)
+			return
+		}},
+		{"mrr-churn-rate", func() (err error) { mrrChurnRate, err = api.MetricsRetrieveMRRChurnRate(metricsFilter); return }},
+		{"ltv", func() (err error) { ltv, err = api.MetricsRetrieveLTV(metricsFilter); return }},
+	}
+	errs := parallel(len(series), func(i int) error { return series[i].fetch() })
+
+	failed := DashboardErrors{}
+	for i, err := range errs {
+		if err != nil {
+			failed[series[i].name] = err
+		}
+	}
+
+	rows := map[Date]*DashboardMetrics{}
+	found := map[Date]map[string]bool{}
+	row := func(date Date, name string) *DashboardMetrics {
+		if rows[date] == nil {
+			rows[date] = &DashboardMetrics{Date: date}
+			found[date] = map[string]bool{}
+		}
+		found[date][name] = true
+		return rows[date]
+	}
+	if failed["all"] == nil {
+		for _, e := range all.Entries {
+			r := row(e.Date, "all")
+			r.MRRPercentageChange, r.ARRPercentageChange = e.MrrPercentageChange, e.ArrPercentageChange
+			r.ARPAPercentageChange, r.ASPPercentageChange = e.ArpaPercentageChange, e.AspPercentageChange
+			r.CustomersPercentageChange, r.LTVPercentageChange = e.CustomersPercentageChange, e.LtvPercentageChange
+			r.CustomerChurnRatePercentageChange = e.CustomerChurnRatePercentageChange
+			r.MRRChurnRatePercentageChange = e.MrrChurnRatePercentageChange
+		}
+	}
+	if failed["mrr"] == nil {
+		for _, e := range mrr.Entries {
+			r := row(e.Date, "mrr")
+			r.MRR, r.MRRNewBusiness, r.MRRExpansion = e.MRR, e.MRRNewBusiness, e.MRRExpansion
+			r.MRRContraction, r.MRRChurn, r.MRRReactivation = e.MRRContraction, e.MRRChurn, e.MRRReactivation
+		}
+	}
+	if failed["arr"] == nil {
+		for _, e := range arr.Entries {
+			row(e.Date, "arr").ARR = e.ARR
+		}
+	}
+	if failed["arpa"] == nil {
+		for _, e := range arpa.Entries {
+			row(e.Date, "arpa").ARPA = e.ARPA
+		}
+	}
+	if failed["asp"] == nil {
+		for _, e := range asp.Entries {
+			row(e.Date, "asp").ASP = e.ASP
+		}
+	}
+	if failed["customer-count"] == nil {
+		for _, e := range customerCount.Entries {
+			row(e.Date, "customer-count").Customers = e.Customers
+		}
+	}
+	if failed["customer-churn-rate"] == nil {
+		for _, e := range customerChurnRate.Entries {
+			row(e.Date, "customer-churn-rate").CustomerChurnRate = e.CustomerChurnRate
+		}
+	}
+	if failed["mrr-churn-rate"] == nil {
+		for _, e := range mrrChurnRate.Entries {
+			row(e.Date, "mrr-churn-rate").MRRChurnRate = e.MRRChurnRate
+		}
+	}
+	if failed["ltv"] == nil {
+		for _, e := range ltv.Entries {
+			row(e.Date, "ltv").LTV = e.LTV
+		}
+	}
+
+	result := &DashboardResult{Entries: make([]*DashboardMetrics, 0, len(rows))}
+	for date, r := range rows {
+		for _, s := range series {
+			if !found[date][s.name] {
+				r.Missing = append(r.Missing, s.name)
+			}
+		}
+		result.Entries = append(result.Entries, r)
+	}
+	sort.Slice(result.Entries, func(i, j int) bool {
+		return dateBefore(result.Entries[i].Date, result.Entries[j].Date)
+	})
+
+	if len(failed) != 0 {
+		return result, failed
+	}
+	return result, nil
+}
+
+// dateBefore orders dates chronologically, unparseable dates are ordered as strings.
+func dateBefore(a, b Date) bool {
+	ta, errA := a.Time()
+	tb, errB := b.Time()
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return ta.Before(tb)
+}
@@ -0,0 +1,87 @@
+package chartmogul
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestMetricsRetrieveDashboard(t *testing.T) {
+	responses := map[string]string{
+		// the first entry of the key metrics is missing
+		"/v/metrics/all": `{"entries": [{"date": "2022-05-31", "mrr": 2000, "mrr-percentage-change": 21.2}]}`,
+		"/v/metrics/mrr": `{"entries": [
+			{"date": "2022-05-31", "mrr": 2000, "mrr-new-business": 500, "mrr-expansion": 100, "mrr-contraction": -50, "mrr-churn": -200, "mrr-reactivation": 0},
+			{"date": "2022-04-30", "mrr": 1650, "mrr-new-business": 1650}]}`,
+		"/v/metrics/arr":                 `{"entries": [{"date": "2022-04-30", "arr": 19800}, {"date": "2022-05-31", "arr": 24000}]}`,
+		"/v/metrics/arpa":                `{"entries": [{"date": "2022-04-30", "arpa": 825}, {"date": "2022-05-31", "arpa": 1000}]}`,
+		"/v/metrics/asp":                 `{"entries": [{"date": "2022-04-30", "asp": 1650}, {"date": "2022-05-31", "asp": 500}]}`,
+		"/v/metrics/customer-count":      `{"entries": [{"date": "2022-04-30", "customers": 2}, {"date": "2022-05-31", "customers": 2}]}`,
+		"/v/metrics/customer-churn-rate": `{"entries": [{"date": "2022-04-30", "customer-churn-rate": 0}, {"date": "2022-05-31", "customer-churn-rate": 50}]}`,
+		"/v/metrics/mrr-churn-rate":      `{"entries": [{"date": "2022-04-30", "mrr-churn-rate": 0}, {"date": "2022-05-31", "mrr-churn-rate": 12.1}]}`,
+	}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.RawQuery != "end-date=2022-05-31&interval=month&start-date=2022-04-01" {
+					t.Errorf("Unexpected query %v", r.URL.RawQuery)
+				}
+				response, ok := responses[r.URL.Path]
+				if !ok {
+					// LTV fails
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error": "bad"}`)) //nolint
+					return
+				}
+				w.Write([]byte(response)) //nolint
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	var tested IApi = &API{
+		ApiKey: "token",
+	}
+	result, err := tested.MetricsRetrieveDashboard(&MetricsFilter{StartDate: "2022-04-01", EndDate: "2022-05-31", Interval: IntervalMonth})
+
+	failed, ok := err.(DashboardErrors)
+	if !ok || len(failed) != 1 || failed["ltv"] == nil {
+		spew.Dump(err)
+		t.Fatal("Expected the LTV series to fail")
+	}
+	if len(result.Entries) != 2 {
+		spew.Dump(result)
+		t.Fatal("Expected 2 joined entries")
+	}
+	expected := DashboardMetrics{
+		Date:              "2022-05-31",
+		MRR:               2000,
+		MRRNewBusiness:    500,
+		MRRExpansion:      100,
+		MRRContraction:    -50,
+		MRRChurn:          -200,
+		ARR:               24000,
+		ARPA:              1000,
+		ASP:               500,
+		Customers:         2,
+		CustomerChurnRate: 50,
+		MRRChurnRate:      12.1,
+
+		MRRPercentageChange: 21.2,
+		Missing:             []string{"ltv"},
+	}
+	if result.Entries[0].Date != "2022-04-30" || !reflect.DeepEqual(result.Entries[0].Missing, []string{"all", "ltv"}) ||
+		!reflect.DeepEqual(*result.Entries[1], expected) {
+		spew.Dump(result)
+		t.Fatal("Unexpected result")
+	}
+}
+
+func TestMetricsRetrieveDashboardInvalidFilter(t *testing.T) {
+	_, err := (&API{ApiKey: "token"}).MetricsRetrieveDashboard(&MetricsFilter{StartDate: "last year"})
+	if _, ok := err.(Errors); !ok {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+}
@@ -0,0 +1,310 @@
+package chartmogul
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Interval is the period of one entry in Metrics API results.
+type Interval string
+
+// Intervals supported by Metrics API.
+const (
+	IntervalDay     Interval = "day"
+	IntervalWeek    Interval = "week"
+	IntervalMonth   Interval = "month"
+	IntervalQuarter Interval = "quarter"
+	IntervalYear    Interval = "year"
+)
+
+// IsValid returns true for intervals supported by the API.
+func (i Interval) IsValid() bool {
+	switch i {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalQuarter, IntervalYear:
+		return true
+	}
+	return false
+}
+
+// PeriodStart returns midnight of the first day of the period containing t, in t's location.
+// Weeks start on weekStart, see Account.WeekStart.
+func (i Interval) PeriodStart(t time.Time, weekStart time.Weekday) time.Time {
+	y, m, d := t.Date()
+	switch i {
+	case IntervalWeek:
+		d -= (int(t.Weekday()) - int(weekStart) + 7) % 7
+	case IntervalMonth:
+		d = 1
+	case IntervalQuarter:
+		m, d = m-(m-1)%3, 1
+	case IntervalYear:
+		m, d = time.January, 1
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// PeriodEnd returns midnight of the last day of the period containing t, in t's location.
+// This is the date of the period's entry in Metrics API results.
+func (i Interval) PeriodEnd(t time.Time, weekStart time.Weekday) time.Time {
+	return i.AddTo(i.PeriodStart(t, weekStart), 1).AddDate(0, 0, -1)
+}
+
+// AddTo moves t by n intervals. Months keep the day of month where possible,
+// eg. January 31 + 1 month is the last day of February.
+func (i Interval) AddTo(t time.Time, n int) time.Time {
+	switch i {
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7*n)
+	case IntervalMonth:
+		return addMonths(t, n)
+	case IntervalQuarter:
+		return addMonths(t, 3*n)
+	case IntervalYear:
+		return addMonths(t, 12*n)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// WeekStart returns the first day of week of the account, Monday if not set.
+func (account *Account) WeekStart() time.Weekday {
+	if strings.EqualFold(account.WeekStartOn, "sunday") {
+		return time.Sunday
+	}
+	return time.Monday
+}
+
+// RelativeRange is a date range relative to today in the account's time zone, eg. "last 12 months".
+// Use LastPeriods, TrailingPeriods or PeriodToDate to create one.
+type RelativeRange struct {
+	unit    Interval
+	count   int
+	partial bool
+	toDate  bool
+}
+
+// LastPeriods is the range of n complete periods before the current one,
+// eg. LastPeriods(12, IntervalMonth) in mid-June is June 1st of the last year until May 31st.
+func LastPeriods(n int, unit Interval) RelativeRange {
+	return RelativeRange{unit: unit, count: n}
+}
+
+// TrailingPeriods is the range of n periods back from today, including today,
+// eg. TrailingPeriods(90, IntervalDay) are the trailing 90 days.
+func TrailingPeriods(n int, unit Interval) RelativeRange {
+	return RelativeRange{unit: unit, count: n, partial: true}
+}
+
+// PeriodToDate is the range from the start of the current period until today, eg. quarter to date.
+func PeriodToDate(unit Interval) RelativeRange {
+	return RelativeRange{unit: unit, toDate: true}
+}
+
+// LastMonths is the range of n complete months before the current month.
+func LastMonths(n int) RelativeRange { return LastPeriods(n, IntervalMonth) }
+
+// TrailingDays is the range of n days ending today.
+func TrailingDays(n int) RelativeRange { return TrailingPeriods(n, IntervalDay) }
+
+// WeekToDate is the range from the start of this week, according to Account.WeekStartOn, until today.
+func WeekToDate() RelativeRange { return PeriodToDate(IntervalWeek) }
+
+// MonthToDate is the range from the start of this month until today.
+func MonthToDate() RelativeRange { return PeriodToDate(IntervalMonth) }
+
+// QuarterToDate is the range from the start of this quarter until today.
+func QuarterToDate() RelativeRange { return PeriodToDate(IntervalQuarter) }
+
+// YearToDate is the range from the start of this year until today.
+func YearToDate() RelativeRange { return PeriodToDate(IntervalYear) }
+
+// Resolve returns the dates of the range as of now in the account's time zone and week start.
+// Account may be nil, in which case UTC and weeks starting on Monday are used.
+func (r RelativeRange) Resolve(now time.Time, account *Account) (start, end Date, err error) {
+	if account == nil {
+		account = &Account{}
+	}
+	if !r.unit.IsValid() {
+		return "", "", fmt.Errorf("chartmogul: invalid relative range unit %q", r.unit)
+	}
+	if !r.toDate && r.count < 1 {
+		return "", "", fmt.Errorf("chartmogul: relative range needs at least one %v", r.unit)
+	}
+	loc, err := account.Location()
+	if err != nil {
+		return "", "", err
+	}
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	weekStart := account.WeekStart()
+
+	var from, to time.Time
+	switch {
+	case r.toDate:
+		from, to = r.unit.PeriodStart(today, weekStart), today
+	case r.partial:
+		from, to = r.unit.AddTo(today, -r.count).AddDate(0, 0, 1), today
+	default:
+		to = r.unit.PeriodStart(today, weekStart).AddDate(0, 0, -1)
+		from = r.unit.AddTo(r.unit.PeriodStart(today, weekStart), -r.count)
+	}
+	return NewDate(from), NewDate(to), nil
+}
+
+func (r RelativeRange) String() string {
+	switch {
+	case r.toDate:
+		return fmt.Sprintf("%v to date", r.unit)
+	case r.partial:
+		return fmt.Sprintf("trailing %d %v", r.count, r.unit)
+	}
+	return fmt.Sprintf("last %d %v", r.count, r.unit)
+}
+
+// now is replaceable in tests.
+var now = time.Now
+
+// MetricsFilterBuilder builds a validated MetricsFilter, see NewMetricsFilter.
+type MetricsFilterBuilder struct {
+	filter   MetricsFilter
+	relative *RelativeRange
+	geo      []string
+	plans    []string
+}
+
+// NewMetricsFilter starts building a MetricsFilter, eg.
+//
+//	filter, err := cm.NewMetricsFilter().
+//		Relative(cm.LastMonths(12)).
+//		Interval(cm.IntervalMonth).
+//		Geo("US", "GB").
+//		Build(account)
+func NewMetricsFilter() *MetricsFilterBuilder {
+	return &MetricsFilterBuilder{}
+}
+
+// Between sets fixed start and end dates.
+func (b *MetricsFilterBuilder) Between(start, end Date) *MetricsFilterBuilder {
+	b.filter.StartDate, b.filter.EndDate, b.relative = start, end, nil
+	return b
+}
+
+// Relative sets a range resolved relative to today on Build.
+func (b *MetricsFilterBuilder) Relative(r RelativeRange) *MetricsFilterBuilder {
+	b.relative = &r
+	return b
+}
+
+// Interval sets the period of one entry.
+func (b *MetricsFilterBuilder) Interval(interval Interval) *MetricsFilterBuilder {
+	b.filter.Interval = interval
+	return b
+}
+
+// Geo adds countries (ISO 3166-1 alpha-2 codes) to filter by.
+func (b *MetricsFilterBuilder) Geo(countries ...string) *MetricsFilterBuilder {
+	for _, country := range countries {
+		b.geo = appendUnique(b.geo, strings.ToUpper(strings.TrimSpace(country)))
+	}
+	return b
+}
+
+// Plans adds plan external IDs to filter by.
+func (b *MetricsFilterBuilder) Plans(planExternalIDs ...string) *MetricsFilterBuilder {
+	for _, plan := range planExternalIDs {
+		b.plans = appendUnique(b.plans, strings.TrimSpace(plan))
+	}
+	return b
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// Build resolves the relative range in the account's time zone, if any, and validates the filter.
+// Account may be nil for fixed ranges or to resolve in UTC.
+func (b *MetricsFilterBuilder) Build(account *Account) (*MetricsFilter, error) {
+	filter := b.filter
+	if b.relative != nil {
+		var err error
+		filter.StartDate, filter.EndDate, err = b.relative.Resolve(now(), account)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, plan := range b.plans {
+		if strings.Contains(plan, ",") {
+			return nil, Errors{"plans": fmt.Sprintf("plan %q contains a comma and can't be filtered by", plan)}
+		}
+	}
+	filter.Geo = strings.Join(b.geo, ",")
+	filter.Plans = strings.Join(b.plans, ",")
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
+
+// Validate checks the filter before it's sent to the API,
+// problems are returned as Errors keyed by the query parameter.
+// Dates must be formatted as DateLayout, see Date.Normalized. A nil filter is valid.
+func (filter *MetricsFilter) Validate() error {
+	if filter == nil {
+		return nil
+	}
+	errs := Errors{}
+	// the API accepts only calendar dates, not the timestamps Date.Time parses too
+	start, startErr := time.Parse(DateLayout, string(filter.StartDate))
+	if filter.StartDate != "" && startErr != nil {
+		errs["start-date"] = fmt.Sprintf("invalid date %q, use %v", filter.StartDate, DateLayout)
+	}
+	end, endErr := time.Parse(DateLayout, string(filter.EndDate))
+	if filter.EndDate != "" && endErr != nil {
+		errs["end-date"] = fmt.Sprintf("invalid date %q, use %v", filter.EndDate, DateLayout)
+	}
+	if startErr == nil && endErr == nil && end.Before(start) {
+		errs["end-date"] = fmt.Sprintf("%v is before start date %v", filter.EndDate, filter.StartDate)
+	}
+	if filter.Interval != "" && !filter.Interval.IsValid() {
+		errs["interval"] = fmt.Sprintf("invalid interval %q, use day, week, month, quarter or year", filter.Interval)
+	}
+	if filter.Geo != "" {
+		for _, country := range strings.Split(filter.Geo, ",") {
+			// the API accepts lowercase codes and spaces after commas
+			if !isCountryCode(strings.ToUpper(strings.TrimSpace(country))) {
+				errs["geo"] = fmt.Sprintf("invalid country code %q", country)
+				break
+			}
+		}
+	}
+	if filter.Plans != "" {
+		for _, plan := range strings.Split(filter.Plans, ",") {
+			if strings.TrimSpace(plan) == "" {
+				errs["plans"] = fmt.Sprintf("empty plan in %q", filter.Plans)
+				break
+			}
+		}
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+func isCountryCode(s string) bool {
+	return len(s) == 2 && s[0] >= 'A' && s[0] <= 'Z' && s[1] >= 'A' && s[1] <= 'Z'
+}
@@ -0,0 +1,152 @@
+package chartmogul
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIntervalPeriods(t *testing.T) {
+	day := time.Date(2024, 2, 14, 15, 0, 0, 0, time.UTC) // Wednesday
+	cases := []struct {
+		interval   Interval
+		weekStart  time.Weekday
+		start, end string
+	}{
+		{IntervalDay, time.Monday, "2024-02-14", "2024-02-14"},
+		{IntervalWeek, time.Monday, "2024-02-12", "2024-02-18"},
+		{IntervalWeek, time.Sunday, "2024-02-11", "2024-02-17"},
+		{IntervalMonth, time.Monday, "2024-02-01", "2024-02-29"},
+		{IntervalQuarter, time.Monday, "2024-01-01", "2024-03-31"},
+		{IntervalYear, time.Monday, "2024-01-01", "2024-12-31"},
+	}
+	for _, c := range cases {
+		start := NewDate(c.interval.PeriodStart(day, c.weekStart))
+		end := NewDate(c.interval.PeriodEnd(day, c.weekStart))
+		if string(start) != c.start || string(end) != c.end {
+			t.Errorf("%v/%v: expected %v..%v, got %v..%v", c.interval, c.weekStart, c.start, c.end, start, end)
+		}
+	}
+	if d := IntervalMonth.AddTo(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), 1); NewDate(d) != "2024-02-29" {
+		t.Errorf("Unexpected month addition %v", d)
+	}
+}
+
+func TestRelativeRangeResolve(t *testing.T) {
+	// Still March 31st in UTC, but already April 1st (a Monday) in Tokyo.
+	instant := time.Date(2024, 3, 31, 23, 30, 0, 0, time.UTC)
+	tokyo := &Account{TimeZone: "Asia/Tokyo", WeekStartOn: "sunday"}
+
+	cases := []struct {
+		r          RelativeRange
+		account    *Account
+		start, end Date
+	}{
+		{LastMonths(12), nil, "2023-03-01", "2024-02-29"},
+		{LastMonths(12), tokyo, "2023-04-01", "2024-03-31"},
+		{TrailingDays(90), nil, "2024-01-02", "2024-03-31"},
+		{QuarterToDate(), nil, "2024-01-01", "2024-03-31"},
+		{QuarterToDate(), tokyo, "2024-04-01", "2024-04-01"},
+		{WeekToDate(), tokyo, "2024-03-31", "2024-04-01"},
+		{WeekToDate(), &Account{}, "2024-03-25", "2024-03-31"},
+		{LastPeriods(2, IntervalWeek), nil, "2024-03-11", "2024-03-24"},
+		{TrailingPeriods(1, IntervalYear), nil, "2023-04-01", "2024-03-31"},
+		{YearToDate(), nil, "2024-01-01", "2024-03-31"},
+	}
+	for _, c := range cases {
+		start, end, err := c.r.Resolve(instant, c.account)
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", c.r, err)
+			continue
+		}
+		if start != c.start || end != c.end {
+			t.Errorf("%v: expected %v..%v, got %v..%v", c.r, c.start, c.end, start, end)
+		}
+	}
+
+	if _, _, err := LastPeriods(0, IntervalMonth).Resolve(instant, nil); err == nil {
+		t.Error("Expected error for empty range")
+	}
+	if _, _, err := LastPeriods(1, "fortnight").Resolve(instant, nil); err == nil {
+		t.Error("Expected error for invalid unit")
+	}
+}
+
+func TestMetricsFilterBuilder(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2024, 3, 31, 23, 30, 0, 0, time.UTC) }
+
+	filter, err := NewMetricsFilter().
+		Relative(LastMonths(3)).
+		Interval(IntervalMonth).
+		Geo("us", " GB", "US").
+		Plans("gold", "silver").
+		Build(&Account{TimeZone: "Asia/Tokyo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := MetricsFilter{StartDate: "2024-01-01", EndDate: "2024-03-31", Interval: IntervalMonth, Geo: "US,GB", Plans: "gold,silver"}
+	if *filter != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, *filter)
+	}
+
+	if _, err := NewMetricsFilter().Plans("a,b").Build(nil); err == nil {
+		t.Fatal("Expected error for plan containing comma")
+	}
+	if _, err := NewMetricsFilter().Between("2024-02-01", "2024-01-01").Build(nil); err == nil {
+		t.Fatal("Expected error for reversed range")
+	}
+}
+
+func TestMetricsFilterValidate(t *testing.T) {
+	var nilFilter *MetricsFilter
+	if err := nilFilter.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	err := (&MetricsFilter{
+		StartDate: "2024-13-01",
+		EndDate:   "2024-01-31",
+		Interval:  "monthly",
+		Geo:       "USA",
+		Plans:     "gold,,silver",
+	}).Validate()
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("Expected Errors, got %v", err)
+	}
+	for _, key := range []string{"start-date", "interval", "geo", "plans"} {
+		if errs[key] == "" {
+			t.Errorf("Expected error for %v in %v", key, errs)
+		}
+	}
+	if _, ok := errs["end-date"]; ok {
+		t.Errorf("Unexpected end-date error in %v", errs)
+	}
+
+	if err := (&MetricsFilter{Geo: "us, GB"}).Validate(); err != nil {
+		t.Errorf("Expected lowercase and spaced country codes to pass, got %v", err)
+	}
+	err = (&MetricsFilter{StartDate: "2022-01-01T10:00:00Z", EndDate: "2022-1-31"}).Validate()
+	if errs, ok := err.(Errors); !ok || errs["start-date"] == "" || errs["end-date"] == "" {
+		t.Errorf("Expected timestamps and unpadded dates to fail, got %v", err)
+	}
+}
+
+func TestMetricsRetrieveValidatesFilter(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("Unexpected request %v", r.RequestURI)
+			}))
+	defer server.Close()
+	SetURL(server.URL + "/v/%v")
+
+	var tested IApi = &API{
+		ApiKey: "token",
+	}
+	_, err := tested.MetricsRetrieveMRR(&MetricsFilter{Interval: "fortnight"})
+	if _, ok := err.(Errors); !ok {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package mirror
+
+import (
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// record is a row to upsert into a table.
+type record struct {
+	table  *table
+	parent string
+	row    interface{}
+}
+
+// fetcher requests the page of the state, advances its cursor or anchor
+// and reports whether there are more pages.
+type fetcher func(c Client, perPage uint32, s *State) ([]record, bool, error)
+
+var fetchers = map[Resource]fetcher{
+	DataSources: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		result, err := c.ListDataSources()
+		if err != nil {
+			return nil, false, err
+		}
+		records := make([]record, 0, len(result.DataSources))
+		for _, row := range result.DataSources {
+			records = append(records, record{table: dataSourcesTable, row: row})
+		}
+		return records, false, nil
+	},
+	Plans: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		result, err := c.ListPlans(&cm.ListPlansParams{Cursor: cursor(perPage, s)})
+		if err != nil {
+			return nil, false, err
+		}
+		records := make([]record, 0, len(result.Plans))
+		for _, row := range result.Plans {
+			records = append(records, record{table: plansTable, row: row})
+		}
+		return records, advance(s, result.Pagination), nil
+	},
+	PlanGroups: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		next := cursor(perPage, s)
+		result, err := c.ListPlanGroups(&next)
+		if err != nil {
+			return nil, false, err
+		}
+		records := make([]record, 0, len(result.PlanGroups))
+		for _, row := range result.PlanGroups {
+			records = append(records, record{table: planGroupsTable, row: row})
+		}
+		return records, advance(s, result.Pagination), nil
+	},
+	Customers: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		result, err := c.ListCustomers(&cm.ListCustomersParams{Cursor: cursor(perPage, s)})
+		if err != nil {
+			return nil, false, err
+		}
+		records := make([]record, 0, len(result.Entries))
+		for _, row := range result.Entries {
+			records = append(records, record{table: customersTable, row: row})
+		}
+		return records, advance(s, result.Pagination), nil
+	},
+	Contacts: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		result, err := c.ListContacts(&cm.ListContactsParams{Cursor: cursor(perPage, s)})
+		if err != nil {
+			return nil, false, err
+		}
+		records := make([]record, 0, len(result.Entries))
+		for _, row := range result.Entries {
+			records = append(records, record{table: contactsTable, row: row})
+		}
+		return records, advance(s, result.Pagination), nil
+	},
+	Notes: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		result, err := c.ListNotes(&cm.ListNotesParams{Cursor: cursor(perPage, s)})
+		if err != nil {
+			return nil, false, err
+		}
+		records := make([]record, 0, len(result.Entries))
+		for _, row := range result.Entries {
+			records = append(records, record{table: notesTable, row: row})
+		}
+		return records, advance(s, result.Pagination), nil
+	},
+	Opportunities: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		result, err := c.ListOpportunities(&cm.ListOpportunitiesParams{Cursor: cursor(perPage, s)})
+		if err != nil {
+			return nil, false, err
+		}
+		records := make([]record, 0, len(result.Entries))
+		for _, row := range result.Entries {
+			records = append(records, record{table: opportunitiesTable, row: row})
+		}
+		return records, advance(s, result.Pagination), nil
+	},
+	Invoices: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		result, err := c.ListAllInvoices(&cm.ListAllInvoicesParams{Cursor: cursor(perPage, s)})
+		if err != nil {
+			return nil, false, err
+		}
+		var records []record
+		for _, row := range result.Invoices {
+			records = append(records, record{table: invoicesTable, row: row})
+			for _, item := range row.LineItems {
+				records = append(records, record{table: lineItemsTable, parent: row.UUID, row: item})
+			}
+			for _, transaction := range row.Transactions {
+				records = append(records, record{table: transactionsTable, parent: row.UUID, row: transaction})
+			}
+		}
+		return records, advance(s, result.Pagination), nil
+	},
+	SubscriptionEvents: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		next := cursor(perPage, s)
+		result, err := c.ListSubscriptionEvents(nil, &next)
+		if err != nil {
+			return nil, false, err
+		}
+		records := make([]record, 0, len(result.SubscriptionEvents))
+		for _, row := range result.SubscriptionEvents {
+			records = append(records, record{table: subscriptionEventsTable, row: row})
+		}
+		return records, advance(s, result.Pagination), nil
+	},
+	// Activities are listed in ascending date order from the anchor, so the next sync
+	// only reads the activities added since.
+	Activities: func(c Client, perPage uint32, s *State) ([]record, bool, error) {
+		result, err := c.MetricsListActivities(&cm.MetricsListActivitiesParams{
+			AnchorCursor: cm.AnchorCursor{PerPage: perPage, StartAfter: s.Anchor},
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		records := make([]record, 0, len(result.Entries))
+		for _, row := range result.Entries {
+			records = append(records, record{table: activitiesTable, row: row})
+		}
+		if len(result.Entries) == 0 {
+			return records, false, nil
+		}
+		s.Anchor = result.Entries[len(result.Entries)-1].UUID
+		return records, result.HasMore, nil
+	},
+}
+
+func cursor(perPage uint32, s *State) cm.Cursor {
+	return cm.Cursor{PerPage: perPage, Cursor: s.Cursor}
+}
+
+// advance moves the state to the next page.
+func advance(s *State, p cm.Pagination) bool {
+	s.Cursor = p.Cursor
+	return p.HasMore && p.Cursor != ""
+}
@@ -0,0 +1,201 @@
+// Package mirror keeps a local copy of a ChartMogul account in SQL tables for BI tools.
+//
+// The tables are populated through the list endpoints and written through database/sql,
+// any driver of a supported Dialect works. Only activities are synced incrementally, from the
+// anchor of the last mirrored one; the list endpoints of the other resources can't filter on
+// updates, so each sync passes through all their pages. The cursor is persisted after each page,
+// so an interrupted pass resumes where it stopped, and when a pass completes the rows it didn't
+// write are deleted, eg. deleted customers or removed line items. Mirrored activities are kept.
+package mirror
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Client lists the resources to mirror, eg. *chartmogul.API.
+type Client interface {
+	ListDataSources() (*cm.DataSources, error)
+	ListPlans(*cm.ListPlansParams) (*cm.Plans, error)
+	ListPlanGroups(*cm.Cursor) (*cm.PlanGroups, error)
+	ListCustomers(*cm.ListCustomersParams) (*cm.Customers, error)
+	ListContacts(*cm.ListContactsParams) (*cm.Contacts, error)
+	ListNotes(*cm.ListNotesParams) (*cm.Notes, error)
+	ListOpportunities(*cm.ListOpportunitiesParams) (*cm.Opportunities, error)
+	ListAllInvoices(*cm.ListAllInvoicesParams) (*cm.Invoices, error)
+	ListSubscriptionEvents(*cm.FilterSubscriptionEvents, *cm.Cursor) (*cm.SubscriptionEvents, error)
+	MetricsListActivities(*cm.MetricsListActivitiesParams) (*cm.MetricsActivities, error)
+}
+
+// Resource is a mirrored list endpoint.
+type Resource string
+
+// Mirrored resources, invoices include their line items and transactions.
+const (
+	DataSources        Resource = "data_sources"
+	Plans              Resource = "plans"
+	PlanGroups         Resource = "plan_groups"
+	Customers          Resource = "customers"
+	Contacts           Resource = "contacts"
+	Notes              Resource = "notes"
+	Opportunities      Resource = "opportunities"
+	Invoices           Resource = "invoices"
+	SubscriptionEvents Resource = "subscription_events"
+	Activities         Resource = "activities"
+)
+
+// AllResources are synced when Sync is called without resources.
+var AllResources = []Resource{
+	DataSources, Plans, PlanGroups, Customers, Contacts, Notes, Opportunities, Invoices, SubscriptionEvents, Activities,
+}
+
+// State of a resource persisted between syncs.
+type State struct {
+	Resource Resource
+	// Cursor of the next page, empty when the last sync went through all pages.
+	Cursor string
+	// Anchor is the UUID of the last mirrored activity.
+	Anchor string
+	// StartedAt is the time the pass through all pages in progress started, RFC 3339.
+	StartedAt string
+	// SyncedAt is the time the last sync went through all pages, RFC 3339.
+	SyncedAt string
+}
+
+// Mirror syncs the resources into the tables of a database.
+type Mirror struct {
+	// PerPage is the page size of the list requests, the API default if zero.
+	PerPage uint32
+
+	db      *sql.DB
+	dialect Dialect
+	client  Client
+	now     func() time.Time
+}
+
+// New creates a mirror writing to the database of the dialect.
+func New(db *sql.DB, dialect Dialect, client Client) *Mirror {
+	return &Mirror{db: db, dialect: dialect, client: client, now: time.Now}
+}
+
+// CreateTables creates the mirror tables if they don't exist.
+func (m *Mirror) CreateTables(ctx context.Context) error {
+	for _, statement := range DDL(m.dialect) {
+		if _, err := m.db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// State returns the persisted state of a resource, empty if it was never synced.
+func (m *Mirror) State(ctx context.Context, resource Resource) (*State, error) {
+	var cursor, anchor, startedAt, syncedAt sql.NullString
+	err := m.db.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT "cursor", "anchor", "started_at", "synced_at" FROM %q WHERE "resource" = %v`, syncStateTable, m.dialect.placeholder(1)),
+		string(resource)).Scan(&cursor, &anchor, &startedAt, &syncedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &State{Resource: resource}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &State{Resource: resource, Cursor: cursor.String, Anchor: anchor.String, StartedAt: startedAt.String, SyncedAt: syncedAt.String}, nil
+}
+
+// Sync mirrors the resources, all of them if none are given, and returns the number of rows
+// written per resource. Rows are upserted by their key, a page and the state in a transaction,
+// the last page with the deletion of the rows not written in the pass.
+func (m *Mirror) Sync(ctx context.Context, resources ...Resource) (map[Resource]int, error) {
+	if len(resources) == 0 {
+		resources = AllResources
+	}
+	written := map[Resource]int{}
+	for _, resource := range resources {
+		n, err := m.sync(ctx, resource)
+		written[resource] = n
+		if err != nil {
+			return written, fmt.Errorf("chartmogul: mirror of %v failed: %w", resource, err)
+		}
+	}
+	return written, nil
+}
+
+func (m *Mirror) sync(ctx context.Context, resource Resource) (int, error) {
+	fetch, ok := fetchers[resource]
+	if !ok {
+		return 0, errors.New("unknown resource")
+	}
+	state, err := m.State(ctx, resource)
+	if err != nil {
+		return 0, err
+	}
+	if state.Cursor == "" || state.StartedAt == "" {
+		state.StartedAt = m.now().UTC().Format(time.RFC3339Nano)
+	}
+	written := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		records, more, err := fetch(m.client, m.PerPage, state)
+		if err != nil {
+			return written, err
+		}
+		var pruned []*table
+		if !more {
+			state.Cursor = ""
+			state.SyncedAt = m.now().UTC().Format(time.RFC3339)
+			pruned = prunedTables[resource]
+		}
+		if err := m.write(ctx, records, state, pruned); err != nil {
+			return written, err
+		}
+		written += len(records)
+		if !more {
+			return written, nil
+		}
+	}
+}
+
+// write upserts the records of a page and the state in a transaction,
+// and deletes the rows of the pruned tables not written in the state's pass.
+func (m *Mirror) write(ctx context.Context, records []record, state *State, pruned []*table) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		values, err := r.table.values(r.row, r.parent, state.StartedAt)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, r.table.upsert(m.dialect), values...)
+		}
+		if err != nil {
+			tx.Rollback() //nolint
+			return err
+		}
+	}
+	for _, t := range pruned {
+		if _, err := tx.ExecContext(ctx, t.prune(m.dialect), state.StartedAt); err != nil {
+			tx.Rollback() //nolint
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(
+		`INSERT INTO %q ("resource", "cursor", "anchor", "started_at", "synced_at") VALUES (%v, %v, %v, %v, %v) `+
+			`ON CONFLICT ("resource") DO UPDATE SET "cursor" = excluded."cursor", "anchor" = excluded."anchor", `+
+			`"started_at" = excluded."started_at", "synced_at" = excluded."synced_at"`,
+		syncStateTable, m.dialect.placeholder(1), m.dialect.placeholder(2), m.dialect.placeholder(3), m.dialect.placeholder(4),
+		m.dialect.placeholder(5)),
+		string(state.Resource), state.Cursor, state.Anchor, state.StartedAt, state.SyncedAt)
+	if err != nil {
+		tx.Rollback() //nolint
+		return err
+	}
+	return tx.Commit()
+}
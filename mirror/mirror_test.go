@@ -0,0 +1,254 @@
+package mirror
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// fakeDB is a database/sql driver recording upserted rows by table and key, it answers the sync state query.
+type fakeDB struct {
+	rows map[string]map[string]map[string]driver.Value
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{rows: map[string]map[string]map[string]driver.Value{}}
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return db, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+func (db *fakeDB) Open(string) (driver.Conn, error)             { return db, nil }
+func (db *fakeDB) Prepare(query string) (driver.Stmt, error)    { return &fakeStmt{db, query}, nil }
+func (db *fakeDB) Close() error                                 { return nil }
+func (db *fakeDB) Begin() (driver.Tx, error)                    { return db, nil }
+func (db *fakeDB) Commit() error                                { return nil }
+func (db *fakeDB) Rollback() error                              { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.HasPrefix(s.query, "INSERT INTO ") {
+		name := strings.Split(s.query, `"`)[1]
+		columns := strings.Split(s.query[strings.Index(s.query, "(")+1:strings.Index(s.query, ")")], ", ")
+		key := strings.Split(s.query[strings.Index(s.query, "ON CONFLICT ("):], `"`)[1]
+		row := map[string]driver.Value{}
+		for i, c := range columns {
+			row[strings.Trim(c, `"`)] = args[i]
+		}
+		if s.db.rows[name] == nil {
+			s.db.rows[name] = map[string]map[string]driver.Value{}
+		}
+		s.db.rows[name][toString(row[key])] = row
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	row, ok := s.db.rows[syncStateTable][args[0].(string)]
+	if !ok {
+		return &fakeRows{}, nil
+	}
+	return &fakeRows{values: [][]driver.Value{{row["cursor"], row["anchor"], row["started_at"], row["synced_at"]}}}, nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"cursor", "anchor", "started_at", "synced_at"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func toString(v driver.Value) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return spew.Sprint(v)
+}
+
+// fakeClient serves customers in pages of one and appends activities, other resources are empty
+// but for an invoice, unless invoices are set.
+type fakeClient struct {
+	Client
+	customers  []*cm.Customer
+	invoices   []*cm.Invoice
+	activities []*cm.MetricsActivity
+	failAt     string
+	anchors    []string
+}
+
+func (c *fakeClient) ListDataSources() (*cm.DataSources, error) {
+	return &cm.DataSources{DataSources: []*cm.DataSource{{UUID: "ds_1", Name: "Stripe"}}}, nil
+}
+
+func (c *fakeClient) ListPlans(*cm.ListPlansParams) (*cm.Plans, error) { return &cm.Plans{}, nil }
+func (c *fakeClient) ListPlanGroups(*cm.Cursor) (*cm.PlanGroups, error) {
+	return &cm.PlanGroups{PlanGroups: []*cm.PlanGroup{{UUID: "plg_1", Plans: []*string{}}}}, nil
+}
+func (c *fakeClient) ListContacts(*cm.ListContactsParams) (*cm.Contacts, error) {
+	return &cm.Contacts{}, nil
+}
+func (c *fakeClient) ListNotes(*cm.ListNotesParams) (*cm.Notes, error) { return &cm.Notes{}, nil }
+func (c *fakeClient) ListOpportunities(*cm.ListOpportunitiesParams) (*cm.Opportunities, error) {
+	return &cm.Opportunities{}, nil
+}
+func (c *fakeClient) ListSubscriptionEvents(*cm.FilterSubscriptionEvents, *cm.Cursor) (*cm.SubscriptionEvents, error) {
+	return &cm.SubscriptionEvents{SubscriptionEvents: []*cm.SubscriptionEvent{{ID: 73}}}, nil
+}
+
+func (c *fakeClient) ListAllInvoices(*cm.ListAllInvoicesParams) (*cm.Invoices, error) {
+	if c.invoices != nil {
+		return &cm.Invoices{Invoices: c.invoices}, nil
+	}
+	amount := 500
+	return &cm.Invoices{Invoices: []*cm.Invoice{{
+		UUID:         "inv_1",
+		LineItems:    []*cm.LineItem{{UUID: "li_1", AmountInCents: 500}},
+		Transactions: []*cm.Transaction{{UUID: "tr_1", Result: "successful", AmountInCents: &amount}},
+	}}}, nil
+}
+
+func (c *fakeClient) ListCustomers(params *cm.ListCustomersParams) (*cm.Customers, error) {
+	if c.failAt != "" && params.Cursor.Cursor == c.failAt {
+		return nil, errors.New("unavailable")
+	}
+	i := 0
+	if params.Cursor.Cursor != "" {
+		i = int(params.Cursor.Cursor[0] - '0')
+	}
+	result := &cm.Customers{Entries: c.customers[i : i+1]}
+	if i+1 < len(c.customers) {
+		result.Pagination = cm.Pagination{Cursor: string(rune('0' + i + 1)), HasMore: true}
+	}
+	return result, nil
+}
+
+func (c *fakeClient) MetricsListActivities(params *cm.MetricsListActivitiesParams) (*cm.MetricsActivities, error) {
+	c.anchors = append(c.anchors, params.StartAfter)
+	i := 0
+	for j, a := range c.activities {
+		if a.UUID == params.StartAfter {
+			i = j + 1
+		}
+	}
+	return &cm.MetricsActivities{Entries: c.activities[i:]}, nil
+}
+
+func TestSync(t *testing.T) {
+	db := newFakeDB()
+	client := &fakeClient{
+		customers: []*cm.Customer{
+			{UUID: "cus_1", ID: 1, Name: "Acme", Mrr: 100, DataSourceUUIDs: []string{"ds_1"}},
+			{UUID: "cus_2", ID: 2, Name: "Umbrella"},
+		},
+		activities: []*cm.MetricsActivity{{UUID: "a1", Type: "new_biz"}},
+	}
+	m := New(sql.OpenDB(db), Postgres, client)
+	m.now = func() time.Time { return time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC) }
+	if err := m.CreateTables(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	written, err := m.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written[Customers] != 2 || written[Invoices] != 3 || written[Activities] != 1 || written[SubscriptionEvents] != 1 {
+		spew.Dump(written)
+		t.Fatal("Unexpected rows written")
+	}
+	customer := db.rows["chartmogul_customers"]["cus_1"]
+	if customer == nil || customer["id"] != int64(1) || customer["name"] != "Acme" || customer["data_source_uuids"] != `["ds_1"]` {
+		spew.Dump(db.rows["chartmogul_customers"])
+		t.Fatal("Unexpected customer")
+	}
+	if db.rows["chartmogul_transactions"]["tr_1"]["invoice_uuid"] != "inv_1" || db.rows["chartmogul_line_items"]["li_1"] == nil {
+		spew.Dump(db.rows)
+		t.Fatal("Unexpected invoice rows")
+	}
+	if db.rows["chartmogul_subscription_events"]["73"] == nil {
+		spew.Dump(db.rows["chartmogul_subscription_events"])
+		t.Fatal("Unexpected subscription events")
+	}
+
+	state, err := m.State(context.Background(), Activities)
+	if err != nil || state.Anchor != "a1" || state.SyncedAt != "2022-03-01T00:00:00Z" {
+		spew.Dump(state, err)
+		t.Fatal("Unexpected state")
+	}
+
+	client.activities = append(client.activities, &cm.MetricsActivity{UUID: "a2", Type: "expansion"})
+	written, err = m.Sync(context.Background(), Activities)
+	if err != nil || written[Activities] != 1 || client.anchors[len(client.anchors)-1] != "a1" {
+		spew.Dump(written, client.anchors, err)
+		t.Fatal("Expected activities from the anchor")
+	}
+}
+
+func TestSyncResumes(t *testing.T) {
+	db := newFakeDB()
+	client := &fakeClient{
+		customers: []*cm.Customer{{UUID: "cus_1"}, {UUID: "cus_2"}, {UUID: "cus_3"}},
+		failAt:    "2",
+	}
+	m := New(sql.OpenDB(db), SQLite, client)
+	written, err := m.Sync(context.Background(), Customers)
+	if err == nil || written[Customers] != 2 {
+		spew.Dump(written, err)
+		t.Fatal("Expected the sync to fail on the third page")
+	}
+	if state, _ := m.State(context.Background(), Customers); state.Cursor != "2" || state.SyncedAt != "" {
+		spew.Dump(state)
+		t.Fatal("Expected the cursor to be persisted")
+	}
+
+	client.failAt = ""
+	written, err = m.Sync(context.Background(), Customers)
+	if err != nil || written[Customers] != 1 || len(db.rows["chartmogul_customers"]) != 3 {
+		spew.Dump(written, err)
+		t.Fatal("Expected the sync to resume from the cursor")
+	}
+	if state, _ := m.State(context.Background(), Customers); state.Cursor != "" || state.SyncedAt == "" {
+		spew.Dump(state)
+		t.Fatal("Expected the cursor to be reset")
+	}
+}
+
+func TestDDL(t *testing.T) {
+	statements := DDL(Postgres)
+	if len(statements) != len(tables)+1 {
+		t.Fatalf("Unexpected statements %v", len(statements))
+	}
+	if !strings.Contains(statements[3], `"uuid" TEXT PRIMARY KEY`) || !strings.Contains(statements[3], `"mrr" DOUBLE PRECISION`) ||
+		!strings.Contains(statements[3], `"data_source_uuids" TEXT`) {
+		t.Fatalf("Unexpected customers table:\n%v", statements[3])
+	}
+	if !strings.Contains(DDL(SQLite)[3], `"mrr" REAL`) {
+		t.Fatalf("Unexpected SQLite customers table:\n%v", DDL(SQLite)[3])
+	}
+	if upsert := lineItemsTable.upsert(SQLite); !strings.HasPrefix(upsert, `INSERT INTO "chartmogul_line_items" ("invoice_uuid", "uuid", `) ||
+		!strings.Contains(upsert, `VALUES (?, ?, `) || !strings.Contains(upsert, `ON CONFLICT ("uuid") DO UPDATE SET "invoice_uuid" = excluded."invoice_uuid"`) {
+		t.Fatalf("Unexpected upsert:\n%v", upsert)
+	}
+}
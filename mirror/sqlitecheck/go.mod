@@ -0,0 +1,11 @@
+module github.com/chartmogul/chartmogul-go/v4/mirror/sqlitecheck
+
+go 1.14
+
+require (
+	github.com/chartmogul/chartmogul-go/v4 v4.0.0
+	github.com/davecgh/go-spew v1.1.1
+	github.com/mattn/go-sqlite3 v1.14.14
+)
+
+replace github.com/chartmogul/chartmogul-go/v4 => ../..
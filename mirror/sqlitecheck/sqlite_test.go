@@ -0,0 +1,123 @@
+// Package sqlitecheck runs the mirror against SQLite. It's a separate module to keep the cgo
+// driver out of the library's dependencies, run it with `go test` in this directory.
+package sqlitecheck
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/chartmogul/chartmogul-go/v4/mirror"
+	"github.com/davecgh/go-spew/spew"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// client serves the customers and invoices set, other resources are empty but for an activity.
+type client struct {
+	mirror.Client
+	customers []*cm.Customer
+	invoices  []*cm.Invoice
+}
+
+func (c *client) ListDataSources() (*cm.DataSources, error) {
+	return &cm.DataSources{DataSources: []*cm.DataSource{{UUID: "ds_1", Name: "Stripe"}}}, nil
+}
+func (c *client) ListPlans(*cm.ListPlansParams) (*cm.Plans, error)  { return &cm.Plans{}, nil }
+func (c *client) ListPlanGroups(*cm.Cursor) (*cm.PlanGroups, error) { return &cm.PlanGroups{}, nil }
+func (c *client) ListContacts(*cm.ListContactsParams) (*cm.Contacts, error) {
+	return &cm.Contacts{}, nil
+}
+func (c *client) ListNotes(*cm.ListNotesParams) (*cm.Notes, error) { return &cm.Notes{}, nil }
+func (c *client) ListOpportunities(*cm.ListOpportunitiesParams) (*cm.Opportunities, error) {
+	return &cm.Opportunities{}, nil
+}
+func (c *client) ListSubscriptionEvents(*cm.FilterSubscriptionEvents, *cm.Cursor) (*cm.SubscriptionEvents, error) {
+	return &cm.SubscriptionEvents{}, nil
+}
+func (c *client) ListAllInvoices(*cm.ListAllInvoicesParams) (*cm.Invoices, error) {
+	return &cm.Invoices{Invoices: c.invoices}, nil
+}
+func (c *client) ListCustomers(*cm.ListCustomersParams) (*cm.Customers, error) {
+	return &cm.Customers{Entries: c.customers}, nil
+}
+func (c *client) MetricsListActivities(params *cm.MetricsListActivitiesParams) (*cm.MetricsActivities, error) {
+	if params.StartAfter != "" {
+		return &cm.MetricsActivities{}, nil
+	}
+	return &cm.MetricsActivities{Entries: []*cm.MetricsActivity{{UUID: "a1", Type: "new_biz"}}}, nil
+}
+
+func count(t *testing.T, db *sql.DB, query string) int {
+	var n int
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSyncSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "mirror.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skipf("SQLite is unavailable: %v", err)
+	}
+
+	client := &client{
+		customers: []*cm.Customer{
+			{UUID: "cus_1", ID: 1, Name: "Acme", Mrr: 100.5, DataSourceUUIDs: []string{"ds_1"}},
+			{UUID: "cus_2", ID: 2, Name: "Umbrella"},
+		},
+		invoices: []*cm.Invoice{{UUID: "inv_1", LineItems: []*cm.LineItem{{UUID: "li_1", AmountInCents: 500}, {UUID: "li_2"}}}},
+	}
+	m := mirror.New(db, mirror.SQLite, client)
+	if err := m.CreateTables(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var name, sources string
+	var mrr float64
+	if err := db.QueryRow(`SELECT "name", "mrr", "data_source_uuids" FROM "chartmogul_customers" WHERE "uuid" = ?`, "cus_1").
+		Scan(&name, &mrr, &sources); err != nil || name != "Acme" || mrr != 100.5 || sources != `["ds_1"]` {
+		spew.Dump(err, name, mrr, sources)
+		t.Fatal("Unexpected customer")
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM "chartmogul_line_items" WHERE "invoice_uuid" = 'inv_1'`); n != 2 {
+		t.Fatalf("Unexpected %v line items", n)
+	}
+
+	// the customer and line item are deleted, the customer's name changes
+	client.customers = []*cm.Customer{{UUID: "cus_1", ID: 1, Name: "Acme Corp"}}
+	client.invoices[0].LineItems = client.invoices[0].LineItems[:1]
+	if _, err := m.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM "chartmogul_customers" WHERE "name" = 'Acme Corp'`); n != 1 ||
+		count(t, db, `SELECT COUNT(*) FROM "chartmogul_customers"`) != 1 {
+		t.Fatal("Expected the deleted customer to be removed and the other updated")
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM "chartmogul_line_items"`); n != 1 {
+		t.Fatalf("Expected the removed line item to be deleted, got %v", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM "chartmogul_activities"`); n != 1 {
+		t.Fatalf("Expected the activity to be kept, got %v", n)
+	}
+	state, err := m.State(context.Background(), mirror.Customers)
+	if err != nil || state.StartedAt == "" || state.SyncedAt < state.StartedAt {
+		spew.Dump(state, err)
+		t.Fatal("Unexpected state")
+	}
+}
@@ -0,0 +1,250 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Dialect of the SQL database.
+type Dialect string
+
+// Supported dialects.
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+func (d Dialect) placeholder(i int) string {
+	if d == Postgres {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
+
+func (d Dialect) sqlType(k columnKind) string {
+	switch {
+	case k == kindInt:
+		return "BIGINT"
+	case k == kindFloat && d == Postgres:
+		return "DOUBLE PRECISION"
+	case k == kindFloat:
+		return "REAL"
+	case k == kindBool:
+		return "BOOLEAN"
+	}
+	return "TEXT"
+}
+
+type columnKind int
+
+const (
+	kindText columnKind = iota
+	kindInt
+	kindFloat
+	kindBool
+	// kindJSON columns hold slices, maps and nested structs as JSON text
+	kindJSON
+)
+
+type tableColumn struct {
+	name  string
+	field int
+	kind  columnKind
+}
+
+// table mirrors a struct type, a column per field named after its JSON tag.
+type table struct {
+	name string
+	key  string
+	// parent is the column referencing the parent row, eg. invoice_uuid of line items
+	parent  string
+	typ     reflect.Type
+	columns []tableColumn
+}
+
+func newTable(name, key, parent string, sample interface{}, skip ...string) *table {
+	t := &table{name: name, key: key, parent: parent, typ: reflect.TypeOf(sample)}
+	skipped := map[string]bool{}
+	for _, s := range skip {
+		skipped[s] = true
+	}
+	for i := 0; i < t.typ.NumField(); i++ {
+		f := t.typ.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.PkgPath != "" || tag == "-" || tag == "" || skipped[tag] {
+			continue
+		}
+		c := tableColumn{name: strings.Replace(tag, "-", "_", -1), field: i}
+		switch f.Type.Kind() {
+		case reflect.String:
+			c.kind = kindText
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			c.kind = kindInt
+		case reflect.Float32, reflect.Float64:
+			c.kind = kindFloat
+		case reflect.Bool:
+			c.kind = kindBool
+		case reflect.Ptr:
+			switch f.Type.Elem().Kind() {
+			case reflect.Int, reflect.Int32, reflect.Int64:
+				c.kind = kindInt
+			default:
+				c.kind = kindJSON
+			}
+		default:
+			c.kind = kindJSON
+		}
+		t.columns = append(t.columns, c)
+	}
+	return t
+}
+
+// Tables of the mirror, all prefixed with chartmogul_.
+var (
+	dataSourcesTable        = newTable("chartmogul_data_sources", "uuid", "", cm.DataSource{}, "errors")
+	plansTable              = newTable("chartmogul_plans", "uuid", "", cm.Plan{}, "errors")
+	planGroupsTable         = newTable("chartmogul_plan_groups", "uuid", "", cm.PlanGroup{}, "errors")
+	customersTable          = newTable("chartmogul_customers", "uuid", "", cm.Customer{}, "errors")
+	contactsTable           = newTable("chartmogul_contacts", "uuid", "", cm.Contact{})
+	notesTable              = newTable("chartmogul_notes", "uuid", "", cm.Note{})
+	opportunitiesTable      = newTable("chartmogul_opportunities", "uuid", "", cm.Opportunity{})
+	invoicesTable           = newTable("chartmogul_invoices", "uuid", "", cm.Invoice{}, "line_items", "transactions", "errors")
+	lineItemsTable          = newTable("chartmogul_line_items", "uuid", "invoice_uuid", cm.LineItem{})
+	transactionsTable       = newTable("chartmogul_transactions", "uuid", "invoice_uuid", cm.Transaction{}, "errors")
+	subscriptionEventsTable = newTable("chartmogul_subscription_events", "id", "", cm.SubscriptionEvent{}, "errors")
+	activitiesTable         = newTable("chartmogul_activities", "uuid", "", cm.MetricsActivity{})
+
+	tables = []*table{
+		dataSourcesTable, plansTable, planGroupsTable, customersTable, contactsTable, notesTable, opportunitiesTable,
+		invoicesTable, lineItemsTable, transactionsTable, subscriptionEventsTable, activitiesTable,
+	}
+)
+
+// prunedTables are the tables of the resources listed in full on each sync,
+// their rows not written in a complete pass are deleted.
+var prunedTables = map[Resource][]*table{
+	DataSources:        {dataSourcesTable},
+	Plans:              {plansTable},
+	PlanGroups:         {planGroupsTable},
+	Customers:          {customersTable},
+	Contacts:           {contactsTable},
+	Notes:              {notesTable},
+	Opportunities:      {opportunitiesTable},
+	Invoices:           {invoicesTable, lineItemsTable, transactionsTable},
+	SubscriptionEvents: {subscriptionEventsTable},
+}
+
+// syncStateTable keeps the cursors and anchors of the resources between syncs.
+const syncStateTable = "chartmogul_sync_state"
+
+// mirroredAtColumn holds the start of the pass which last wrote a row, RFC 3339.
+const mirroredAtColumn = "mirrored_at"
+
+// names returns the column names, with the parent column first and mirrored_at last.
+func (t *table) names() []string {
+	var names []string
+	if t.parent != "" {
+		names = append(names, t.parent)
+	}
+	for _, c := range t.columns {
+		names = append(names, c.name)
+	}
+	return append(names, mirroredAtColumn)
+}
+
+func (t *table) ddl(d Dialect) string {
+	var columns []string
+	if t.parent != "" {
+		columns = append(columns, fmt.Sprintf("%q TEXT", t.parent))
+	}
+	for _, c := range t.columns {
+		definition := fmt.Sprintf("%q %v", c.name, d.sqlType(c.kind))
+		if c.name == t.key {
+			definition += " PRIMARY KEY"
+		}
+		columns = append(columns, definition)
+	}
+	columns = append(columns, fmt.Sprintf("%q TEXT", mirroredAtColumn))
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %q (\n  %v\n)", t.name, strings.Join(columns, ",\n  "))
+}
+
+// upsert returns the statement inserting or updating a row by its key.
+func (t *table) upsert(d Dialect) string {
+	names := t.names()
+	quoted := make([]string, len(names))
+	placeholders := make([]string, len(names))
+	var updates []string
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+		placeholders[i] = d.placeholder(i + 1)
+		if name != t.key {
+			updates = append(updates, fmt.Sprintf("%q = excluded.%q", name, name))
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %q (%v) VALUES (%v) ON CONFLICT (%q) DO UPDATE SET %v",
+		t.name, strings.Join(quoted, ", "), strings.Join(placeholders, ", "), t.key, strings.Join(updates, ", "))
+}
+
+// prune returns the statement deleting the rows not written in the pass started at the placeholder.
+func (t *table) prune(d Dialect) string {
+	return fmt.Sprintf("DELETE FROM %q WHERE %q IS NULL OR %q <> %v", t.name, mirroredAtColumn, mirroredAtColumn, d.placeholder(1))
+}
+
+// values returns the values of a row, a pointer to the table's struct, in the order of names.
+func (t *table) values(row interface{}, parentKey, mirroredAt string) ([]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	var values []interface{}
+	if t.parent != "" {
+		values = append(values, parentKey)
+	}
+	for _, c := range t.columns {
+		f := v.Field(c.field)
+		switch c.kind {
+		case kindText:
+			values = append(values, f.String())
+		case kindInt:
+			switch {
+			case f.Kind() == reflect.Ptr && f.IsNil():
+				values = append(values, nil)
+			case f.Kind() == reflect.Ptr:
+				values = append(values, f.Elem().Int())
+			case f.Kind() >= reflect.Uint && f.Kind() <= reflect.Uint64:
+				values = append(values, int64(f.Uint()))
+			default:
+				values = append(values, f.Int())
+			}
+		case kindFloat:
+			values = append(values, f.Float())
+		case kindBool:
+			values = append(values, f.Bool())
+		case kindJSON:
+			if (f.Kind() == reflect.Ptr || f.Kind() == reflect.Slice || f.Kind() == reflect.Map || f.Kind() == reflect.Interface) && f.IsNil() {
+				values = append(values, nil)
+				continue
+			}
+			data, err := json.Marshal(f.Interface())
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, string(data))
+		}
+	}
+	return append(values, mirroredAt), nil
+}
+
+// DDL returns the statements creating the mirror tables in the dialect, if they don't exist.
+func DDL(d Dialect) []string {
+	statements := make([]string, 0, len(tables)+1)
+	for _, t := range tables {
+		statements = append(statements, t.ddl(d))
+	}
+	statements = append(statements, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %q (\n  \"resource\" TEXT PRIMARY KEY,\n  \"cursor\" TEXT,\n  \"anchor\" TEXT,\n  \"started_at\" TEXT,\n  \"synced_at\" TEXT\n)",
+		syncStateTable))
+	return statements
+}
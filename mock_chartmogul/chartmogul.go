@@ -5,6 +5,7 @@
 package mock_v4
 
 import (
+	context "context"
 	reflect "reflect"
 
 	chartmogul "github.com/chartmogul/chartmogul-go/v4"
@@ -399,6 +400,21 @@ func (mr *MockIApiMockRecorder) EmptyDataSource(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmptyDataSource", reflect.TypeOf((*MockIApi)(nil).EmptyDataSource), arg0)
 }
 
+// ExportActivities mocks base method.
+func (m *MockIApi) ExportActivities(arg0 context.Context, arg1 *chartmogul.CreateMetricsActivitiesExportParam) (*chartmogul.MetricsActivitiesReader, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportActivities", arg0, arg1)
+	ret0, _ := ret[0].(*chartmogul.MetricsActivitiesReader)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportActivities indicates an expected call of ExportActivities.
+func (mr *MockIApiMockRecorder) ExportActivities(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportActivities", reflect.TypeOf((*MockIApi)(nil).ExportActivities), arg0, arg1)
+}
+
 // ListAllInvoices mocks base method.
 func (m *MockIApi) ListAllInvoices(arg0 *chartmogul.ListAllInvoicesParams) (*chartmogul.Invoices, error) {
 	m.ctrl.T.Helper()
@@ -608,6 +624,66 @@ func (mr *MockIApiMockRecorder) MergeCustomers(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeCustomers", reflect.TypeOf((*MockIApi)(nil).MergeCustomers), arg0)
 }
 
+// MetricsBreakdownByGeo mocks base method.
+func (m *MockIApi) MetricsBreakdownByGeo(arg0 *chartmogul.MetricsFilter, arg1 []string) (*chartmogul.MetricsBreakdown, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MetricsBreakdownByGeo", arg0, arg1)
+	ret0, _ := ret[0].(*chartmogul.MetricsBreakdown)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MetricsBreakdownByGeo indicates an expected call of MetricsBreakdownByGeo.
+func (mr *MockIApiMockRecorder) MetricsBreakdownByGeo(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsBreakdownByGeo", reflect.TypeOf((*MockIApi)(nil).MetricsBreakdownByGeo), arg0, arg1)
+}
+
+// MetricsBreakdownByPlanGroup mocks base method.
+func (m *MockIApi) MetricsBreakdownByPlanGroup(arg0 *chartmogul.MetricsFilter) (*chartmogul.MetricsBreakdown, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MetricsBreakdownByPlanGroup", arg0)
+	ret0, _ := ret[0].(*chartmogul.MetricsBreakdown)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MetricsBreakdownByPlanGroup indicates an expected call of MetricsBreakdownByPlanGroup.
+func (mr *MockIApiMockRecorder) MetricsBreakdownByPlanGroup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsBreakdownByPlanGroup", reflect.TypeOf((*MockIApi)(nil).MetricsBreakdownByPlanGroup), arg0)
+}
+
+// MetricsBreakdownByPlans mocks base method.
+func (m *MockIApi) MetricsBreakdownByPlans(arg0 *chartmogul.MetricsFilter, arg1 map[string][]string) (*chartmogul.MetricsBreakdown, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MetricsBreakdownByPlans", arg0, arg1)
+	ret0, _ := ret[0].(*chartmogul.MetricsBreakdown)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MetricsBreakdownByPlans indicates an expected call of MetricsBreakdownByPlans.
+func (mr *MockIApiMockRecorder) MetricsBreakdownByPlans(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsBreakdownByPlans", reflect.TypeOf((*MockIApi)(nil).MetricsBreakdownByPlans), arg0, arg1)
+}
+
+// MetricsBreakdownByRegion mocks base method.
+func (m *MockIApi) MetricsBreakdownByRegion(arg0 *chartmogul.MetricsFilter, arg1 map[string][]string) (*chartmogul.MetricsBreakdown, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MetricsBreakdownByRegion", arg0, arg1)
+	ret0, _ := ret[0].(*chartmogul.MetricsBreakdown)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MetricsBreakdownByRegion indicates an expected call of MetricsBreakdownByRegion.
+func (mr *MockIApiMockRecorder) MetricsBreakdownByRegion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsBreakdownByRegion", reflect.TypeOf((*MockIApi)(nil).MetricsBreakdownByRegion), arg0, arg1)
+}
+
 // MetricsCreateActivitiesExport mocks base method.
 func (m *MockIApi) MetricsCreateActivitiesExport(arg0 *chartmogul.CreateMetricsActivitiesExportParam) (*chartmogul.MetricsActivitiesExport, error) {
 	m.ctrl.T.Helper()
@@ -773,6 +849,21 @@ func (mr *MockIApiMockRecorder) MetricsRetrieveCustomerCount(arg0 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsRetrieveCustomerCount", reflect.TypeOf((*MockIApi)(nil).MetricsRetrieveCustomerCount), arg0)
 }
 
+// MetricsRetrieveDashboard mocks base method.
+func (m *MockIApi) MetricsRetrieveDashboard(arg0 *chartmogul.MetricsFilter) (*chartmogul.DashboardResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MetricsRetrieveDashboard", arg0)
+	ret0, _ := ret[0].(*chartmogul.DashboardResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MetricsRetrieveDashboard indicates an expected call of MetricsRetrieveDashboard.
+func (mr *MockIApiMockRecorder) MetricsRetrieveDashboard(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsRetrieveDashboard", reflect.TypeOf((*MockIApi)(nil).MetricsRetrieveDashboard), arg0)
+}
+
 // MetricsRetrieveLTV mocks base method.
 func (m *MockIApi) MetricsRetrieveLTV(arg0 *chartmogul.MetricsFilter) (*chartmogul.LTVResult, error) {
 	m.ctrl.T.Helper()
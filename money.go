@@ -0,0 +1,273 @@
+package chartmogul
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned by Money arithmetic when the operands are in different currencies.
+var ErrCurrencyMismatch = errors.New("chartmogul: currency mismatch")
+
+// currencyExponents lists ISO 4217 currencies whose minor unit isn't 1/100 of the major unit.
+// All other currencies use two decimal places.
+var currencyExponents = map[string]int{
+	// zero-decimal currencies
+	"BIF": 0,
+	"CLP": 0,
+	"DJF": 0,
+	"GNF": 0,
+	"ISK": 0,
+	"JPY": 0,
+	"KMF": 0,
+	"KRW": 0,
+	"PYG": 0,
+	"RWF": 0,
+	"UGX": 0,
+	"UYI": 0,
+	"VND": 0,
+	"VUV": 0,
+	"XAF": 0,
+	"XOF": 0,
+	"XPF": 0,
+	// three-decimal currencies
+	"BHD": 3,
+	"IQD": 3,
+	"JOD": 3,
+	"KWD": 3,
+	"LYD": 3,
+	"OMR": 3,
+	"TND": 3,
+	// four-decimal currencies
+	"CLF": 4,
+	"UYW": 4,
+}
+
+// CurrencyExponent returns the number of decimal places of the minor unit of an ISO 4217 currency,
+// eg. 2 for USD, 0 for JPY and 3 for KWD.
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Money is an amount in the minor unit of its currency paired with the ISO 4217 currency code.
+// The minor unit is what the API calls "cents": cents for USD, yen for JPY, fils for KWD.
+//
+// The zero value is a zero amount without currency, which may be combined with any currency,
+// so it can be used as a starting point for sums.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney creates Money from an amount in minor units.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney creates Money from a decimal string in major units, eg. "12.34" USD or "1500" JPY.
+// It fails if the amount has more decimal places than the currency's minor unit allows.
+func ParseMoney(amount string, currency string) (Money, error) {
+	exp := CurrencyExponent(currency)
+	s := strings.TrimSpace(amount)
+	neg := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("chartmogul: invalid amount %q", amount)
+	}
+	trimmed := strings.TrimRight(frac, "0")
+	if len(trimmed) > exp {
+		return Money{}, fmt.Errorf("chartmogul: amount %q has more than %d decimal places for %v", amount, exp, currency)
+	}
+	digits := whole + trimmed + strings.Repeat("0", exp-len(trimmed))
+	if digits == "" {
+		digits = "0"
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("chartmogul: invalid amount %q: %v", amount, err)
+	}
+	if neg {
+		minor = -minor
+	}
+	return NewMoney(minor, currency), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MoneyFromMajor converts an amount in major units (eg. dollars) to Money, rounding half away from zero.
+func MoneyFromMajor(amount float64, currency string) Money {
+	minor := amount * math.Pow10(CurrencyExponent(currency))
+	return NewMoney(int64(math.Round(minor)), currency)
+}
+
+// Major returns the amount in major units, eg. dollars. Use only for display or statistics,
+// floats can't represent all decimal amounts exactly.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(CurrencyExponent(m.Currency))
+}
+
+// Decimal formats the amount in major units with exactly as many decimal places as the currency has,
+// eg. "12.30" for USD, "1500" for JPY, "1.250" for KWD.
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	abs := m.Amount
+	sign := ""
+	if abs < 0 {
+		sign = "-"
+		abs = -abs
+	}
+	digits := strconv.FormatUint(uint64(abs), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount with its currency code, eg. "12.30 USD".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero returns true if the amount is zero, regardless of currency.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// currency returns the currency shared by both operands.
+func (m Money) currency(o Money) (string, error) {
+	switch {
+	case strings.EqualFold(m.Currency, o.Currency):
+		return strings.ToUpper(m.Currency), nil
+	case m.Currency == "" && m.Amount == 0:
+		return o.Currency, nil
+	case o.Currency == "" && o.Amount == 0:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %v and %v", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+// Add returns the sum of both amounts. It fails with ErrCurrencyMismatch for different currencies.
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := m.currency(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: currency}, nil
+}
+
+// Sub returns the difference of both amounts. It fails with ErrCurrencyMismatch for different currencies.
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+// Cmp compares both amounts and returns -1, 0 or +1. It fails with ErrCurrencyMismatch for different currencies.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.currency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Neg returns the amount with opposite sign.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Mul returns the amount multiplied by an integer factor, eg. quantity.
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Amount returns the line item amount as Money. Line items don't carry currency,
+// pass the currency of their invoice.
+func (lineItem *LineItem) Amount(currency string) Money {
+	return NewMoney(int64(lineItem.AmountInCents), currency)
+}
+
+// SetAmount sets AmountInCents from Money.
+func (lineItem *LineItem) SetAmount(m Money) {
+	lineItem.AmountInCents = int(m.Amount)
+}
+
+// Total returns the sum of amounts of all line items in the invoice currency.
+func (invoice *Invoice) Total() Money {
+	total := NewMoney(0, invoice.Currency)
+	for _, lineItem := range invoice.LineItems {
+		total.Amount += int64(lineItem.AmountInCents)
+	}
+	return total
+}
+
+// Amount returns the transaction amount as Money. Transactions don't carry currency,
+// pass the currency of their invoice. If the amount isn't set, the transaction is for
+// the full value of the invoice and ok is false.
+func (transaction *Transaction) Amount(currency string) (m Money, ok bool) {
+	if transaction.AmountInCents == nil {
+		return NewMoney(0, currency), false
+	}
+	return NewMoney(int64(*transaction.AmountInCents), currency), true
+}
+
+// SetAmount sets AmountInCents from Money.
+func (transaction *Transaction) SetAmount(m Money) {
+	amount := int(m.Amount)
+	transaction.AmountInCents = &amount
+}
+
+// Amount returns the opportunity amount as Money.
+func (opportunity *Opportunity) Amount() Money {
+	return NewMoney(int64(opportunity.AmountInCents), opportunity.Currency)
+}
+
+// SetAmount sets AmountInCents and Currency from Money.
+func (opportunity *Opportunity) SetAmount(m Money) {
+	opportunity.AmountInCents = int(m.Amount)
+	opportunity.Currency = m.Currency
+}
+
+// Amount returns the subscription event amount as Money.
+func (subscriptionEvent *SubscriptionEvent) Amount() Money {
+	return NewMoney(int64(subscriptionEvent.AmountInCents), subscriptionEvent.Currency)
+}
+
+// SetAmount sets AmountInCents and Currency from Money.
+// It fails if the amount doesn't fit the 32-bit wire field.
+func (subscriptionEvent *SubscriptionEvent) SetAmount(m Money) error {
+	if m.Amount > math.MaxInt32 || m.Amount < math.MinInt32 {
+		return fmt.Errorf("chartmogul: amount %v out of range", m)
+	}
+	subscriptionEvent.AmountInCents = int32(m.Amount)
+	subscriptionEvent.Currency = m.Currency
+	return nil
+}
@@ -0,0 +1,124 @@
+package chartmogul
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		amount   string
+		currency string
+		expected Money
+		decimal  string
+	}{
+		{"12.34", "usd", Money{1234, "USD"}, "12.34"},
+		{"12.3", "USD", Money{1230, "USD"}, "12.30"},
+		{"-0.05", "EUR", Money{-5, "EUR"}, "-0.05"},
+		{"1500", "JPY", Money{1500, "JPY"}, "1500"},
+		{"1500.000", "JPY", Money{1500, "JPY"}, "1500"},
+		{"1.25", "KWD", Money{1250, "KWD"}, "1.250"},
+		{"0.001", "BHD", Money{1, "BHD"}, "0.001"},
+		{".5", "GBP", Money{50, "GBP"}, "0.50"},
+	}
+	for _, c := range cases {
+		m, err := ParseMoney(c.amount, c.currency)
+		if err != nil {
+			t.Errorf("%v %v: unexpected error %v", c.amount, c.currency, err)
+			continue
+		}
+		if m != c.expected {
+			t.Errorf("%v %v: expected %+v, got %+v", c.amount, c.currency, c.expected, m)
+		}
+		if m.Decimal() != c.decimal {
+			t.Errorf("%v %v: expected decimal %v, got %v", c.amount, c.currency, c.decimal, m.Decimal())
+		}
+	}
+
+	for _, invalid := range [][2]string{{"1.5", "JPY"}, {"1.2345", "KWD"}, {"1,000", "USD"}, {"", "USD"}, {"abc", "USD"}, {"-", "USD"}} {
+		if _, err := ParseMoney(invalid[0], invalid[1]); err == nil {
+			t.Errorf("%v %v: expected error", invalid[0], invalid[1])
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := NewMoney(1050, "USD")
+	b := NewMoney(250, "usd")
+
+	sum, err := a.Add(b)
+	if err != nil || sum != NewMoney(1300, "USD") {
+		t.Fatalf("Unexpected sum %v %v", sum, err)
+	}
+	diff, err := b.Sub(a)
+	if err != nil || diff.String() != "-8.00 USD" {
+		t.Fatalf("Unexpected difference %v %v", diff, err)
+	}
+	if cmp, err := a.Cmp(b); err != nil || cmp != 1 {
+		t.Fatalf("Unexpected comparison %v %v", cmp, err)
+	}
+	if a.Mul(3).Amount != 3150 {
+		t.Fatalf("Unexpected product %v", a.Mul(3))
+	}
+
+	var total Money
+	if total, err = total.Add(a); err != nil || total != a {
+		t.Fatalf("Zero value should adopt currency, got %v %v", total, err)
+	}
+
+	if _, err := a.Add(NewMoney(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("Expected currency mismatch, got %v", err)
+	}
+	if _, err := a.Cmp(NewMoney(1, "JPY")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("Expected currency mismatch, got %v", err)
+	}
+}
+
+func TestMoneyFromMajor(t *testing.T) {
+	if m := MoneyFromMajor(19.99, "USD"); m.Amount != 1999 {
+		t.Errorf("Unexpected %v", m)
+	}
+	if m := MoneyFromMajor(1999, "JPY"); m.Amount != 1999 || m.Major() != 1999 {
+		t.Errorf("Unexpected %v", m)
+	}
+	if m := MoneyFromMajor(1.2345, "KWD"); m.Amount != 1235 || m.String() != "1.235 KWD" {
+		t.Errorf("Unexpected %v", m)
+	}
+}
+
+func TestMoneyWireHelpers(t *testing.T) {
+	invoice := &Invoice{
+		Currency:  "JPY",
+		LineItems: []*LineItem{{AmountInCents: 1000}, {AmountInCents: 500}},
+	}
+	if total := invoice.Total(); total.String() != "1500 JPY" {
+		t.Errorf("Unexpected invoice total %v", total)
+	}
+	invoice.LineItems[0].SetAmount(NewMoney(700, "JPY"))
+	if invoice.LineItems[0].Amount(invoice.Currency) != NewMoney(700, "JPY") {
+		t.Errorf("Unexpected line item amount %v", invoice.LineItems[0].AmountInCents)
+	}
+
+	transaction := &Transaction{}
+	if _, ok := transaction.Amount("USD"); ok {
+		t.Error("Transaction without amount should not be ok")
+	}
+	transaction.SetAmount(NewMoney(995, "USD"))
+	if m, ok := transaction.Amount("USD"); !ok || m.Amount != 995 {
+		t.Errorf("Unexpected transaction amount %v", m)
+	}
+
+	opportunity := &Opportunity{}
+	opportunity.SetAmount(NewMoney(12345, "KWD"))
+	if opportunity.Currency != "KWD" || opportunity.Amount().Decimal() != "12.345" {
+		t.Errorf("Unexpected opportunity amount %+v", opportunity)
+	}
+
+	event := &SubscriptionEvent{}
+	if err := event.SetAmount(NewMoney(1<<40, "USD")); err == nil {
+		t.Error("Expected out of range error")
+	}
+	if err := event.SetAmount(NewMoney(-500, "EUR")); err != nil || event.Amount() != NewMoney(-500, "EUR") {
+		t.Errorf("Unexpected subscription event amount %+v %v", event, err)
+	}
+}
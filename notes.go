@@ -4,22 +4,22 @@ package chartmogul
 type Note struct {
 	UUID string `json:"uuid"`
 	// Basic info
-	CustomerUUID string `json:"customer_uuid"`
-	Type         string `json:"type"`
-	Text         string `json:"text"`
-	Author       string `json:"author"`
-	CallDuration uint32 `json:"call_duration"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
+	CustomerUUID string    `json:"customer_uuid"`
+	Type         string    `json:"type"`
+	Text         string    `json:"text"`
+	Author       string    `json:"author"`
+	CallDuration uint32    `json:"call_duration"`
+	CreatedAt    Timestamp `json:"created_at"`
+	UpdatedAt    Timestamp `json:"updated_at"`
 }
 
 // UpdateNote allows updating note on the update endpoint.
 type UpdateNote struct {
-	Text         string `json:"text,omitempty"`
-	AuthorEmail  string `json:"author_email,omitempty"`
-	CallDuration uint32 `json:"call_duration,omitempty"`
-	CreatedAt    string `json:"created_at,omitempty"`
-	UpdatedAt    string `json:"updated_at,omitempty"`
+	Text         string    `json:"text,omitempty"`
+	AuthorEmail  string    `json:"author_email,omitempty"`
+	CallDuration uint32    `json:"call_duration,omitempty"`
+	CreatedAt    Timestamp `json:"created_at,omitempty"`
+	UpdatedAt    Timestamp `json:"updated_at,omitempty"`
 }
 
 // NewNote allows creating note on a new endpoint.
@@ -29,11 +29,11 @@ type NewNote struct {
 	Type         string `json:"type"`
 
 	//Optional
-	AuthorEmail  string `json:"author_email,omitempty"`
-	Text         string `json:"text,omitempty"`
-	CallDuration uint32 `json:"call_duration,omitempty"`
-	CreatedAt    string `json:"created_at,omitempty"`
-	UpdatedAt    string `json:"updated_at,omitempty"`
+	AuthorEmail  string    `json:"author_email,omitempty"`
+	Text         string    `json:"text,omitempty"`
+	CallDuration uint32    `json:"call_duration,omitempty"`
+	CreatedAt    Timestamp `json:"created_at,omitempty"`
+	UpdatedAt    Timestamp `json:"updated_at,omitempty"`
 }
 
 // ListNoteParams = parameters for listing customer notes in API.
@@ -7,22 +7,22 @@ type Opportunity struct {
 	Owner              string `json:"owner"`
 	Pipeline           string `json:"pipeline"`
 	PipelineStage      string `json:"pipeline_stage"`
-	EstimatedCloseDate string `json:"estimated_close_date"`
+	EstimatedCloseDate Date   `json:"estimated_close_date"`
 	Currency           string `json:"currency"`
 	AmountInCents      int    `json:"amount_in_cents"`
 	Type               string `json:"type"`
 	ForecastCategory   string `json:"forecast_category"`
 	WinLikelihood      int    `json:"win_likelihood"`
 	Custom             map[string]interface{}  `json:"custom,omitempty"`
-	CreatedAt          string `json:"created_at"`
-	UpdatedAt          string `json:"updated_at"`
+	CreatedAt          Timestamp `json:"created_at"`
+	UpdatedAt          Timestamp `json:"updated_at"`
 }
 
 type UpdateOpportunity struct {
 	Owner              string `json:"owner,omitempty"`
 	Pipeline           string `json:"pipeline,omitempty"`
 	PipelineStage      string `json:"pipeline_stage,omitempty"`
-	EstimatedCloseDate string `json:"estimated_close_date,omitempty"`
+	EstimatedCloseDate Date   `json:"estimated_close_date,omitempty"`
 	Currency           string `json:"currency,omitempty"`
 	AmountInCents      int    `json:"amount_in_cents,omitempty"`
 	Type               string `json:"type,omitempty"`
@@ -37,7 +37,7 @@ type NewOpportunity struct {
 	Owner              string `json:"owner"`
 	Pipeline           string `json:"pipeline"`
 	PipelineStage      string `json:"pipeline_stage"`
-	EstimatedCloseDate string `json:"estimated_close_date"`
+	EstimatedCloseDate Date   `json:"estimated_close_date"`
 	Currency           string `json:"currency"`
 	AmountInCents      int    `json:"amount_in_cents"`
 
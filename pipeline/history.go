@@ -0,0 +1,298 @@
+package pipeline
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// EventType is what changed about an opportunity between two snapshots.
+type EventType string
+
+// Types of events.
+const (
+	Created          EventType = "created"
+	StageChanged     EventType = "stage_changed"
+	CloseDateChanged EventType = "close_date_changed"
+	Removed          EventType = "removed"
+)
+
+// Event is a change of an opportunity seen between two snapshots. Its time is when the opportunity was
+// created or updated if that's between the snapshots, else when the later snapshot was taken.
+// Moves to another pipeline are stage changes with the new pipeline, FromPipeline is the previous one.
+type Event struct {
+	Time            time.Time `json:"time"`
+	Type            EventType `json:"type"`
+	OpportunityUUID string    `json:"opportunity-uuid"`
+	Pipeline        string    `json:"pipeline"`
+	FromPipeline    string    `json:"from-pipeline,omitempty"`
+	FromStage       string    `json:"from-stage,omitempty"`
+	ToStage         string    `json:"to-stage,omitempty"`
+	FromCloseDate   cm.Date   `json:"from-close-date,omitempty"`
+	ToCloseDate     cm.Date   `json:"to-close-date,omitempty"`
+}
+
+// Slip returns the days the close date moved, negative if it moved earlier.
+func (e *Event) Slip() int {
+	from, err := e.FromCloseDate.Time()
+	if err != nil {
+		return 0
+	}
+	to, err := e.ToCloseDate.Time()
+	if err != nil {
+		return 0
+	}
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// Tracker snapshots the opportunities into a store, so their changes can be followed,
+// as ChartMogul returns only their current stage.
+type Tracker struct {
+	// Params filter the opportunities, all if nil.
+	Params *cm.ListOpportunitiesParams
+	// OnError receives the errors of scheduled snapshots, they're dropped if nil.
+	OnError func(error)
+
+	client Client
+	store  Store
+}
+
+// NewTracker creates a tracker saving snapshots to the store, in memory if nil.
+func NewTracker(client Client, store Store) *Tracker {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Tracker{client: client, store: store}
+}
+
+// Snapshot lists the opportunities and saves them.
+func (t *Tracker) Snapshot() (*Snapshot, error) {
+	at := now().UTC()
+	opportunities, err := ListAll(t.client, t.Params)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{Time: at, Opportunities: opportunities}
+	if err := t.store.Save(snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Run takes a snapshot right away and then every period until the context is done.
+func (t *Tracker) Run(ctx context.Context, every time.Duration) error {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if _, err := t.Snapshot(); err != nil && t.OnError != nil {
+			t.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Events loads the saved snapshots and returns their changes, see History.
+func (t *Tracker) Events() ([]*Event, error) {
+	snapshots, err := t.store.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+	return History(snapshots), nil
+}
+
+// History diffs each snapshot with the one before and returns the events in chronological order.
+// The opportunities of the first snapshot are the starting point and don't create events.
+func History(snapshots []*Snapshot) []*Event {
+	events := []*Event{}
+	for i := 1; i < len(snapshots); i++ {
+		events = append(events, Diff(snapshots[i-1], snapshots[i])...)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
+
+// Diff returns the changes of the opportunities from one snapshot to a later one.
+func Diff(from, to *Snapshot) []*Event {
+	before := map[string]*cm.Opportunity{}
+	for _, o := range from.Opportunities {
+		before[o.UUID] = o
+	}
+	between := func(ts cm.Timestamp) time.Time {
+		if t, err := ts.Time(); err == nil && t.After(from.Time) && !t.After(to.Time) {
+			return t
+		}
+		return to.Time
+	}
+
+	events := []*Event{}
+	seen := map[string]bool{}
+	for _, o := range to.Opportunities {
+		seen[o.UUID] = true
+		previous, ok := before[o.UUID]
+		if !ok {
+			events = append(events, &Event{Time: between(o.CreatedAt), Type: Created, OpportunityUUID: o.UUID,
+				Pipeline: o.Pipeline, ToStage: o.PipelineStage, ToCloseDate: o.EstimatedCloseDate})
+			continue
+		}
+		at := between(o.UpdatedAt)
+		if previous.Pipeline != o.Pipeline || previous.PipelineStage != o.PipelineStage {
+			events = append(events, &Event{Time: at, Type: StageChanged, OpportunityUUID: o.UUID,
+				Pipeline: o.Pipeline, FromPipeline: previous.Pipeline, FromStage: previous.PipelineStage, ToStage: o.PipelineStage})
+		}
+		if previous.EstimatedCloseDate != o.EstimatedCloseDate {
+			events = append(events, &Event{Time: at, Type: CloseDateChanged, OpportunityUUID: o.UUID,
+				Pipeline: o.Pipeline, FromCloseDate: previous.EstimatedCloseDate, ToCloseDate: o.EstimatedCloseDate})
+		}
+	}
+	for _, o := range from.Opportunities {
+		if !seen[o.UUID] {
+			events = append(events, &Event{Time: to.Time, Type: Removed, OpportunityUUID: o.UUID,
+				Pipeline: o.Pipeline, FromStage: o.PipelineStage, FromCloseDate: o.EstimatedCloseDate})
+		}
+	}
+	return events
+}
+
+// StageTime is how long opportunities stayed in a stage before moving on.
+type StageTime struct {
+	Pipeline string        `json:"pipeline"`
+	Stage    string        `json:"stage"`
+	Count    int           `json:"count"`
+	Total    time.Duration `json:"total"`
+}
+
+// Average returns the average time in the stage.
+func (s *StageTime) Average() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// TimeInStage sums the stays in each stage, sorted by pipeline and stage. Only stays seen from
+// start to end count, ie. not the stages of the first snapshot nor of opportunities still in them.
+func TimeInStage(events []*Event) []*StageTime {
+	type stay struct {
+		pipeline, stage string
+		since           time.Time
+	}
+	stays := map[string]stay{}
+	index := map[[2]string]*StageTime{}
+	times := []*StageTime{}
+	for _, e := range events {
+		switch e.Type {
+		case Created:
+			stays[e.OpportunityUUID] = stay{e.Pipeline, e.ToStage, e.Time}
+		case StageChanged:
+			if s, ok := stays[e.OpportunityUUID]; ok {
+				key := [2]string{s.pipeline, s.stage}
+				if index[key] == nil {
+					index[key] = &StageTime{Pipeline: s.pipeline, Stage: s.stage}
+					times = append(times, index[key])
+				}
+				index[key].Count++
+				index[key].Total += e.Time.Sub(s.since)
+			}
+			stays[e.OpportunityUUID] = stay{e.Pipeline, e.ToStage, e.Time}
+		case Removed:
+			delete(stays, e.OpportunityUUID)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		if times[i].Pipeline != times[j].Pipeline {
+			return times[i].Pipeline < times[j].Pipeline
+		}
+		return times[i].Stage < times[j].Stage
+	})
+	return times
+}
+
+// Conversion counts the moves from one stage to another, in the same pipeline unless ToPipeline is set.
+type Conversion struct {
+	Pipeline   string `json:"pipeline"`
+	From       string `json:"from"`
+	ToPipeline string `json:"to-pipeline,omitempty"`
+	To         string `json:"to"`
+	Count      int    `json:"count"`
+	// Rate is the percentage of the opportunities entering the From stage which moved to the To stage.
+	Rate float64 `json:"rate"`
+}
+
+// Conversions counts the stage changes, sorted by pipeline, from and to stage. The rates are per
+// opportunity entering a stage, being created in it or moved into it, eg. to a won stage for the
+// win rate of the stage; the rest stayed in the stage, went elsewhere or was removed. Opportunities
+// already in a stage on the first snapshot count as entering it when they leave it or are removed.
+func Conversions(events []*Event) []*Conversion {
+	type conversionKey struct{ pipeline, from, toPipeline, to string }
+	index := map[conversionKey]*Conversion{}
+	entries := map[[2]string]int{}
+	stages := map[string][2]string{}
+	conversions := []*Conversion{}
+	// leave counts the entry of an opportunity seen in a stage without having entered it
+	leave := func(uuid string, stage [2]string) {
+		if current, ok := stages[uuid]; !ok || current != stage {
+			entries[stage]++
+		}
+	}
+	for _, e := range events {
+		switch e.Type {
+		case Created:
+			stages[e.OpportunityUUID] = [2]string{e.Pipeline, e.ToStage}
+			entries[stages[e.OpportunityUUID]]++
+		case StageChanged:
+			from := e.FromPipeline
+			if from == "" {
+				from = e.Pipeline
+			}
+			leave(e.OpportunityUUID, [2]string{from, e.FromStage})
+			key := conversionKey{from, e.FromStage, "", e.ToStage}
+			if e.Pipeline != from {
+				key.toPipeline = e.Pipeline
+			}
+			if index[key] == nil {
+				index[key] = &Conversion{Pipeline: key.pipeline, From: key.from, ToPipeline: key.toPipeline, To: key.to}
+				conversions = append(conversions, index[key])
+			}
+			index[key].Count++
+			stages[e.OpportunityUUID] = [2]string{e.Pipeline, e.ToStage}
+			entries[stages[e.OpportunityUUID]]++
+		case Removed:
+			leave(e.OpportunityUUID, [2]string{e.Pipeline, e.FromStage})
+			delete(stages, e.OpportunityUUID)
+		}
+	}
+	for _, c := range conversions {
+		c.Rate = float64(c.Count) * 100 / float64(entries[[2]string{c.Pipeline, c.From}])
+	}
+	sort.Slice(conversions, func(i, j int) bool {
+		a, b := conversions[i], conversions[j]
+		if a.Pipeline != b.Pipeline {
+			return a.Pipeline < b.Pipeline
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.ToPipeline != b.ToPipeline {
+			return a.ToPipeline < b.ToPipeline
+		}
+		return a.To < b.To
+	})
+	return conversions
+}
+
+// Slipped returns the close date changes to a later date.
+func Slipped(events []*Event) []*Event {
+	slipped := []*Event{}
+	for _, e := range events {
+		if e.Type == CloseDateChanged && e.Slip() > 0 {
+			slipped = append(slipped, e)
+		}
+	}
+	return slipped
+}
@@ -0,0 +1,138 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// changingClient returns the next state of the opportunities on each snapshot.
+type changingClient struct {
+	states [][]*cm.Opportunity
+	calls  int
+}
+
+func (c *changingClient) ListOpportunities(params *cm.ListOpportunitiesParams) (*cm.Opportunities, error) {
+	if c.calls >= len(c.states) {
+		return nil, errors.New("no more states")
+	}
+	c.calls++
+	return &cm.Opportunities{Entries: c.states[c.calls-1]}, nil
+}
+
+func TestTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { now = time.Now }()
+
+	opportunity := func(uuid, stage string, closeDate cm.Date, updatedAt cm.Timestamp) *cm.Opportunity {
+		return &cm.Opportunity{UUID: uuid, Pipeline: "New", PipelineStage: stage, EstimatedCloseDate: closeDate,
+			CreatedAt: "2022-03-05T00:00:00Z", UpdatedAt: updatedAt}
+	}
+	client := &changingClient{states: [][]*cm.Opportunity{
+		{opportunity("o1", "Demo", "2022-03-31", ""), opportunity("o2", "Demo", "2022-03-31", "")},
+		{opportunity("o1", "Proposal", "2022-04-15", "2022-03-04T00:00:00Z"), opportunity("o2", "Demo", "2022-03-31", ""),
+			opportunity("o3", "Demo", "2022-03-31", "2022-03-05T00:00:00Z")},
+		{opportunity("o1", "Won", "2022-04-15", ""), opportunity("o3", "Proposal", "2022-03-31", "2022-03-10T00:00:00Z")},
+		{opportunity("o1", "Won", "2022-04-15", ""), opportunity("o3", "Lost", "2022-03-20", "")},
+	}}
+	tracker := NewTracker(client, NewFileStore(filepath.Join(dir, "snapshots.jsonl")))
+	for day := 1; day <= 22; day += 7 {
+		at := time.Date(2022, 3, day, 0, 0, 0, 0, time.UTC)
+		now = func() time.Time { return at }
+		if _, err := tracker.Snapshot(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := tracker.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		day  int
+		typ  EventType
+		uuid string
+	}{
+		{4, StageChanged, "o1"}, {4, CloseDateChanged, "o1"}, {5, Created, "o3"}, {10, StageChanged, "o3"},
+		{15, StageChanged, "o1"}, {15, Removed, "o2"}, {22, StageChanged, "o3"}, {22, CloseDateChanged, "o3"},
+	}
+	if len(events) != len(expected) {
+		spew.Dump(events)
+		t.Fatal("Unexpected events")
+	}
+	for i, e := range expected {
+		if events[i].Time.Day() != e.day || events[i].Type != e.typ || events[i].OpportunityUUID != e.uuid {
+			spew.Dump(events)
+			t.Fatalf("Unexpected event %v", i)
+		}
+	}
+
+	times := TimeInStage(events)
+	day := 24 * time.Hour
+	if len(times) != 2 || times[0].Stage != "Demo" || times[0].Count != 1 || times[0].Total != 5*day ||
+		times[1].Stage != "Proposal" || times[1].Count != 2 || times[1].Average() != 11*day+12*time.Hour {
+		spew.Dump(times)
+		t.Fatal("Unexpected time in stage")
+	}
+
+	// o1, o2 and o3 entered Demo, o1 and o2 being there on the first snapshot; o1 and o3 entered Proposal.
+	conversions := Conversions(events)
+	if len(conversions) != 3 || conversions[0].To != "Proposal" || conversions[0].Count != 2 || conversions[0].Rate != 200.0/3 ||
+		conversions[1].To != "Lost" || conversions[1].Rate != 50 || conversions[2].To != "Won" || conversions[2].Rate != 50 {
+		spew.Dump(conversions)
+		t.Fatal("Unexpected conversions")
+	}
+
+	slipped := Slipped(events)
+	if len(slipped) != 1 || slipped[0].OpportunityUUID != "o1" || slipped[0].Slip() != 15 {
+		spew.Dump(slipped)
+		t.Fatal("Unexpected slipped close dates")
+	}
+}
+
+func TestConversionsAcrossPipelines(t *testing.T) {
+	at := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	events := []*Event{
+		{Time: at, Type: Created, OpportunityUUID: "o1", Pipeline: "New", ToStage: "Demo"},
+		{Time: at, Type: Created, OpportunityUUID: "o2", Pipeline: "New", ToStage: "Demo"},
+		{Time: at.Add(time.Hour), Type: StageChanged, OpportunityUUID: "o1", Pipeline: "Expansion", FromPipeline: "New",
+			FromStage: "Demo", ToStage: "Demo"},
+		{Time: at.Add(2 * time.Hour), Type: StageChanged, OpportunityUUID: "o1", Pipeline: "Expansion", FromPipeline: "Expansion",
+			FromStage: "Demo", ToStage: "Won"},
+	}
+	conversions := Conversions(events)
+	if len(conversions) != 2 ||
+		conversions[0].Pipeline != "Expansion" || conversions[0].From != "Demo" || conversions[0].To != "Won" || conversions[0].Rate != 100 ||
+		conversions[1].Pipeline != "New" || conversions[1].From != "Demo" || conversions[1].ToPipeline != "Expansion" ||
+		conversions[1].To != "Demo" || conversions[1].Rate != 50 {
+		spew.Dump(conversions)
+		t.Fatal("Unexpected conversions across pipelines")
+	}
+}
+
+func TestTrackerRun(t *testing.T) {
+	tracker := NewTracker(&changingClient{states: [][]*cm.Opportunity{{{UUID: "o1"}}}}, nil)
+	var errs []error
+	tracker.OnError = func(err error) { errs = append(errs, err) }
+	ctx, cancel := context.WithTimeout(context.Background(), 35*time.Millisecond)
+	defer cancel()
+	if err := tracker.Run(ctx, 10*time.Millisecond); err != context.DeadlineExceeded {
+		t.Fatal(err)
+	}
+	snapshots, err := tracker.store.Snapshots()
+	if err != nil || len(snapshots) != 1 || len(errs) == 0 {
+		spew.Dump(err, snapshots, errs)
+		t.Fatal("Expected one snapshot and then errors")
+	}
+}
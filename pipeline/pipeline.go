@@ -0,0 +1,267 @@
+// Package pipeline aggregates opportunities into pipeline and forecast reports in one currency,
+// eg. weighted pipeline by close month and owner, forecast categories and coverage of a target,
+// and tracks the stage changes of opportunities in snapshots.
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// now is the date amounts are converted on, replaced in tests.
+var now = time.Now
+
+// Forecast categories of opportunities, see cm.Opportunity.ForecastCategory.
+const (
+	CategoryPipeline  = "pipeline"
+	CategoryBestCase  = "best_case"
+	CategoryCommitted = "committed"
+	CategoryWon       = "won"
+	CategoryLost      = "lost"
+)
+
+// Client lists opportunities, eg. *chartmogul.API.
+type Client interface {
+	ListOpportunities(listOpportunitiesParams *cm.ListOpportunitiesParams) (*cm.Opportunities, error)
+}
+
+// Converter converts money into the report's currency on a date, eg. *currency.Converter.
+type Converter interface {
+	Money(m cm.Money, date cm.Date) (cm.Money, error)
+}
+
+// Dimension to group the open pipeline by.
+type Dimension string
+
+// Dimensions of opportunities.
+const (
+	ByCloseMonth Dimension = "close_month"
+	ByOwner      Dimension = "owner"
+	ByPipeline   Dimension = "pipeline"
+	ByStage      Dimension = "stage"
+)
+
+// Amounts sum opportunities in minor units of the report's currency.
+type Amounts struct {
+	Count      int   `json:"count"`
+	Unweighted int64 `json:"unweighted"`
+	// Weighted are the amounts times their win likelihood.
+	Weighted int64 `json:"weighted"`
+}
+
+func (a *Amounts) add(o *Opportunity) {
+	a.Count++
+	a.Unweighted += o.Converted
+	a.Weighted += o.Weighted
+}
+
+// Group is the open pipeline of one value of a dimension, eg. an owner.
+type Group struct {
+	Key string `json:"key"`
+	Amounts
+}
+
+// Opportunity is an opportunity with its amount in the report's currency.
+type Opportunity struct {
+	*cm.Opportunity
+	// Converted is the amount in minor units of the report's currency, Weighted times the win likelihood.
+	Converted int64 `json:"converted"`
+	Weighted  int64 `json:"weighted"`
+}
+
+// Open is true for opportunities neither won nor lost.
+func (o *Opportunity) Open() bool {
+	return o.ForecastCategory != CategoryWon && o.ForecastCategory != CategoryLost
+}
+
+// Forecast sums opportunities by forecast category, each category apart.
+// Opportunities with another category count as pipeline.
+type Forecast struct {
+	Won       Amounts `json:"won"`
+	Committed Amounts `json:"committed"`
+	BestCase  Amounts `json:"best-case"`
+	Pipeline  Amounts `json:"pipeline"`
+}
+
+// Report is the pipeline of opportunities in one currency.
+type Report struct {
+	Currency      string         `json:"currency"`
+	Opportunities []*Opportunity `json:"opportunities"`
+}
+
+// ListAll lists all opportunities matching the parameters, which can be nil, page by page.
+func ListAll(client Client, params *cm.ListOpportunitiesParams) ([]*cm.Opportunity, error) {
+	p := &cm.ListOpportunitiesParams{}
+	if params != nil {
+		*p = *params
+	}
+	var opportunities []*cm.Opportunity
+	for {
+		result, err := client.ListOpportunities(p)
+		if err != nil {
+			return nil, err
+		}
+		opportunities = append(opportunities, result.Entries...)
+		if !result.HasMore || result.Cursor == "" {
+			return opportunities, nil
+		}
+		p.Cursor.Cursor = result.Cursor
+	}
+}
+
+// NewReport converts the amounts of the opportunities into the currency at today's rates.
+// The converter can be nil if all opportunities are in the currency.
+func NewReport(opportunities []*cm.Opportunity, currency string, converter Converter) (*Report, error) {
+	r := &Report{Currency: cm.NewMoney(0, currency).Currency, Opportunities: make([]*Opportunity, len(opportunities))}
+	today := cm.NewDate(now().UTC())
+	for i, opportunity := range opportunities {
+		amount := opportunity.Amount()
+		if amount.Currency != r.Currency {
+			if converter == nil {
+				return nil, fmt.Errorf("chartmogul: opportunity %v is in %v, not %v", opportunity.UUID, amount.Currency, r.Currency)
+			}
+			converted, err := converter.Money(amount, today)
+			if err != nil {
+				return nil, fmt.Errorf("chartmogul: opportunity %v: %v", opportunity.UUID, err)
+			}
+			if converted.Currency != r.Currency {
+				return nil, fmt.Errorf("chartmogul: opportunity %v was converted into %v, not %v", opportunity.UUID, converted.Currency, r.Currency)
+			}
+			amount = converted
+		}
+		r.Opportunities[i] = &Opportunity{
+			Opportunity: opportunity,
+			Converted:   amount.Amount,
+			Weighted:    int64(math.Round(float64(amount.Amount) * float64(opportunity.WinLikelihood) / 100)),
+		}
+	}
+	return r, nil
+}
+
+// Open returns the open pipeline.
+func (r *Report) Open() Amounts {
+	var open Amounts
+	for _, o := range r.Opportunities {
+		if o.Open() {
+			open.add(o)
+		}
+	}
+	return open
+}
+
+// By groups the open pipeline by the dimension, sorted by key. Close months are formatted as "2006-01",
+// empty for opportunities without a valid estimated close date.
+func (r *Report) By(dimension Dimension) ([]*Group, error) {
+	var key func(o *Opportunity) string
+	switch dimension {
+	case ByCloseMonth:
+		key = func(o *Opportunity) string {
+			t, err := o.EstimatedCloseDate.Time()
+			if err != nil {
+				return ""
+			}
+			return t.Format("2006-01")
+		}
+	case ByOwner:
+		key = func(o *Opportunity) string { return o.Owner }
+	case ByPipeline:
+		key = func(o *Opportunity) string { return o.Pipeline }
+	case ByStage:
+		key = func(o *Opportunity) string { return o.PipelineStage }
+	default:
+		return nil, fmt.Errorf("chartmogul: unknown dimension %q", dimension)
+	}
+	index := map[string]*Group{}
+	groups := []*Group{}
+	for _, o := range r.Opportunities {
+		if !o.Open() {
+			continue
+		}
+		k := key(o)
+		if index[k] == nil {
+			index[k] = &Group{Key: k}
+			groups = append(groups, index[k])
+		}
+		index[k].add(o)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups, nil
+}
+
+// Forecast sums the opportunities closing within the dates, both inclusive, by forecast category.
+// Zero dates leave the range open; opportunities without a valid close date only count in an open range.
+func (r *Report) Forecast(from, to cm.Date) *Forecast {
+	f := &Forecast{}
+	for _, o := range r.Opportunities {
+		if !closesWithin(o, from, to) {
+			continue
+		}
+		switch o.ForecastCategory {
+		case CategoryWon:
+			f.Won.add(o)
+		case CategoryLost:
+		case CategoryCommitted:
+			f.Committed.add(o)
+		case CategoryBestCase:
+			f.BestCase.add(o)
+		default:
+			f.Pipeline.add(o)
+		}
+	}
+	return f
+}
+
+// Coverage compares the open pipeline with the part of a target not yet won.
+type Coverage struct {
+	Target int64 `json:"target"`
+	Won    int64 `json:"won"`
+	// Remaining is the target less won, not below zero.
+	Remaining int64   `json:"remaining"`
+	Open      Amounts `json:"open"`
+	// Ratio and WeightedRatio are the unweighted and weighted open pipeline per remaining target,
+	// eg. 3 for a pipeline three times the remaining target; zero if nothing remains.
+	Ratio         float64 `json:"ratio"`
+	WeightedRatio float64 `json:"weighted-ratio"`
+}
+
+// Coverage compares the pipeline of opportunities closing within the dates, see Forecast,
+// with the target in the report's currency.
+func (r *Report) Coverage(target cm.Money, from, to cm.Date) (*Coverage, error) {
+	if target.Currency != r.Currency {
+		return nil, fmt.Errorf("chartmogul: target is in %v, not %v", target.Currency, r.Currency)
+	}
+	f := r.Forecast(from, to)
+	c := &Coverage{Target: target.Amount, Won: f.Won.Unweighted}
+	for _, a := range []Amounts{f.Committed, f.BestCase, f.Pipeline} {
+		c.Open.Count += a.Count
+		c.Open.Unweighted += a.Unweighted
+		c.Open.Weighted += a.Weighted
+	}
+	if c.Target > c.Won {
+		c.Remaining = c.Target - c.Won
+		c.Ratio = float64(c.Open.Unweighted) / float64(c.Remaining)
+		c.WeightedRatio = float64(c.Open.Weighted) / float64(c.Remaining)
+	}
+	return c, nil
+}
+
+func closesWithin(o *Opportunity, from, to cm.Date) bool {
+	if from.IsZero() && to.IsZero() {
+		return true
+	}
+	t, err := o.EstimatedCloseDate.Time()
+	if err != nil {
+		return false
+	}
+	if f, err := from.Time(); err == nil && t.Before(f) {
+		return false
+	}
+	if e, err := to.Time(); err == nil && t.After(e) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+type fakeClient struct {
+	pages [][]*cm.Opportunity
+}
+
+func (c *fakeClient) ListOpportunities(params *cm.ListOpportunitiesParams) (*cm.Opportunities, error) {
+	page := 0
+	if params.Cursor.Cursor == "c2" {
+		page = 1
+	}
+	result := &cm.Opportunities{Entries: c.pages[page]}
+	if page == 0 {
+		result.HasMore, result.Cursor = true, "c2"
+	}
+	return result, nil
+}
+
+// fakeConverter converts euros into dollars at 1.1 on the date it expects.
+type fakeConverter struct{}
+
+func (fakeConverter) Money(m cm.Money, date cm.Date) (cm.Money, error) {
+	if m.Currency != "EUR" || date != "2022-03-15" {
+		return cm.Money{}, errors.New("no rate")
+	}
+	return cm.NewMoney(m.Amount*11/10, "USD"), nil
+}
+
+func opportunities() []*cm.Opportunity {
+	return []*cm.Opportunity{
+		{UUID: "o1", Owner: "ann", Pipeline: "New", PipelineStage: "Demo", ForecastCategory: "pipeline",
+			WinLikelihood: 20, AmountInCents: 100000, Currency: "USD", EstimatedCloseDate: "2022-04-10"},
+		{UUID: "o2", Owner: "bob", Pipeline: "New", PipelineStage: "Proposal", ForecastCategory: "committed",
+			WinLikelihood: 80, AmountInCents: 50000, Currency: "EUR", EstimatedCloseDate: "2022-03-20"},
+		{UUID: "o3", Owner: "ann", Pipeline: "Expansion", PipelineStage: "Demo", ForecastCategory: "best_case",
+			WinLikelihood: 50, AmountInCents: 20000, Currency: "USD", EstimatedCloseDate: "2022-03-31"},
+		{UUID: "o4", Owner: "bob", Pipeline: "New", PipelineStage: "Won", ForecastCategory: "won",
+			WinLikelihood: 100, AmountInCents: 30000, Currency: "USD", EstimatedCloseDate: "2022-03-02"},
+		{UUID: "o5", Owner: "ann", Pipeline: "New", PipelineStage: "Lost", ForecastCategory: "lost",
+			AmountInCents: 90000, Currency: "USD", EstimatedCloseDate: "2022-03-05"},
+	}
+}
+
+func TestReport(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 3, 15, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	all := opportunities()
+	listed, err := ListAll(&fakeClient{pages: [][]*cm.Opportunity{all[:2], all[2:]}}, nil)
+	if err != nil || len(listed) != 5 {
+		spew.Dump(err, listed)
+		t.Fatal("Expected both pages")
+	}
+
+	if _, err := NewReport(listed, "USD", nil); err == nil {
+		t.Fatal("Expected euros to need a converter")
+	}
+	r, err := NewReport(listed, "usd", fakeConverter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Currency != "USD" || r.Opportunities[1].Converted != 55000 || r.Opportunities[1].Weighted != 44000 {
+		spew.Dump(r)
+		t.Fatal("Unexpected conversion")
+	}
+	if open := r.Open(); open != (Amounts{Count: 3, Unweighted: 175000, Weighted: 74000}) {
+		spew.Dump(open)
+		t.Fatal("Unexpected open pipeline")
+	}
+
+	expected := map[Dimension][]Group{
+		ByCloseMonth: {{"2022-03", Amounts{2, 75000, 54000}}, {"2022-04", Amounts{1, 100000, 20000}}},
+		ByOwner:      {{"ann", Amounts{2, 120000, 30000}}, {"bob", Amounts{1, 55000, 44000}}},
+		ByPipeline:   {{"Expansion", Amounts{1, 20000, 10000}}, {"New", Amounts{2, 155000, 64000}}},
+		ByStage:      {{"Demo", Amounts{2, 120000, 30000}}, {"Proposal", Amounts{1, 55000, 44000}}},
+	}
+	for dimension, groups := range expected {
+		actual, err := r.By(dimension)
+		if err != nil || len(actual) != len(groups) {
+			spew.Dump(err, actual)
+			t.Fatalf("Unexpected groups by %v", dimension)
+		}
+		for i := range groups {
+			if *actual[i] != groups[i] {
+				spew.Dump(actual)
+				t.Fatalf("Unexpected groups by %v", dimension)
+			}
+		}
+	}
+	if _, err := r.By("country"); err == nil {
+		t.Fatal("Expected an unknown dimension to fail")
+	}
+
+	f := r.Forecast("2022-03-01", "2022-03-31")
+	if f.Won.Unweighted != 30000 || f.Committed.Unweighted != 55000 || f.BestCase.Weighted != 10000 || f.Pipeline.Count != 0 {
+		spew.Dump(f)
+		t.Fatal("Unexpected forecast")
+	}
+
+	c, err := r.Coverage(cm.NewMoney(50000, "USD"), "2022-03-01", "2022-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Remaining != 20000 || c.Open.Unweighted != 75000 || c.Ratio != 3.75 || c.WeightedRatio != 2.7 {
+		spew.Dump(c)
+		t.Fatal("Unexpected coverage")
+	}
+	if _, err := r.Coverage(cm.NewMoney(50000, "EUR"), "", ""); err == nil {
+		t.Fatal("Expected a target in another currency to fail")
+	}
+}
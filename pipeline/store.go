@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Snapshot is the state of the opportunities at a time.
+type Snapshot struct {
+	Time          time.Time         `json:"time"`
+	Opportunities []*cm.Opportunity `json:"opportunities"`
+}
+
+// Store keeps the snapshots of a tracker, eg. in memory, a file or a database.
+type Store interface {
+	// Save adds a snapshot taken after the saved ones.
+	Save(snapshot *Snapshot) error
+	// Snapshots returns the saved snapshots in the order they were taken.
+	Snapshots() ([]*Snapshot, error)
+}
+
+// MemoryStore keeps the snapshots until the process ends.
+type MemoryStore struct {
+	mu        sync.Mutex
+	snapshots []*Snapshot
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Save adds the snapshot.
+func (s *MemoryStore) Save(snapshot *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, snapshot)
+	return nil
+}
+
+// Snapshots returns the saved snapshots.
+func (s *MemoryStore) Snapshots() ([]*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Snapshot(nil), s.snapshots...), nil
+}
+
+// FileStore appends the snapshots to a file as JSON lines, so the history survives restarts.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore creates a store in the file, which doesn't need to exist yet.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Save appends the snapshot to the file.
+func (s *FileStore) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Snapshots reads the snapshots from the file, none if it doesn't exist yet.
+func (s *FileStore) Snapshots() ([]*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var snapshots []*Snapshot
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	for decoder.More() {
+		snapshot := &Snapshot{}
+		if err := decoder.Decode(snapshot); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
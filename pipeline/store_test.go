@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := NewFileStore(filepath.Join(dir, "snapshots.jsonl"))
+	if snapshots, err := file.Snapshots(); err != nil || len(snapshots) != 0 {
+		spew.Dump(err, snapshots)
+		t.Fatal("Expected no snapshots before the file exists")
+	}
+	for _, store := range []Store{NewMemoryStore(), file} {
+		for day := 1; day <= 2; day++ {
+			snapshot := &Snapshot{
+				Time:          time.Date(2022, 3, day, 0, 0, 0, 0, time.UTC),
+				Opportunities: []*cm.Opportunity{{UUID: "o1", PipelineStage: "Demo", EstimatedCloseDate: "2022-03-31"}},
+			}
+			if err := store.Save(snapshot); err != nil {
+				t.Fatal(err)
+			}
+		}
+		snapshots, err := store.Snapshots()
+		if err != nil || len(snapshots) != 2 || snapshots[1].Time.Day() != 2 ||
+			snapshots[0].Opportunities[0].EstimatedCloseDate != "2022-03-31" {
+			spew.Dump(err, snapshots)
+			t.Fatalf("Unexpected snapshots of %T", store)
+		}
+	}
+}
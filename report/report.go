@@ -0,0 +1,303 @@
+// Package report renders a revenue report of a month, eg. for the board, in Markdown or HTML
+// from Metrics API results and the account details, with month-over-month and year-over-year tables
+// and inline SVG charts. The templates can be replaced to customise the layout.
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"text/template"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// now is the time of generating reports, replaced in tests.
+var now = time.Now
+
+// Client retrieves the metrics and the account, eg. *chartmogul.API.
+type Client interface {
+	RetrieveAccount() (*cm.Account, error)
+	MetricsRetrieveAll(metricsFilter *cm.MetricsFilter) (*cm.MetricsResult, error)
+	MetricsRetrieveMRR(metricsFilter *cm.MetricsFilter) (*cm.MRRResult, error)
+}
+
+// Format of a rendered report.
+type Format string
+
+// Formats of the built-in templates.
+const (
+	Markdown Format = "markdown"
+	HTML     Format = "html"
+)
+
+// Kind of a metric decides how its values are formatted and how changes are computed.
+type Kind string
+
+// Kinds of metrics.
+const (
+	// Amount is in minor units of the account's currency, like MRR in Metrics API.
+	Amount Kind = "amount"
+	Count  Kind = "count"
+	// Rate is in percents, like churn rates in Metrics API.
+	Rate Kind = "rate"
+)
+
+// Month are the metrics at the end of a calendar month.
+type Month struct {
+	// Start is the first day of the month.
+	Start cm.Date
+	// HasData is false for months without an entry in the metrics, eg. before the first data,
+	// their metrics are zero.
+	HasData bool
+	Metrics *cm.AllMetrics
+	// MRR is the MRR with its movements within the month.
+	MRR *cm.MRRMetrics
+}
+
+// Row compares a metric in the reported month with an earlier month.
+type Row struct {
+	Metric            string
+	Kind              Kind
+	Current, Previous float64
+	// Change is the relative change in percents, for rates the difference in percentage points.
+	Change float64
+	// HasPrevious is false if the earlier month has no data; Previous and Change are zero then.
+	HasPrevious bool
+}
+
+// ChangeText formats the change with its sign, eg. "+12.5%", "-0.3 pp" for rates or "n/a" without an earlier value.
+func (r *Row) ChangeText() string {
+	switch {
+	case !r.HasPrevious:
+		return "n/a"
+	case r.Kind == Rate:
+		return fmt.Sprintf("%+.1f pp", r.Change)
+	case r.Previous == 0:
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", r.Change)
+}
+
+// Data is what the templates render.
+type Data struct {
+	Title       string
+	Account     *cm.Account
+	Currency    string
+	GeneratedAt time.Time
+	// Month is the reported month, Months the thirteen months up to it, oldest first.
+	Month  *Month
+	Months []*Month
+	// MoM compares the key metrics with the previous month, YoY with the same month a year earlier.
+	MoM []*Row
+	YoY []*Row
+	// Movements compares the MRR movements with the previous month.
+	Movements []*Row
+	// MRRChart and MovementsChart are SVG documents of MRR and its movements over Months.
+	MRRChart       string
+	MovementsChart string
+}
+
+// Format formats a value of the kind, eg. {{$.Format .Kind .Current}} in templates.
+func (d *Data) Format(kind Kind, value float64) string {
+	switch kind {
+	case Amount:
+		return formatMoney(value, d.Currency)
+	case Rate:
+		return formatPercent(value)
+	}
+	return fmt.Sprintf("%.0f", value)
+}
+
+// Generator retrieves the metrics of a month and renders them with its templates.
+type Generator struct {
+	// Title of the reports, "Revenue report" if empty.
+	Title string
+	// Filter narrows down the metrics, eg. by Geo or Plans; the generator sets dates and interval.
+	Filter *cm.MetricsFilter
+
+	client    Client
+	templates map[Format]*template.Template
+}
+
+// New creates a generator with the built-in Markdown and HTML templates.
+func New(client Client) *Generator {
+	g := &Generator{client: client, templates: map[Format]*template.Template{}}
+	for format, text := range defaultTemplates {
+		g.templates[format] = template.Must(template.New(string(format)).Funcs(Funcs).Parse(text))
+	}
+	return g
+}
+
+// SetTemplate replaces the template of a format, or adds one for a new format.
+// The text is a text/template rendering Data, with Funcs available.
+func (g *Generator) SetTemplate(format Format, text string) error {
+	t, err := template.New(string(format)).Funcs(Funcs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("chartmogul: template %v: %v", format, err)
+	}
+	g.templates[format] = t
+	return nil
+}
+
+// Generate builds the data of the month containing the date and renders it in the format.
+func (g *Generator) Generate(w io.Writer, format Format, month cm.Date) error {
+	data, err := g.Build(month)
+	if err != nil {
+		return err
+	}
+	return g.Render(w, format, data)
+}
+
+// Render renders the data in the format.
+func (g *Generator) Render(w io.Writer, format Format, data *Data) error {
+	t, ok := g.templates[format]
+	if !ok {
+		return fmt.Errorf("chartmogul: no template for format %q", format)
+	}
+	return t.Execute(w, data)
+}
+
+// Build retrieves the account and the monthly metrics of the thirteen months up to the month
+// containing the date, and compares the month with the previous one and with a year earlier.
+func (g *Generator) Build(month cm.Date) (*Data, error) {
+	t, err := month.Time()
+	if err != nil {
+		return nil, err
+	}
+	account, err := g.client.RetrieveAccount()
+	if err != nil {
+		return nil, err
+	}
+	weekStart := account.WeekStart()
+	start := cm.IntervalMonth.PeriodStart(t, weekStart)
+	first := cm.IntervalMonth.AddTo(start, -12)
+	filter := &cm.MetricsFilter{}
+	if g.Filter != nil {
+		*filter = *g.Filter
+	}
+	filter.StartDate = cm.NewDate(first)
+	filter.EndDate = cm.NewDate(cm.IntervalMonth.PeriodEnd(start, weekStart))
+	filter.Interval = cm.IntervalMonth
+
+	all, err := g.client.MetricsRetrieveAll(filter)
+	if err != nil {
+		return nil, err
+	}
+	mrr, err := g.client.MetricsRetrieveMRR(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &Data{
+		Title:       g.Title,
+		Account:     account,
+		Currency:    account.Currency,
+		GeneratedAt: now(),
+		Months:      make([]*Month, 13),
+	}
+	if data.Title == "" {
+		data.Title = "Revenue report"
+	}
+	index := map[cm.Date]int{}
+	for i := range data.Months {
+		m := cm.IntervalMonth.AddTo(first, i)
+		end := cm.NewDate(cm.IntervalMonth.PeriodEnd(m, weekStart))
+		data.Months[i] = &Month{Start: cm.NewDate(m), Metrics: &cm.AllMetrics{Date: end}, MRR: &cm.MRRMetrics{Date: end}}
+		index[data.Months[i].Start] = i
+	}
+	monthOf := func(date cm.Date) *Month {
+		t, err := date.Time()
+		if err != nil {
+			return nil
+		}
+		if i, ok := index[cm.NewDate(cm.IntervalMonth.PeriodStart(t, weekStart))]; ok {
+			return data.Months[i]
+		}
+		return nil
+	}
+	for _, entry := range all.Entries {
+		if m := monthOf(entry.Date); m != nil {
+			m.Metrics, m.HasData = entry, true
+		}
+	}
+	for _, entry := range mrr.Entries {
+		if m := monthOf(entry.Date); m != nil {
+			m.MRR = entry
+		}
+	}
+	data.Month = data.Months[12]
+	if !data.Month.HasData {
+		return nil, fmt.Errorf("chartmogul: no metrics for %v", data.Month.Start)
+	}
+
+	data.MoM = keyMetricRows(data.Month, data.Months[11])
+	data.YoY = keyMetricRows(data.Month, data.Months[0])
+	data.Movements = movementRows(data.Month, data.Months[11])
+	data.MRRChart = mrrChart(data.Months, data.Currency)
+	data.MovementsChart = movementsChart(data.Months, data.Currency)
+	return data, nil
+}
+
+// keyMetrics are the metrics of the MoM and YoY tables.
+var keyMetrics = []struct {
+	name  string
+	kind  Kind
+	value func(*cm.AllMetrics) float64
+}{
+	{"MRR", Amount, func(m *cm.AllMetrics) float64 { return m.Mrr }},
+	{"ARR", Amount, func(m *cm.AllMetrics) float64 { return m.Arr }},
+	{"Customers", Count, func(m *cm.AllMetrics) float64 { return float64(m.Customers) }},
+	{"ARPA", Amount, func(m *cm.AllMetrics) float64 { return m.Arpa }},
+	{"ASP", Amount, func(m *cm.AllMetrics) float64 { return m.Asp }},
+	{"Customer churn rate", Rate, func(m *cm.AllMetrics) float64 { return m.CustomerChurnRate }},
+	{"MRR churn rate", Rate, func(m *cm.AllMetrics) float64 { return m.MrrChurnRate }},
+	{"LTV", Amount, func(m *cm.AllMetrics) float64 { return m.Ltv }},
+}
+
+// movements are the MRR movements of the movements table and chart, in stacking order.
+var movements = []struct {
+	name  string
+	value func(*cm.MRRMetrics) float64
+}{
+	{"New business", func(m *cm.MRRMetrics) float64 { return m.MRRNewBusiness }},
+	{"Expansion", func(m *cm.MRRMetrics) float64 { return m.MRRExpansion }},
+	{"Reactivation", func(m *cm.MRRMetrics) float64 { return m.MRRReactivation }},
+	{"Contraction", func(m *cm.MRRMetrics) float64 { return m.MRRContraction }},
+	{"Churn", func(m *cm.MRRMetrics) float64 { return m.MRRChurn }},
+}
+
+func keyMetricRows(current, previous *Month) []*Row {
+	rows := make([]*Row, len(keyMetrics))
+	for i, metric := range keyMetrics {
+		rows[i] = newRow(metric.name, metric.kind, metric.value(current.Metrics), metric.value(previous.Metrics), previous.HasData)
+	}
+	return rows
+}
+
+func movementRows(current, previous *Month) []*Row {
+	rows := make([]*Row, 0, len(movements)+1)
+	var net, previousNet float64
+	for _, movement := range movements {
+		v, p := movement.value(current.MRR), movement.value(previous.MRR)
+		net, previousNet = net+v, previousNet+p
+		rows = append(rows, newRow(movement.name, Amount, v, p, previous.HasData))
+	}
+	return append(rows, newRow("Net MRR movement", Amount, net, previousNet, previous.HasData))
+}
+
+func newRow(metric string, kind Kind, current, previous float64, hasPrevious bool) *Row {
+	row := &Row{Metric: metric, Kind: kind, Current: current}
+	if !hasPrevious {
+		return row
+	}
+	row.Previous, row.HasPrevious = previous, true
+	switch {
+	case kind == Rate:
+		row.Change = current - previous
+	case previous != 0:
+		row.Change = (current - previous) / math.Abs(previous) * 100
+	}
+	return row
+}
@@ -0,0 +1,160 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// fakeClient serves monthly metrics from March 2021 on, missing the first year's February and March.
+type fakeClient struct {
+	filter *cm.MetricsFilter
+}
+
+func (c *fakeClient) RetrieveAccount() (*cm.Account, error) {
+	return &cm.Account{Name: "Acme & Co", Currency: "USD"}, nil
+}
+
+func (c *fakeClient) MetricsRetrieveAll(filter *cm.MetricsFilter) (*cm.MetricsResult, error) {
+	c.filter = filter
+	result := &cm.MetricsResult{}
+	for i, date := range []cm.Date{"2021-04-30", "2021-05-31", "2022-01-31", "2022-02-28", "2022-03-31"} {
+		result.Entries = append(result.Entries, &cm.AllMetrics{
+			Date: date, Mrr: float64(100000 + i*10000), Customers: uint32(10 + i), CustomerChurnRate: 2 + float64(i)/2,
+		})
+	}
+	return result, nil
+}
+
+func (c *fakeClient) MetricsRetrieveMRR(filter *cm.MetricsFilter) (*cm.MRRResult, error) {
+	return &cm.MRRResult{Entries: []*cm.MRRMetrics{
+		{Date: "2022-02-28", MRRNewBusiness: 5000, MRRChurn: -2000},
+		{Date: "2022-03-31", MRRNewBusiness: 8000, MRRExpansion: 4000, MRRChurn: -2000},
+	}}, nil
+}
+
+func TestBuild(t *testing.T) {
+	client := &fakeClient{}
+	g := New(client)
+	g.Filter = &cm.MetricsFilter{Geo: "US"}
+	data, err := g.Build("2022-03-15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.filter.StartDate != "2021-03-01" || client.filter.EndDate != "2022-03-31" ||
+		client.filter.Interval != cm.IntervalMonth || client.filter.Geo != "US" {
+		spew.Dump(client.filter)
+		t.Fatal("Unexpected filter")
+	}
+	if len(data.Months) != 13 || data.Month.Start != "2022-03-01" || data.Months[0].HasData || !data.Months[1].HasData {
+		spew.Dump(data.Months)
+		t.Fatal("Unexpected months")
+	}
+
+	mrr, customers, churn := data.MoM[0], data.MoM[2], data.MoM[5]
+	if mrr.Current != 140000 || mrr.Previous != 130000 || mrr.ChangeText() != "+7.7%" ||
+		customers.Current != 14 || churn.ChangeText() != "+0.5 pp" {
+		spew.Dump(data.MoM)
+		t.Fatal("Unexpected month over month")
+	}
+	if data.YoY[0].HasPrevious || data.YoY[0].ChangeText() != "n/a" {
+		spew.Dump(data.YoY)
+		t.Fatal("Expected no data a year earlier")
+	}
+	net := data.Movements[len(data.Movements)-1]
+	if net.Current != 10000 || net.Previous != 3000 || data.Movements[0].Change != 60 {
+		spew.Dump(data.Movements)
+		t.Fatal("Unexpected movements")
+	}
+
+	if _, err := g.Build("2022-04-01"); err == nil {
+		t.Fatal("Expected a month without metrics to fail")
+	}
+}
+
+func TestRender(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 4, 2, 9, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	g := New(&fakeClient{})
+	data, err := g.Build("2022-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	if err := g.Render(out, Markdown, data); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"# Revenue report: March 2022\n",
+		"| MRR | 1400.00 USD | 1300.00 USD | +7.7% |\n",
+		"| Customer churn rate | 4.0% | n/a | n/a |\n",
+		"![MRR](data:image/svg+xml;base64,",
+		"_Generated 2022-04-02 09:00 UTC from ChartMogul._",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Log(out.String())
+			t.Fatalf("Expected %q in Markdown", expected)
+		}
+	}
+
+	out.Reset()
+	if err := g.Render(out, HTML, data); err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+	if !strings.Contains(html, "<p>Acme &amp; Co, amounts in USD.</p>") || strings.Count(html, "<svg ") != 2 {
+		t.Log(html)
+		t.Fatal("Unexpected HTML")
+	}
+	for _, svg := range []string{data.MRRChart, data.MovementsChart} {
+		if err := wellFormed(svg); err != nil {
+			t.Log(svg)
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSetTemplate(t *testing.T) {
+	g := New(&fakeClient{})
+	if err := g.SetTemplate(Markdown, "{{range .MoM}}{{.Metric}}={{money .Current $.Currency}};{{end}}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetTemplate("slack", "*{{.Title}}* {{percent (index .MoM 0).Change}}"); err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := g.Generate(out, Markdown, "2022-03-01"); err != nil || !strings.HasPrefix(out.String(), "MRR=1400.00 USD;ARR=0.00 USD;") {
+		spew.Dump(err, out.String())
+		t.Fatal("Unexpected custom Markdown")
+	}
+	out.Reset()
+	if err := g.Generate(out, "slack", "2022-03-01"); err != nil || out.String() != "*Revenue report* 7.7%" {
+		spew.Dump(err, out.String())
+		t.Fatal("Unexpected custom format")
+	}
+	if err := g.SetTemplate(HTML, "{{.Unclosed"); err == nil {
+		t.Fatal("Expected an invalid template to fail")
+	}
+	if err := g.Generate(out, "pdf", "2022-03-01"); err == nil {
+		t.Fatal("Expected an unknown format to fail")
+	}
+}
+
+// wellFormed parses the document as XML.
+func wellFormed(document string) error {
+	d := xml.NewDecoder(strings.NewReader(document))
+	for {
+		if _, err := d.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
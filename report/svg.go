@@ -0,0 +1,176 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Chart dimensions in pixels.
+const (
+	chartWidth  = 640
+	chartHeight = 260
+	chartLeft   = 70
+	chartRight  = 20
+	chartTop    = 30
+	chartBottom = 40
+)
+
+// movementColors are the colors of movements, in the order of movements.
+var movementColors = []string{"#2e7d32", "#66bb6a", "#42a5f5", "#ffa726", "#e53935"}
+
+// plot maps values onto the plot area of a chart.
+type plot struct {
+	min, max float64
+	slots    int
+	currency string
+}
+
+// newPlot fits the values, always including zero, into slots for amounts in the currency.
+func newPlot(slots int, currency string, values ...float64) plot {
+	p := plot{slots: slots, currency: currency}
+	for _, v := range values {
+		p.min, p.max = math.Min(p.min, v), math.Max(p.max, v)
+	}
+	if p.max == p.min {
+		p.max = p.min + 1
+	}
+	return p
+}
+
+// y returns the vertical position of a value.
+func (p plot) y(v float64) float64 {
+	h := float64(chartHeight - chartTop - chartBottom)
+	return chartTop + h*(p.max-v)/(p.max-p.min)
+}
+
+// x returns the horizontal center of a slot.
+func (p plot) x(slot int) float64 {
+	w := float64(chartWidth - chartLeft - chartRight)
+	return chartLeft + w*(float64(slot)+0.5)/float64(p.slots)
+}
+
+// slotWidth returns the width of a slot.
+func (p plot) slotWidth() float64 {
+	return float64(chartWidth-chartLeft-chartRight) / float64(p.slots)
+}
+
+// frame writes the opening tag, title, axes with the zero line, value labels and month labels.
+func (p plot) frame(b *strings.Builder, title string, months []*Month) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(b, `<title>%s</title>`, html.EscapeString(title))
+	fmt.Fprintf(b, `<text x="%d" y="18" font-size="13" font-weight="bold">%s</text>`, chartLeft, html.EscapeString(title))
+	ticks := []float64{p.max, 0}
+	if p.min < 0 {
+		ticks = append(ticks, p.min)
+	}
+	for _, v := range ticks {
+		fmt.Fprintf(b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ccc"/>`, chartLeft, p.y(v), chartWidth-chartRight, p.y(v))
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`, chartLeft-6, p.y(v)+4, axisLabel(v, p.currency))
+	}
+	for i, m := range months {
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, p.x(i), chartHeight-chartBottom+16, monthLabel(m.Start))
+	}
+}
+
+// mrrChart draws the MRR of the months as a line, months without data are left out.
+func mrrChart(months []*Month, currency string) string {
+	values := make([]float64, 0, len(months))
+	for _, m := range months {
+		values = append(values, m.Metrics.Mrr)
+	}
+	p := newPlot(len(months), currency, values...)
+	b := &strings.Builder{}
+	p.frame(b, "MRR", months)
+	var points []string
+	for i, m := range months {
+		if !m.HasData {
+			continue
+		}
+		points = append(points, fmt.Sprintf("%.1f,%.1f", p.x(i), p.y(m.Metrics.Mrr)))
+		fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="3" fill="#1565c0"/>`, p.x(i), p.y(m.Metrics.Mrr))
+	}
+	fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="#1565c0" stroke-width="2"/>`, strings.Join(points, " "))
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// movementsChart draws the MRR movements of the months as bars stacked up from zero for gains
+// and down from zero for losses.
+func movementsChart(months []*Month, currency string) string {
+	var values []float64
+	for _, m := range months {
+		var up, down float64
+		for _, movement := range movements {
+			if v := movement.value(m.MRR); v > 0 {
+				up += v
+			} else {
+				down += v
+			}
+		}
+		values = append(values, up, down)
+	}
+	p := newPlot(len(months), currency, values...)
+	b := &strings.Builder{}
+	p.frame(b, "MRR movements", months)
+	w := p.slotWidth() * 0.6
+	for i, m := range months {
+		up, down := 0.0, 0.0
+		for j, movement := range movements {
+			v := movement.value(m.MRR)
+			if v == 0 {
+				continue
+			}
+			from := &up
+			if v < 0 {
+				from = &down
+			}
+			y1, y2 := p.y(*from), p.y(*from+v)
+			*from += v
+			fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s</title></rect>`,
+				p.x(i)-w/2, math.Min(y1, y2), w, math.Abs(y2-y1), movementColors[j], html.EscapeString(movement.name))
+		}
+	}
+	for j, movement := range movements {
+		x := chartLeft + 130 + j*85
+		fmt.Fprintf(b, `<rect x="%d" y="9" width="10" height="10" fill="%s"/><text x="%d" y="18">%s</text>`,
+			x, movementColors[j], x+14, html.EscapeString(movement.name))
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// axisLabel formats an amount in minor units of the currency shortly, eg. 1.2M for 120,000,000 cents.
+func axisLabel(v float64, currency string) string {
+	v /= math.Pow10(cm.CurrencyExponent(currency))
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e6:
+		return fmt.Sprintf("%.1fM", v/1e6)
+	case abs >= 1e3:
+		return fmt.Sprintf("%.1fk", v/1e3)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+// monthLabel formats the month of the date shortly, eg. "Jan 22".
+func monthLabel(date cm.Date) string {
+	t, err := date.Time()
+	if err != nil {
+		return date.String()
+	}
+	return t.Format("Jan 06")
+}
+
+// monthName formats the month of the date, eg. "January 2022".
+func monthName(date cm.Date) string {
+	t, err := date.Time()
+	if err != nil {
+		return date.String()
+	}
+	return t.Format("January 2006")
+}
@@ -0,0 +1,108 @@
+package report
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"text/template"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Funcs are the functions available in templates besides the text/template built-ins:
+//
+//	money     formats an amount in minor units with the currency, eg. {{money .Current $.Currency}}
+//	percent   formats a percentage, eg. {{percent 12.5}} is "12.5%"
+//	month     formats the month of a date, eg. {{month .Month.Start}} is "January 2022"
+//	dataURI   encodes an SVG document as a data URI for an image, eg. in Markdown
+var Funcs = template.FuncMap{
+	"money":   formatMoney,
+	"percent": formatPercent,
+	"month":   monthName,
+	"dataURI": svgDataURI,
+}
+
+// formatMoney formats an amount in minor units of the currency in major units, eg. "1234.50 USD".
+func formatMoney(amount float64, currency string) string {
+	return cm.NewMoney(int64(math.Round(amount)), currency).String()
+}
+
+func formatPercent(value float64) string {
+	return fmt.Sprintf("%.1f%%", value)
+}
+
+func svgDataURI(svg string) string {
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg))
+}
+
+// defaultTemplates are the built-in templates by format.
+var defaultTemplates = map[Format]string{
+	Markdown: markdownTemplate,
+	HTML:     htmlTemplate,
+}
+
+const markdownTemplate = `# {{.Title}}: {{month .Month.Start}}
+
+{{.Account.Name}}, amounts in {{.Currency}}.
+
+## Month over month
+
+| Metric | {{month .Month.Start}} | Previous month | Change |
+| --- | ---: | ---: | ---: |
+{{range .MoM}}| {{.Metric}} | {{$.Format .Kind .Current}} | {{if .HasPrevious}}{{$.Format .Kind .Previous}}{{else}}n/a{{end}} | {{.ChangeText}} |
+{{end}}
+## Year over year
+
+| Metric | {{month .Month.Start}} | A year earlier | Change |
+| --- | ---: | ---: | ---: |
+{{range .YoY}}| {{.Metric}} | {{$.Format .Kind .Current}} | {{if .HasPrevious}}{{$.Format .Kind .Previous}}{{else}}n/a{{end}} | {{.ChangeText}} |
+{{end}}
+## MRR movements
+
+| Movement | {{month .Month.Start}} | Previous month | Change |
+| --- | ---: | ---: | ---: |
+{{range .Movements}}| {{.Metric}} | {{$.Format .Kind .Current}} | {{if .HasPrevious}}{{$.Format .Kind .Previous}}{{else}}n/a{{end}} | {{.ChangeText}} |
+{{end}}
+![MRR]({{dataURI .MRRChart}})
+
+![MRR movements]({{dataURI .MovementsChart}})
+
+_Generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}} from ChartMogul._
+`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{html .Title}}: {{month .Month.Start}}</title>
+<style>
+body { font-family: sans-serif; max-width: 720px; margin: 2em auto; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #ddd; }
+td.number, th.number { text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{html .Title}}: {{month .Month.Start}}</h1>
+<p>{{html .Account.Name}}, amounts in {{html .Currency}}.</p>
+<h2>Month over month</h2>
+<table>
+<tr><th>Metric</th><th class="number">{{month .Month.Start}}</th><th class="number">Previous month</th><th class="number">Change</th></tr>
+{{range .MoM}}<tr><td>{{html .Metric}}</td><td class="number">{{$.Format .Kind .Current}}</td><td class="number">{{if .HasPrevious}}{{$.Format .Kind .Previous}}{{else}}n/a{{end}}</td><td class="number">{{.ChangeText}}</td></tr>
+{{end}}</table>
+<h2>Year over year</h2>
+<table>
+<tr><th>Metric</th><th class="number">{{month .Month.Start}}</th><th class="number">A year earlier</th><th class="number">Change</th></tr>
+{{range .YoY}}<tr><td>{{html .Metric}}</td><td class="number">{{$.Format .Kind .Current}}</td><td class="number">{{if .HasPrevious}}{{$.Format .Kind .Previous}}{{else}}n/a{{end}}</td><td class="number">{{.ChangeText}}</td></tr>
+{{end}}</table>
+<h2>MRR movements</h2>
+<table>
+<tr><th>Movement</th><th class="number">{{month .Month.Start}}</th><th class="number">Previous month</th><th class="number">Change</th></tr>
+{{range .Movements}}<tr><td>{{html .Metric}}</td><td class="number">{{$.Format .Kind .Current}}</td><td class="number">{{if .HasPrevious}}{{$.Format .Kind .Previous}}{{else}}n/a{{end}}</td><td class="number">{{.ChangeText}}</td></tr>
+{{end}}</table>
+<figure>{{.MRRChart}}</figure>
+<figure>{{.MovementsChart}}</figure>
+<p><small>Generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}} from ChartMogul.</small></p>
+</body>
+</html>
+`
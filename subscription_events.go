@@ -9,13 +9,13 @@ type SubscriptionEvent struct {
 	SubscriptionSetExternalID string      `json:"subscription_set_external_id,omitempty"`
 	SubscriptionExternalID    string      `json:"subscription_external_id,omitempty"`
 	PlanExternalID            string      `json:"plan_external_id,omitempty"`
-	EventDate                 string      `json:"event_date,omitempty"`
-	EffectiveDate             string      `json:"effective_date,omitempty"`
+	EventDate                 Timestamp   `json:"event_date,omitempty"`
+	EffectiveDate             Timestamp   `json:"effective_date,omitempty"`
 	EventType                 string      `json:"event_type,omitempty"`
 	ExternalID                string      `json:"external_id,omitempty"`
 	Errors                    interface{} `json:"errors,omitempty"`
-	CreatedAt                 string      `json:"created_at,omitempty"`
-	UpdatedAt                 string      `json:"updated_at,omitempty"`
+	CreatedAt                 Timestamp   `json:"created_at,omitempty"`
+	UpdatedAt                 Timestamp   `json:"updated_at,omitempty"`
 	Quantity                  int32       `json:"quantity,omitempty"`
 	Currency                  string      `json:"currency,omitempty"`
 	AmountInCents             int32       `json:"amount_in_cents,omitempty"`
@@ -36,14 +36,14 @@ type DeleteSubscriptionEvent struct {
 }
 
 type FilterSubscriptionEvents struct {
-	CustomerExternalID     string `json:"customer_external_id,omitempty"`
-	DataSourceUUID         string `json:"data_source_uuid,omitempty"`
-	EffectiveDate          string `json:"effective_date,omitempty"`
-	EventDate              string `json:"event_date,omitempty"`
-	EventType              string `json:"event_type,omitempty"`
-	ExternalID             string `json:"external_id,omitempty"`
-	PlanExternalID         string `json:"plan_external_id,omitempty"`
-	SubscriptionExternalID string `json:"subscription_external_id,omitempty"`
+	CustomerExternalID     string    `json:"customer_external_id,omitempty"`
+	DataSourceUUID         string    `json:"data_source_uuid,omitempty"`
+	EffectiveDate          Timestamp `json:"effective_date,omitempty"`
+	EventDate              Timestamp `json:"event_date,omitempty"`
+	EventType              string    `json:"event_type,omitempty"`
+	ExternalID             string    `json:"external_id,omitempty"`
+	PlanExternalID         string    `json:"plan_external_id,omitempty"`
+	SubscriptionExternalID string    `json:"subscription_external_id,omitempty"`
 }
 
 type DeleteSubscriptionEventParams struct {
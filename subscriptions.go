@@ -28,7 +28,7 @@ type Subscriptions struct {
 
 // CancelSubscriptionParams represents arguments to be marshalled into JSON.
 type CancelSubscriptionParams struct {
-	CancelledAt       string    `json:"cancelled_at,omitempty"`
+	CancelledAt       Timestamp `json:"cancelled_at,omitempty"`
 	CancellationDates *[]string `json:"cancellation_dates,omitempty"`
 }
 
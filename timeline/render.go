@@ -0,0 +1,55 @@
+package timeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// EncodeJSON writes the timeline as JSON.
+func (t *Timeline) EncodeJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t)
+}
+
+// EncodeText writes the timeline as plain text for support tickets, an event per line
+// with its time in the location, eg. the account's time zone, followed by the contacts.
+func (t *Timeline) EncodeText(w io.Writer, loc *time.Location) error {
+	if loc == nil {
+		loc = time.UTC
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "Timeline of %v\n\n", t.CustomerUUID)
+	for _, event := range t.Events {
+		fmt.Fprintf(out, "%v  %-18v  %v\n", event.Time.In(loc).Format("2006-01-02 15:04 MST"), event.Kind, oneLine(event.Summary))
+	}
+	if len(t.Events) == 0 {
+		fmt.Fprintln(out, "No events.")
+	}
+	if len(t.Undated) != 0 {
+		fmt.Fprintf(out, "\nUndated:\n")
+	}
+	for _, event := range t.Undated {
+		fmt.Fprintf(out, "- %v: %v\n", event.Kind, oneLine(event.Summary))
+	}
+	if len(t.Contacts) != 0 {
+		fmt.Fprintf(out, "\nContacts:\n")
+	}
+	for _, contact := range t.Contacts {
+		details := []string{strings.TrimSpace(contact.FirstName + " " + contact.LastName)}
+		for _, detail := range []string{contact.Title, contact.Email, contact.Phone, contact.LinkedIn} {
+			if detail != "" {
+				details = append(details, detail)
+			}
+		}
+		fmt.Fprintf(out, "- %v\n", strings.Join(details, ", "))
+	}
+	return out.Flush()
+}
+
+// oneLine joins the lines of a text, eg. of a note.
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,321 @@
+// Package timeline merges everything known about a customer, eg. activities, invoices, notes and
+// subscription events, into one chronological stream of events for support and success teams.
+package timeline
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+)
+
+// Client retrieves the customer's records, eg. *chartmogul.API.
+type Client interface {
+	cm.IApi
+	ListCustomerOpporunities(listOpportunitiesParams *cm.ListOpportunitiesParams, customerUUID string) (*cm.Opportunities, error)
+}
+
+// Kind of a timeline event.
+type Kind string
+
+// Kinds of events, by the record they come from.
+const (
+	Activity          Kind = "activity"
+	Invoice           Kind = "invoice"
+	Transaction       Kind = "transaction"
+	Note              Kind = "note"
+	Opportunity       Kind = "opportunity"
+	SubscriptionEvent Kind = "subscription_event"
+)
+
+// Kinds are all kinds of events.
+var Kinds = []Kind{Activity, Invoice, Transaction, Note, Opportunity, SubscriptionEvent}
+
+// Event is a dated record of a customer. The record of its kind is set, for transactions with their invoice.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Kind    Kind      `json:"kind"`
+	Summary string    `json:"summary"`
+
+	Activity          *cm.MetricsCustomerActivity `json:"activity,omitempty"`
+	Invoice           *cm.Invoice                 `json:"invoice,omitempty"`
+	Transaction       *cm.Transaction             `json:"transaction,omitempty"`
+	Note              *cm.Note                    `json:"note,omitempty"`
+	Opportunity       *cm.Opportunity             `json:"opportunity,omitempty"`
+	SubscriptionEvent *cm.SubscriptionEvent       `json:"subscription-event,omitempty"`
+}
+
+// Timeline is a customer's events in chronological order.
+type Timeline struct {
+	CustomerUUID string   `json:"customer-uuid"`
+	Events       []*Event `json:"events"`
+	// Undated are the events whose record has no valid date, eg. "2022-13-01", left out of Events.
+	Undated []*Event `json:"undated,omitempty"`
+	// Contacts aren't dated and are listed apart.
+	Contacts []*cm.Contact `json:"contacts"`
+}
+
+// Options narrow down a timeline, the zero value is everything.
+type Options struct {
+	// Kinds of events, all if empty. Only the records of these kinds are retrieved.
+	Kinds []Kind
+	// From and To limit the events to a time range, both inclusive, unbounded if zero.
+	From, To time.Time
+}
+
+func (o *Options) has(kind Kind) bool {
+	if o == nil || len(o.Kinds) == 0 {
+		return true
+	}
+	for _, k := range o.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) within(t time.Time) bool {
+	return o == nil || (o.From.IsZero() || !t.Before(o.From)) && (o.To.IsZero() || !t.After(o.To))
+}
+
+// Filter returns a copy of the timeline with the events matching the options.
+func (t *Timeline) Filter(options *Options) *Timeline {
+	filtered := &Timeline{CustomerUUID: t.CustomerUUID, Events: []*Event{}, Contacts: t.Contacts}
+	for _, event := range t.Events {
+		if options.has(event.Kind) && options.within(event.Time) {
+			filtered.Events = append(filtered.Events, event)
+		}
+	}
+	for _, event := range t.Undated {
+		if options.has(event.Kind) {
+			filtered.Undated = append(filtered.Undated, event)
+		}
+	}
+	return filtered
+}
+
+// CustomerTimeline retrieves the customer's activities, invoices with their transactions, notes, opportunities,
+// subscription events and contacts concurrently and merges them into one timeline. Options can be nil.
+//
+// Activities, invoices and transactions are dated when they happened, notes and opportunities when
+// they were created, subscription events when they take effect. Records without a valid date are Undated.
+func CustomerTimeline(client Client, customerUUID string, options *Options) (*Timeline, error) {
+	var lock sync.Mutex
+	t := &Timeline{CustomerUUID: customerUUID, Events: []*Event{}, Contacts: []*cm.Contact{}}
+	add := func(ts cm.Timestamp, event *Event) {
+		if !options.has(event.Kind) {
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		at, err := ts.Time()
+		switch {
+		case err != nil:
+			t.Undated = append(t.Undated, event)
+		case options.within(at):
+			event.Time = at
+			t.Events = append(t.Events, event)
+		}
+	}
+
+	var fetches []func() error
+	if options.has(Activity) {
+		fetches = append(fetches, func() error { return fetchActivities(client, customerUUID, add) })
+	}
+	if options.has(Invoice) || options.has(Transaction) {
+		fetches = append(fetches, func() error { return fetchInvoices(client, customerUUID, add) })
+	}
+	if options.has(Note) {
+		fetches = append(fetches, func() error { return fetchNotes(client, customerUUID, add) })
+	}
+	if options.has(Opportunity) {
+		fetches = append(fetches, func() error { return fetchOpportunities(client, customerUUID, add) })
+	}
+	if options.has(SubscriptionEvent) {
+		fetches = append(fetches, func() error { return fetchSubscriptionEvents(client, customerUUID, add) })
+	}
+	fetches = append(fetches, func() error {
+		contacts, err := fetchContacts(client, customerUUID)
+		t.Contacts = contacts
+		return err
+	})
+
+	if err := cm.Parallel(fetches...); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(t.Events, func(i, j int) bool {
+		a, b := t.Events[i], t.Events[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		return kindOrder(a.Kind) < kindOrder(b.Kind)
+	})
+	sort.SliceStable(t.Undated, func(i, j int) bool { return kindOrder(t.Undated[i].Kind) < kindOrder(t.Undated[j].Kind) })
+	return t, nil
+}
+
+// kindOrder orders events at the same time, eg. an invoice before its payment.
+func kindOrder(kind Kind) int {
+	for i, k := range Kinds {
+		if k == kind {
+			return i
+		}
+	}
+	return len(Kinds)
+}
+
+type adder func(ts cm.Timestamp, event *Event)
+
+func fetchActivities(client Client, customerUUID string, add adder) error {
+	it := cm.NewMetricsCustomerActivitiesIterator(client, customerUUID, nil)
+	for {
+		activity, err := it.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		summary := activity.Description
+		if summary == "" {
+			summary = activity.Type
+		}
+		movement := minor(activity.ActivityMrrMovement, activity.Currency).String()
+		if activity.ActivityMrrMovement >= 0 {
+			movement = "+" + movement
+		}
+		summary = fmt.Sprintf("%v, MRR %v to %v", summary, movement, minor(activity.ActivityMrr, activity.Currency))
+		add(activity.Date, &Event{Kind: Activity, Summary: summary, Activity: activity})
+	}
+}
+
+// minor returns an amount in minor units as returned by Metrics API as Money.
+func minor(amount float64, currency string) cm.Money {
+	return cm.NewMoney(int64(math.Round(amount)), currency)
+}
+
+func fetchInvoices(client Client, customerUUID string, add adder) error {
+	cursor := &cm.Cursor{}
+	for {
+		result, err := client.ListInvoices(cursor, customerUUID)
+		if err != nil {
+			return err
+		}
+		for _, invoice := range result.Invoices {
+			summary := fmt.Sprintf("Invoice %v of %v", invoice.ExternalID, invoice.Total())
+			add(invoice.Date, &Event{Kind: Invoice, Summary: summary, Invoice: invoice})
+			for _, transaction := range invoice.Transactions {
+				amount, ok := transaction.Amount(invoice.Currency)
+				if !ok {
+					amount = invoice.Total()
+				}
+				summary := fmt.Sprintf("%v %v of %v for invoice %v", transaction.Result, transaction.Type, amount, invoice.ExternalID)
+				event := &Event{Kind: Transaction, Summary: summary, Invoice: invoice, Transaction: transaction}
+				add(transaction.Date, event)
+			}
+		}
+		if !result.HasMore || result.Cursor == "" {
+			return nil
+		}
+		cursor.Cursor = result.Cursor
+	}
+}
+
+func fetchNotes(client Client, customerUUID string, add adder) error {
+	it := cm.NewCustomerNotesIterator(client, customerUUID, nil)
+	for {
+		note, err := it.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		summary := fmt.Sprintf("%v by %v: %v", note.Type, note.Author, note.Text)
+		add(note.CreatedAt, &Event{Kind: Note, Summary: summary, Note: note})
+	}
+}
+
+func fetchOpportunities(client Client, customerUUID string, add adder) error {
+	it := cm.NewCustomerOpportunitiesIterator(client, customerUUID, nil)
+	for {
+		opportunity, err := it.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		summary := fmt.Sprintf("Opportunity of %v in %v/%v, owned by %v", opportunity.Amount(),
+			opportunity.Pipeline, opportunity.PipelineStage, opportunity.Owner)
+		add(opportunity.CreatedAt, &Event{Kind: Opportunity, Summary: summary, Opportunity: opportunity})
+	}
+}
+
+// fetchSubscriptionEvents lists the events of each of the customer's external IDs in each of its data sources.
+func fetchSubscriptionEvents(client Client, customerUUID string, add adder) error {
+	customer, err := client.RetrieveCustomer(customerUUID)
+	if err != nil {
+		return err
+	}
+	externalIDs := customer.ExternalIDs
+	if len(externalIDs) == 0 && customer.ExternalID != "" {
+		externalIDs = []string{customer.ExternalID}
+	}
+	dataSourceUUIDs := customer.DataSourceUUIDs
+	if len(dataSourceUUIDs) == 0 {
+		dataSourceUUIDs = []string{customer.DataSourceUUID}
+	}
+	seen := map[uint64]bool{}
+	for _, externalID := range externalIDs {
+		for _, dataSourceUUID := range dataSourceUUIDs {
+			filters := &cm.FilterSubscriptionEvents{CustomerExternalID: externalID, DataSourceUUID: dataSourceUUID}
+			cursor := &cm.Cursor{}
+			for {
+				result, err := client.ListSubscriptionEvents(filters, cursor)
+				if err != nil {
+					return err
+				}
+				for _, event := range result.SubscriptionEvents {
+					if seen[event.ID] {
+						continue
+					}
+					seen[event.ID] = true
+					date := event.EffectiveDate
+					if date.IsZero() {
+						date = event.EventDate
+					}
+					summary := fmt.Sprintf("%v of subscription %v, plan %v", event.EventType, event.SubscriptionExternalID, event.PlanExternalID)
+					add(date, &Event{Kind: SubscriptionEvent, Summary: summary, SubscriptionEvent: event})
+				}
+				if !result.HasMore || result.Cursor == "" {
+					break
+				}
+				cursor.Cursor = result.Cursor
+			}
+		}
+	}
+	return nil
+}
+
+func fetchContacts(client Client, customerUUID string) ([]*cm.Contact, error) {
+	contacts := []*cm.Contact{}
+	params := &cm.ListContactsParams{}
+	for {
+		result, err := client.ListCustomersContacts(params, customerUUID)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, result.Entries...)
+		if !result.HasMore || result.Cursor == "" {
+			return contacts, nil
+		}
+		params.Cursor.Cursor = result.Cursor
+	}
+}
@@ -0,0 +1,173 @@
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cm "github.com/chartmogul/chartmogul-go/v4"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// fakeClient serves one record of each kind and counts the requests.
+type fakeClient struct {
+	cm.IApi
+	lock     sync.Mutex
+	requests map[string]int
+	fail     bool
+}
+
+func (c *fakeClient) count(endpoint string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.requests[endpoint]++
+}
+
+func (c *fakeClient) MetricsListCustomerActivities(cursor *cm.Cursor, customerUUID string) (*cm.MetricsCustomerActivities, error) {
+	c.count("activities")
+	return &cm.MetricsCustomerActivities{Entries: []*cm.MetricsCustomerActivity{
+		{ID: 1, Date: "2022-01-10T10:00:00Z", Type: "new_biz", Description: "purchased the Pro plan", ActivityMrr: 5000, ActivityMrrMovement: 5000, Currency: "USD"},
+	}}, nil
+}
+
+func (c *fakeClient) ListInvoices(cursor *cm.Cursor, customerUUID string) (*cm.Invoices, error) {
+	c.count("invoices")
+	if cursor.Cursor == "" {
+		return &cm.Invoices{Pagination: cm.Pagination{HasMore: true, Cursor: "c2"}}, nil
+	}
+	return &cm.Invoices{Invoices: []*cm.Invoice{{
+		ExternalID: "inv_1", Currency: "USD", Date: "2022-01-10T10:00:00Z",
+		LineItems:    []*cm.LineItem{{AmountInCents: 5000}},
+		Transactions: []*cm.Transaction{{Date: "2022-01-10T10:00:00Z", Type: "payment", Result: "successful"}},
+	}}}, nil
+}
+
+func (c *fakeClient) ListCustomerNotes(params *cm.ListNotesParams, customerUUID string) (*cm.Notes, error) {
+	c.count("notes")
+	return &cm.Notes{Entries: []*cm.Note{
+		{Type: "call", Author: "Ann", Text: "Asked about\nannual billing", CreatedAt: "2022-03-01T09:00:00Z"},
+		{Type: "note", Author: "Bob", Text: "Imported", CreatedAt: "2022-13-01T09:00:00Z"},
+	}}, nil
+}
+
+func (c *fakeClient) ListCustomerOpporunities(params *cm.ListOpportunitiesParams, customerUUID string) (*cm.Opportunities, error) {
+	c.count("opportunities")
+	if c.fail {
+		return nil, errors.New("unavailable")
+	}
+	return &cm.Opportunities{Entries: []*cm.Opportunity{{
+		Pipeline: "Sales", PipelineStage: "Demo", Owner: "bob@example.com", AmountInCents: 100000, Currency: "USD", CreatedAt: "2021-12-20T00:00:00Z",
+	}}}, nil
+}
+
+func (c *fakeClient) RetrieveCustomer(customerUUID string) (*cm.Customer, error) {
+	c.count("customer")
+	return &cm.Customer{UUID: customerUUID, ExternalIDs: []string{"ext_1", "ext_2"}, DataSourceUUID: "ds_1", DataSourceUUIDs: []string{"ds_1", "ds_2"}}, nil
+}
+
+func (c *fakeClient) ListSubscriptionEvents(filters *cm.FilterSubscriptionEvents, cursor *cm.Cursor) (*cm.SubscriptionEvents, error) {
+	c.count("subscription events")
+	// The same event is listed for both external IDs.
+	switch filters.DataSourceUUID {
+	case "ds_1":
+		return &cm.SubscriptionEvents{SubscriptionEvents: []*cm.SubscriptionEvent{
+			{ID: 7, EventType: "subscription_start_scheduled", SubscriptionExternalID: "sub_1", PlanExternalID: "pro",
+				EventDate: "2022-01-01T00:00:00Z", EffectiveDate: "2022-01-10T00:00:00Z"},
+		}}, nil
+	case "ds_2":
+		return &cm.SubscriptionEvents{SubscriptionEvents: []*cm.SubscriptionEvent{
+			{ID: 8, EventType: "subscription_start", SubscriptionExternalID: "sub_2", PlanExternalID: "basic",
+				EventDate: "2022-01-10T00:00:00Z"},
+		}}, nil
+	}
+	return nil, errors.New("expected a data source")
+}
+
+func (c *fakeClient) ListCustomersContacts(params *cm.ListContactsParams, customerUUID string) (*cm.Contacts, error) {
+	c.count("contacts")
+	return &cm.Contacts{Entries: []*cm.Contact{{FirstName: "Ann", LastName: "Lee", Title: "CTO", Email: "ann@example.com"}}}, nil
+}
+
+func TestCustomerTimeline(t *testing.T) {
+	client := &fakeClient{requests: map[string]int{}}
+	timeline, err := CustomerTimeline(client, "cus_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Kind{Opportunity, SubscriptionEvent, SubscriptionEvent, Activity, Invoice, Transaction, Note}
+	if len(timeline.Events) != len(expected) || len(timeline.Contacts) != 1 || client.requests["invoices"] != 2 ||
+		len(timeline.Undated) != 1 || timeline.Undated[0].Note.Author != "Bob" {
+		spew.Dump(timeline, client.requests)
+		t.Fatal("Unexpected timeline")
+	}
+	for i, event := range timeline.Events {
+		if event.Kind != expected[i] {
+			spew.Dump(timeline.Events)
+			t.Fatalf("Unexpected event %v", i)
+		}
+	}
+	if timeline.Events[2].SubscriptionEvent.ID != 8 ||
+		timeline.Events[3].Summary != "purchased the Pro plan, MRR +50.00 USD to 50.00 USD" ||
+		timeline.Events[5].Summary != "successful payment of 50.00 USD for invoice inv_1" || timeline.Events[5].Invoice == nil {
+		spew.Dump(timeline.Events)
+		t.Fatal("Unexpected summaries")
+	}
+
+	out := &bytes.Buffer{}
+	if err := timeline.EncodeText(out, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"2022-01-10 10:00 UTC  transaction         successful payment of 50.00 USD for invoice inv_1\n",
+		"2022-03-01 09:00 UTC  note                call by Ann: Asked about annual billing\n",
+		"Undated:\n- note: note by Bob: Imported\n",
+		"- Ann Lee, CTO, ann@example.com\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Log(out.String())
+			t.Fatalf("Expected %q in the text", line)
+		}
+	}
+
+	out.Reset()
+	if err := timeline.EncodeJSON(out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Timeline
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded.Events) != 7 || decoded.Events[6].Note == nil ||
+		decoded.CustomerUUID != "cus_1" || decoded.Events[1].SubscriptionEvent == nil {
+		spew.Dump(err, decoded)
+		t.Fatal("Unexpected JSON")
+	}
+
+	filtered := timeline.Filter(&Options{Kinds: []Kind{Activity, Note}, To: time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)})
+	if len(filtered.Events) != 1 || filtered.Events[0].Kind != Activity || len(filtered.Undated) != 1 {
+		spew.Dump(filtered.Events)
+		t.Fatal("Unexpected filtered timeline")
+	}
+}
+
+func TestCustomerTimelineOptions(t *testing.T) {
+	client := &fakeClient{requests: map[string]int{}}
+	timeline, err := CustomerTimeline(client, "cus_1", &Options{
+		Kinds: []Kind{Transaction, Note},
+		From:  time.Date(2022, 1, 10, 10, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timeline.Events) != 2 || timeline.Events[0].Kind != Transaction ||
+		client.requests["activities"] != 0 || client.requests["opportunities"] != 0 || client.requests["customer"] != 0 {
+		spew.Dump(timeline.Events, client.requests)
+		t.Fatal("Expected only transactions and notes to be retrieved")
+	}
+
+	client.fail = true
+	if _, err := CustomerTimeline(client, "cus_1", nil); err == nil {
+		t.Fatal("Expected a failed request to fail")
+	}
+}